@@ -2,19 +2,35 @@ package component
 
 import (
 	"ai_jianli_go/config"
+	"ai_jianli_go/pkg/llm"
 	"context"
+	"fmt"
 
 	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/model"
 )
 
+// 使用大模型的功能，对应 ai_config.yaml 中 features 的键
+const (
+	FeatureInterview = "interview"
+	FeatureRemark    = "remark"
+	FeatureResume    = "resume"
+	FeatureWikiQA    = "wiki_qa"
+	FeatureRerank    = "rerank"
+	FeatureQuestion  = "question"
+)
+
+// 未配置功能模型时使用的模型名称
+const defaultModelName = "gpt-4o"
+
 type AIComponent struct {
-	ChatModel map[string]*openai.ChatModel
+	ChatModel map[string]model.ChatModel
 	config    *config.AIConfig
 }
 
 func NewAIComponent(aiConfig *config.AIConfig) *AIComponent {
 	component := &AIComponent{
-		ChatModel: make(map[string]*openai.ChatModel),
+		ChatModel: make(map[string]model.ChatModel),
 		config:    aiConfig,
 	}
 	component.initChatModel()
@@ -23,26 +39,50 @@ func NewAIComponent(aiConfig *config.AIConfig) *AIComponent {
 
 var AIComponentInstance *AIComponent
 
-func (ac *AIComponent) GetChatModel(modelName string) *openai.ChatModel {
+func (ac *AIComponent) GetChatModel(modelName string) model.ChatModel {
 	return ac.ChatModel[modelName]
 }
 
-func (ac *AIComponent) AddChatModel(modelName string, model *openai.ChatModel) {
+// GetFeatureModel 获取某个功能配置的模型
+func (ac *AIComponent) GetFeatureModel(feature string) model.ChatModel {
+	if modelName, ok := ac.config.Features[feature]; ok && modelName != "" {
+		return ac.ChatModel[modelName]
+	}
+	return ac.ChatModel[defaultModelName]
+}
+
+func (ac *AIComponent) AddChatModel(modelName string, model model.ChatModel) {
 	ac.ChatModel[modelName] = model
 }
 
 func (ac *AIComponent) initChatModel() {
 	ctx := context.Background()
 	for modelName, modelConfig := range ac.config.Items {
-		model, err := openai.NewChatModel(ctx, &openai.ChatModelConfig{
+		model, err := newChatModel(ctx, modelConfig)
+		if err != nil {
+			panic(err)
+		}
+		ac.ChatModel[modelName] = model
+	}
+}
+
+// newChatModel 根据提供方创建模型
+func newChatModel(ctx context.Context, modelConfig config.AIConfigItem) (model.ChatModel, error) {
+	switch modelConfig.Provider {
+	case "", config.ProviderOpenAI:
+		return openai.NewChatModel(ctx, &openai.ChatModelConfig{
 			Model:   modelConfig.Model,
 			BaseURL: modelConfig.BaseURL,
 			APIKey:  modelConfig.APIKey,
 		})
-		if err != nil {
-			panic(err)
+	case config.ProviderFake:
+		fake := llm.NewFakeChatModel(nil, modelConfig.DefaultResponse)
+		for _, r := range modelConfig.Responses {
+			fake.AddResponse(r.Match, r.Response)
 		}
-		ac.ChatModel[modelName] = model
+		return fake, nil
+	default:
+		return nil, fmt.Errorf("unsupported model provider: %s", modelConfig.Provider)
 	}
 }
 
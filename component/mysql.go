@@ -24,7 +24,7 @@ func initMySQL() {
 		panic(err)
 	}
 	// 设置表的字符集为 utf8mb4
-	db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci").AutoMigrate(&model.Meeting{}, &model.Resume{}, &model.Template{})
+	db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci").AutoMigrate(&model.Meeting{}, &model.MeetingRound{}, &model.MeetingStage{}, &model.Resume{}, &model.Template{}, &model.WikiJob{}, &model.Question{}, &model.MeetingReport{})
 	initModel()
 }
 
@@ -35,9 +35,14 @@ func GetMySQLDB() *gorm.DB {
 func initModel() {
 	db.AutoMigrate(model.User{})
 	db.AutoMigrate(model.Meeting{})
+	db.AutoMigrate(model.MeetingRound{})
+	db.AutoMigrate(model.MeetingStage{})
 	db.AutoMigrate(model.Resume{})
 	db.AutoMigrate(model.Template{})
 	db.AutoMigrate(model.Wiki{})
+	db.AutoMigrate(model.WikiJob{})
+	db.AutoMigrate(model.Question{})
+	db.AutoMigrate(model.MeetingReport{})
 	// 初始化模板
 	// initTemplate()
 }
@@ -50,7 +55,7 @@ func initTemplate() {
 	defer file.Close()
 	// 初始化模板
 	template := model.NewTemplate("默认模板", string(content))
-	chatModel := GetAIComponent().GetChatModel("gpt-4o")
+	chatModel := GetAIComponent().GetFeatureModel(FeatureResume)
 	template.SetShowContent(chatModel)
 	db.Create(template)
 }
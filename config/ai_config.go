@@ -7,14 +7,33 @@ import (
 	"github.com/spf13/viper"
 )
 
+// 模型提供方
+const (
+	ProviderOpenAI = "openai"
+	ProviderFake   = "fake"
+)
+
 type AIConfig struct {
-	Items map[string]AIConfigItem `yaml:"Items"`
+	Items    map[string]AIConfigItem `yaml:"Items"`
+	Features map[string]string       `yaml:"features"` // 功能 -> 模型名称
 }
 
 type AIConfigItem struct {
-	Model   string `yaml:"Model"`
-	BaseURL string `yaml:"BaseURL"`
-	APIKey  string `yaml:"APIKey"`
+	Provider string `yaml:"Provider"` // openai(默认) / fake
+	Model    string `yaml:"Model"`
+	BaseURL  string `yaml:"BaseURL"`
+	APIKey   string `yaml:"APIKey"`
+
+	// fake 模型的预设回答
+	Responses       []FakeResponse `yaml:"Responses"`
+	DefaultResponse string         `yaml:"DefaultResponse"`
+}
+
+// FakeResponse 提示词包含 Match 时返回 Response
+// 用列表而不是map，避免viper把关键字转成小写
+type FakeResponse struct {
+	Match    string `yaml:"Match"`
+	Response string `yaml:"Response"`
 }
 
 var aiConfig AIConfig
@@ -25,23 +44,38 @@ func GetAIConfig() *AIConfig {
 
 // InitAIConfig 初始化AI配置
 func InitAIConfig() {
-    workdir, _ := os.Getwd()
-    viper.SetConfigName("ai_config")
-    viper.SetConfigType("yaml")
-    viper.AddConfigPath(workdir + "/config")
+	workdir, _ := os.Getwd()
+	viper.SetConfigName("ai_config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(workdir + "/config")
+
+	if err := viper.ReadInConfig(); err != nil {
+		logs.SugarLogger.Errorf("Failed to read AI config file: %v", err)
+		return
+	}
 
-    if err := viper.ReadInConfig(); err != nil {
-        logs.SugarLogger.Errorf("Failed to read AI config file: %v", err)
-        return
-    }
+	// features 单独解析，其余顶层键都是模型配置
+	features := make(map[string]string)
+	if err := viper.UnmarshalKey("features", &features); err != nil {
+		logs.SugarLogger.Errorf("Failed to unmarshal AI features config: %v", err)
+		return
+	}
 
-    modelsConfig := make(map[string]AIConfigItem)
-    if err := viper.Unmarshal(&modelsConfig); err != nil {
-        logs.SugarLogger.Errorf("Failed to unmarshal AI config: %v", err)
-        return
-    }
+	modelsConfig := make(map[string]AIConfigItem)
+	for key := range viper.AllSettings() {
+		if key == "features" {
+			continue
+		}
+		var item AIConfigItem
+		if err := viper.UnmarshalKey(key, &item); err != nil {
+			logs.SugarLogger.Errorf("Failed to unmarshal AI config %s: %v", key, err)
+			return
+		}
+		modelsConfig[key] = item
+	}
 
-    logs.SugarLogger.Infof("AI config loaded successfully: %+v", modelsConfig)
+	logs.SugarLogger.Infof("AI config loaded successfully, models: %d, features: %v", len(modelsConfig), features)
 
 	aiConfig.Items = modelsConfig
-}
\ No newline at end of file
+	aiConfig.Features = features
+}
@@ -15,6 +15,9 @@ type Config struct {
 	LocalPath `yaml:"localPath"`
 	Role      `yaml:"role"`
 	RateLimit `yaml:"rateLimit"`
+	Rerank    `yaml:"rerank"`
+	Ingest    `yaml:"ingest"`
+	Embedding `yaml:"embedding"`
 }
 
 type MySQL struct {
@@ -44,6 +47,7 @@ type Speech struct {
 	APIKey    string `yaml:"apiKey"`
 	APISecret string `yaml:"apiSecret"`
 	AppID     string `yaml:"appId"`
+	Endpoint  string `yaml:"endpoint"` // 接口地址，为空时使用讯飞语音听写默认地址
 }
 
 type LocalPath struct {
@@ -55,6 +59,39 @@ type Role struct {
 	Policy string `yaml:"policy"`
 }
 
+// 检索结果重排方式
+const (
+	RerankProviderCrossEncoder = "cross_encoder"
+	RerankProviderLLM          = "llm"
+)
+
+// Rerank 知识库检索结果重排配置，Provider为空时不重排
+type Rerank struct {
+	Provider string `yaml:"provider"` // cross_encoder / llm
+	BaseURL  string `yaml:"baseURL"`  // cross_encoder 重排服务地址，兼容 /rerank 接口
+	APIKey   string `yaml:"apiKey"`
+	Model    string `yaml:"model"`
+}
+
+// Ingest 知识库文档导入任务配置
+type Ingest struct {
+	Workers     int `yaml:"workers"`     // 后台导入协程数，默认2
+	MaxAttempts int `yaml:"maxAttempts"` // 最大尝试次数，默认3
+	Backoff     int `yaml:"backoff"`     // 首次重试等待秒数，之后每次翻倍，默认5
+}
+
+// Embedding 嵌入模型及接口调用配置
+type Embedding struct {
+	Model     string `yaml:"model"`     // 嵌入模型名称
+	BaseURL   string `yaml:"baseURL"`   // OpenAI兼容接口地址
+	APIKey    string `yaml:"apiKey"`    // 接口密钥
+	Dimension int    `yaml:"dimension"` // 向量维度，同时决定知识库索引的向量维度，默认2560
+
+	BatchSize   int `yaml:"batchSize"`   // 每次请求嵌入接口的文本数，默认10
+	Concurrency int `yaml:"concurrency"` // 同时进行的嵌入请求数，默认4
+	CacheTTL    int `yaml:"cacheTTL"`    // 向量缓存有效期(小时)，默认720，小于0表示不过期
+}
+
 // RateLimit 限流配置结构体
 type RateLimit struct {
 	// 是否启用限流
@@ -173,3 +210,38 @@ func GetSpeechConfig() Speech {
 func GetRateLimitConfig() RateLimit {
 	return config.RateLimit
 }
+
+func GetRerankConfig() Rerank {
+	return config.Rerank
+}
+
+func GetIngestConfig() Ingest {
+	conf := config.Ingest
+	if conf.Workers <= 0 {
+		conf.Workers = 2
+	}
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = 3
+	}
+	if conf.Backoff <= 0 {
+		conf.Backoff = 5
+	}
+	return conf
+}
+
+func GetEmbeddingConfig() Embedding {
+	conf := config.Embedding
+	if conf.Dimension <= 0 {
+		conf.Dimension = 2560
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 10
+	}
+	if conf.Concurrency <= 0 {
+		conf.Concurrency = 4
+	}
+	if conf.CacheTTL == 0 {
+		conf.CacheTTL = 720
+	}
+	return conf
+}
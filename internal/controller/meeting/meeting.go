@@ -3,9 +3,13 @@ package meetingController
 import (
 	"ai_jianli_go/internal/controller"
 	meetingService "ai_jianli_go/internal/service/meeting"
+	"ai_jianli_go/types/model"
 	"ai_jianli_go/types/req"
 	"ai_jianli_go/types/resp/common"
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -81,6 +85,26 @@ func (mc *MeetingController) Delete(c *gin.Context) {
 	ctrl.NoDataJSON(code)
 }
 
+// 获取面试各轮结果接口
+func (mc *MeetingController) GetRounds(c *gin.Context) {
+	ctrl := controller.NewCtrl[req.GetMeetingReq](c)
+
+	id, err := strconv.ParseUint(c.Query("id"), 10, 64)
+	if err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.ID = uint(id)
+	ctrl.Request.UserID = c.GetUint("id")
+
+	rounds, code := mc.svc.GetRounds(ctrl.Request)
+	if code != common.CodeSuccess {
+		ctrl.NoDataJSON(code)
+		return
+	}
+	ctrl.WithDataJSON(code, rounds)
+}
+
 // 上传简历接口
 func (mc *MeetingController) UploadResume(c *gin.Context) {
 	ctrl := controller.NewCtrl[req.UploadResumeReq](c)
@@ -101,12 +125,54 @@ func (mc *MeetingController) AIInterview(c *gin.Context) {
 		return
 	}
 	ctrl.Request.UserID = c.GetUint("id")
-	reply, code := mc.svc.AIInterview(ctrl.Request)
+	round, code := mc.svc.AIInterview(ctrl.Request)
 	if code != common.CodeSuccess {
 		ctrl.NoDataJSON(code)
 		return
 	}
-	ctrl.WithDataJSON(code, gin.H{"reply": reply})
+	ctrl.WithDataJSON(code, round)
+}
+
+// AI面试流式接口（SSE），delta事件推送字符串字段新生成的文本，field事件推送已生成完整的字段，done事件返回本轮结构化结果
+func (mc *MeetingController) AIInterviewStream(c *gin.Context) {
+	ctrl := controller.NewCtrl[req.AIInterviewReq](c)
+	if err := c.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = c.GetUint("id")
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	reqCtx := c.Request.Context()
+	round, code := mc.svc.AIInterviewStream(reqCtx, ctrl.Request, func(field model.TurnField) error {
+		if err := reqCtx.Err(); err != nil {
+			return err
+		}
+		if field.Completed() {
+			c.SSEvent("field", field)
+		} else {
+			c.SSEvent("delta", field)
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if code != common.CodeSuccess {
+		// 尚未开始推送时按普通接口返回错误
+		if !c.Writer.Written() {
+			ctrl.NoDataJSON(code)
+			return
+		}
+		ctrl.Response.SetNoData(code)
+		c.SSEvent("error", ctrl.Response)
+		c.Writer.Flush()
+		return
+	}
+	ctrl.Response.SetWithData(code, round)
+	c.SSEvent("done", ctrl.Response)
+	c.Writer.Flush()
 }
 
 // 获取面试评价接口
@@ -119,11 +185,139 @@ func (mc *MeetingController) GetRemark(c *gin.Context) {
 		return
 	}
 	ctrl.Request.MeetingID = uint(id)
+	if v := c.Query("version"); v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil || version <= 0 {
+			ctrl.NoDataJSON(common.CodeInvalidParams)
+			return
+		}
+		ctrl.Request.Version = version
+	}
 	ctrl.Request.UserID = c.GetUint("id")
-	meeting, code := mc.svc.GetRemark(context.Background(), ctrl.Request)
+	report, code := mc.svc.GetRemark(context.Background(), ctrl.Request)
 	if code != common.CodeSuccess {
 		ctrl.NoDataJSON(code)
 		return
 	}
-	ctrl.WithDataJSON(code, meeting)
-}
\ No newline at end of file
+	ctrl.WithDataJSON(code, report)
+}
+
+// 重新生成面试评价接口
+func (mc *MeetingController) RegenerateRemark(c *gin.Context) {
+	ctrl := controller.NewCtrl[req.RegenerateRemarkReq](c)
+	if err := c.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = c.GetUint("id")
+	report, code := mc.svc.RegenerateRemark(context.Background(), ctrl.Request)
+	if code != common.CodeSuccess {
+		ctrl.NoDataJSON(code)
+		return
+	}
+	ctrl.WithDataJSON(code, report)
+}
+
+// 获取面试阶段列表接口
+func (mc *MeetingController) GetStages(c *gin.Context) {
+	ctrl := controller.NewCtrl[req.GetMeetingReq](c)
+
+	id, err := strconv.ParseUint(c.Query("id"), 10, 64)
+	if err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.ID = uint(id)
+	ctrl.Request.UserID = c.GetUint("id")
+
+	stages, code := mc.svc.GetStages(ctrl.Request)
+	if code != common.CodeSuccess {
+		ctrl.NoDataJSON(code)
+		return
+	}
+	ctrl.WithDataJSON(code, stages)
+}
+
+// 进入下一面试阶段接口
+func (mc *MeetingController) NextStage(c *gin.Context) {
+	ctrl := controller.NewCtrl[req.NextStageReq](c)
+	if err := c.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = c.GetUint("id")
+	stage, code := mc.svc.NextStage(ctrl.Request)
+	if code != common.CodeSuccess {
+		ctrl.NoDataJSON(code)
+		return
+	}
+	ctrl.WithDataJSON(code, stage)
+}
+
+// 获取多阶段面试综合报告接口
+func (mc *MeetingController) GetStageReport(c *gin.Context) {
+	ctrl := controller.NewCtrl[req.GetRemarkReq](c)
+
+	id, err := strconv.ParseUint(c.Query("id"), 10, 64)
+	if err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.MeetingID = uint(id)
+	ctrl.Request.UserID = c.GetUint("id")
+	report, code := mc.svc.GetStageReport(context.Background(), ctrl.Request)
+	if code != common.CodeSuccess {
+		ctrl.NoDataJSON(code)
+		return
+	}
+	ctrl.WithDataJSON(code, report)
+}
+
+// 候选人对比排名接口
+func (mc *MeetingController) Compare(c *gin.Context) {
+	ctrl := controller.NewCtrl[req.CompareMeetingReq](c)
+	if err := c.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = c.GetUint("id")
+	ranking, code := mc.svc.Compare(context.Background(), ctrl.Request)
+	if code != common.CodeSuccess {
+		ctrl.NoDataJSON(code)
+		return
+	}
+	ctrl.WithDataJSON(code, ranking)
+}
+
+// 导出面试报告接口
+func (mc *MeetingController) ExportReport(c *gin.Context) {
+	ctrl := controller.NewCtrl[req.ExportReportReq](c)
+
+	id, err := strconv.ParseUint(c.Query("id"), 10, 64)
+	if err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.MeetingID = uint(id)
+	ctrl.Request.Format = c.Query("format")
+	if v := c.Query("version"); v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil || version <= 0 {
+			ctrl.NoDataJSON(common.CodeInvalidParams)
+			return
+		}
+		ctrl.Request.Version = version
+	}
+	ctrl.Request.UserID = c.GetUint("id")
+	file, code := mc.svc.ExportReport(context.Background(), ctrl.Request)
+	if code != common.CodeSuccess {
+		ctrl.NoDataJSON(code)
+		return
+	}
+
+	// filename为ASCII文件名，供不支持filename*的客户端使用；filename*为UTF-8编码的中文文件名
+	disposition := fmt.Sprintf("attachment; filename=\"%s\"; filename*=UTF-8''%s",
+		file.ASCIIName, url.PathEscape(file.Name))
+	c.Header("Content-Disposition", disposition)
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
@@ -0,0 +1,124 @@
+package questionController
+
+import (
+	"ai_jianli_go/internal/controller"
+	questionService "ai_jianli_go/internal/service/question"
+	"ai_jianli_go/types/req"
+	"ai_jianli_go/types/resp/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuestionController struct {
+	svc *questionService.QuestionService
+}
+
+func NewQuestionController(svc *questionService.QuestionService) *QuestionController {
+	return &QuestionController{svc: svc}
+}
+
+// Generate 根据文章生成待审核的题目
+func (c *QuestionController) Generate(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.GenerateQuestionReq](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+	result, code := c.svc.Generate(ctx.Request.Context(), ctrl.Request)
+	ctrl.WithDataJSON(code, result)
+}
+
+func (c *QuestionController) List(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.ListQuestionReq](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+	list, code := c.svc.List(ctrl.Request)
+	ctrl.WithDataJSON(code, list)
+}
+
+// AdminList 管理员查看所有用户的题目，可按user_id筛选
+func (c *QuestionController) AdminList(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.ListQuestionReq](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	list, code := c.svc.List(ctrl.Request)
+	ctrl.WithDataJSON(code, list)
+}
+
+// Update 修改自己的题目
+func (c *QuestionController) Update(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.UpdateQuestionReq](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+	question, code := c.svc.Update(ctrl.Request)
+	ctrl.WithDataJSON(code, question)
+}
+
+// Review 批量通过或驳回自己的题目
+func (c *QuestionController) Review(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.ReviewQuestionReq](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+	updated, code := c.svc.Review(ctrl.Request)
+	ctrl.WithDataJSON(code, updated)
+}
+
+// Delete 删除自己的题目
+func (c *QuestionController) Delete(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.DeleteQuestionReq](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+	code := c.svc.Delete(ctrl.Request)
+	ctrl.NoDataJSON(code)
+}
+
+// AdminUpdate 管理员修改任意用户的题目
+func (c *QuestionController) AdminUpdate(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.UpdateQuestionReq](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = 0
+	question, code := c.svc.Update(ctrl.Request)
+	ctrl.WithDataJSON(code, question)
+}
+
+// AdminReview 管理员批量审核任意用户的题目
+func (c *QuestionController) AdminReview(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.ReviewQuestionReq](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = 0
+	updated, code := c.svc.Review(ctrl.Request)
+	ctrl.WithDataJSON(code, updated)
+}
+
+// AdminDelete 管理员删除任意用户的题目
+func (c *QuestionController) AdminDelete(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.DeleteQuestionReq](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = 0
+	code := c.svc.Delete(ctrl.Request)
+	ctrl.NoDataJSON(code)
+}
@@ -1,36 +1,45 @@
 package speech
 
 import (
+	"ai_jianli_go/component"
 	"ai_jianli_go/config"
+	"ai_jianli_go/logs"
 	"ai_jianli_go/pkg/speech"
+	"ai_jianli_go/types/model"
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
-	"path/filepath"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
 type SpeechController struct {
-	recognizer *speech.Recognizer
+	recognizer speech.SpeechRecognizer
 }
 
 func NewSpeechController() *SpeechController {
-	fmt.Println(config.GetSpeechConfig())
 	config := speech.Config{
 		APIKey:    config.GetSpeechConfig().APIKey,
 		APISecret: config.GetSpeechConfig().APISecret,
 		AppID:     config.GetSpeechConfig().AppID,
+		Endpoint:  config.GetSpeechConfig().Endpoint,
 	}
 
 	return &SpeechController{
-		recognizer: speech.NewRecognizer(config),
+		recognizer: speech.NewXfyunRecognizer(config),
 	}
 }
 
 // Recognize 处理语音识别请求
 func (c *SpeechController) Recognize(ctx *gin.Context) {
+	// 限制请求体大小，预留表单字段的空间
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, speech.MaxAudioSize+1<<20)
+
 	// 获取上传的音频文件
 	file, err := ctx.FormFile("audio")
 	if err != nil {
@@ -39,22 +48,41 @@ func (c *SpeechController) Recognize(ctx *gin.Context) {
 		})
 		return
 	}
+	if file.Size > speech.MaxAudioSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("音频文件不能超过%dMB", speech.MaxAudioSize>>20),
+		})
+		return
+	}
 
-	// 创建临时文件
-	tempDir := os.TempDir()
-	tempFile := filepath.Join(tempDir, "audio_"+time.Now().Format("20060102150405")+".wav")
-
-	// 保存上传的文件
-	if err = ctx.SaveUploadedFile(file, tempFile); err != nil {
+	// 读取上传的文件
+	f, err := file.Open()
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "保存音频文件失败",
+			"error": "读取音频文件失败",
+		})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "读取音频文件失败",
+		})
+		return
+	}
+
+	// 转换为识别服务要求的16k采样率16bit单声道PCM
+	pcm, err := speech.NormalizeAudio(data)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
-	defer os.Remove(tempFile) // 清理临时文件
 
 	// 执行语音识别
-	resultData, err := c.recognizer.RecognizeFile(tempFile)
+	resultData, err := c.recognizer.Recognize(ctx.Request.Context(), bytes.NewReader(pcm))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "语音识别失败: " + err.Error(),
@@ -62,8 +90,146 @@ func (c *SpeechController) Recognize(ctx *gin.Context) {
 		return
 	}
 
-	// 返回识别结果
+	// 识别结果保存在服务端，提交回答时按recognition_id引用
+	recognitionID, err := model.SaveRecognition(ctx.Request.Context(), component.GetRedisDB(), ctx.GetUint("id"), resultData)
+	if err != nil {
+		logs.SugarLogger.Errorf("保存语音识别结果失败: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "保存语音识别结果失败",
+		})
+		return
+	}
+
+	// 返回识别结果，segments中带有词级的时间和置信度
 	ctx.JSON(http.StatusOK, gin.H{
-		"text": resultData,
+		"recognition_id": recognitionID,
+		"text":           resultData.Text,
+		"segments":       resultData.Segments,
+		"low_confidence": resultData.LowConfidence,
 	})
 }
+
+// 流式识别推送给客户端的消息类型
+const (
+	streamPartial = "partial"
+	streamFinal   = "final"
+	streamError   = "error"
+	// 客户端发送该文本消息表示音频结束
+	streamEnd = "end"
+	// 客户端单条消息的最大长度
+	streamMessageLimit = 1 << 20
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	// 跨域由cors中间件统一放开
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// streamMessage 流式识别推送给客户端的消息
+type streamMessage struct {
+	Type          string           `json:"type"`                     // partial/final/error
+	Text          string           `json:"text,omitempty"`           // 到目前为止的完整识别文本
+	Segments      []speech.Segment `json:"segments,omitempty"`       // 分段，带词级的时间和置信度
+	LowConfidence bool             `json:"low_confidence,omitempty"` // 是否有低置信度的词
+	Message       string           `json:"message,omitempty"`        // 错误信息
+	RecognitionID string           `json:"recognition_id,omitempty"` // 最终结果的识别ID，提交回答时引用
+}
+
+// Stream 处理流式语音识别：客户端以二进制消息发送16bit PCM音频（默认16k采样率单声道），
+// 发送文本消息end表示说完，服务端实时推送中间结果，识别结束时推送最终结果
+func (c *SpeechController) Stream(ctx *gin.Context) {
+	// 浏览器录音的采样率通常为44.1k或48k，可通过参数指定，服务端转换后再发送给识别服务
+	format := speech.TargetFormat
+	if v := ctx.Query("sample_rate"); v != "" {
+		format.SampleRate, _ = strconv.Atoi(v)
+	}
+	if v := ctx.Query("channels"); v != "" {
+		format.Channels, _ = strconv.Atoi(v)
+	}
+	// 音频帧可能跨消息，整个连接使用同一个转换器
+	converter, err := speech.NewPCMConverter(format)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	userID := ctx.GetUint("id")
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// Upgrade失败时已向客户端返回错误
+		logs.SugarLogger.Errorf("建立语音识别WebSocket连接失败: %v", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(streamMessageLimit)
+
+	sessionCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session, err := c.recognizer.NewSession(sessionCtx)
+	if err != nil {
+		logs.SugarLogger.Errorf("建立语音识别会话失败: %v", err)
+		conn.WriteJSON(streamMessage{Type: streamError, Message: "语音识别服务连接失败"})
+		return
+	}
+	defer session.Close()
+
+	// 客户端的音频到达即转发给识别服务，客户端断开时结束会话
+	go func() {
+		for {
+			msgType, data, err := conn.ReadMessage()
+			if err != nil {
+				cancel()
+				return
+			}
+			switch msgType {
+			case websocket.BinaryMessage:
+				if err = session.Write(converter.Write(data)); err != nil {
+					logs.SugarLogger.Errorf("转发音频失败: %v", err)
+					session.Close()
+					return
+				}
+				// 超过识别服务支持的最长时长时结束发送，返回已识别的结果
+				if converter.Duration() >= speech.MaxAudioDuration {
+					if err = session.CloseSend(); err != nil {
+						logs.SugarLogger.Errorf("结束音频发送失败: %v", err)
+						session.Close()
+					}
+					return
+				}
+			case websocket.TextMessage:
+				if strings.TrimSpace(string(data)) == streamEnd {
+					if err = session.CloseSend(); err != nil {
+						logs.SugarLogger.Errorf("结束音频发送失败: %v", err)
+						session.Close()
+					}
+					return
+				}
+			}
+		}
+	}()
+
+	for result := range session.Results() {
+		msg := streamMessage{Type: streamPartial, Text: result.Text, Segments: result.Segments, LowConfidence: result.LowConfidence}
+		if result.Final {
+			msg.Type = streamFinal
+			if msg.RecognitionID, err = model.SaveRecognition(sessionCtx, component.GetRedisDB(), userID, &result); err != nil {
+				logs.SugarLogger.Errorf("保存语音识别结果失败: %v", err)
+				conn.WriteJSON(streamMessage{Type: streamError, Message: "保存语音识别结果失败"})
+				return
+			}
+		}
+		if err = conn.WriteJSON(msg); err != nil {
+			logs.SugarLogger.Errorf("推送识别结果失败: %v", err)
+			return
+		}
+	}
+	if err = session.Err(); err != nil {
+		logs.SugarLogger.Errorf("流式语音识别失败: %v", err)
+		conn.WriteJSON(streamMessage{Type: streamError, Message: "语音识别失败"})
+	}
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
@@ -9,11 +9,13 @@ import (
 	"ai_jianli_go/types/req"
 	"ai_jianli_go/types/resp/common"
 	"fmt"
+	"mime/multipart"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -54,25 +56,118 @@ func (c *WikiController) CreateWiki(ctx *gin.Context) {
 				ctrl.NoDataJSON(common.CodeInvalidParams)
 				return
 			}
-
-			// 保存上传的文件
-			lname := path.Ext(file.Filename)
-			file.Filename = ctrl.Request.Title + "_" + time.Now().Format("20060102150405") + lname
-			workdir, _ := os.Getwd()
-			savepath := filepath.Join(workdir, config.GetLocalPathConfig().Path, "wiki", file.Filename)
-			err = ctx.SaveUploadedFile(file, savepath)
+			savepath, err := saveUploadedFile(ctx, file, ctrl.Request.Title)
 			if err != nil {
 				logs.SugarLogger.Errorf("保存文件失败: %v", err)
 				ctrl.NoDataJSON(common.CodeInvalidParams)
 				return
 			}
 			ctrl.Request.Url = savepath
-			logs.SugarLogger.Infof("文件上传成功，保存路径: %s", savepath)
 		}
 	}
 
-	code := c.svc.CreateWiki(ctrl.Request)
-	ctrl.NoDataJSON(code)
+	job, code := c.svc.CreateWiki(ctrl.Request)
+	ctrl.WithDataJSON(code, job)
+}
+
+// saveUploadedFile 保存上传的知识库文件，返回保存路径
+func saveUploadedFile(ctx *gin.Context, file *multipart.FileHeader, title string) (string, error) {
+	lname := path.Ext(file.Filename)
+	file.Filename = title + "_" + time.Now().Format("20060102150405") + lname
+	workdir, _ := os.Getwd()
+	savepath := filepath.Join(workdir, config.GetLocalPathConfig().Path, "wiki", file.Filename)
+	if err := ctx.SaveUploadedFile(file, savepath); err != nil {
+		return "", err
+	}
+	logs.SugarLogger.Infof("文件上传成功，保存路径: %s", savepath)
+	return savepath, nil
+}
+
+func (c *WikiController) UpdateWiki(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.UpdateWikiRequest](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+
+	// 上传了新文件时替换文章内容
+	if file, err := ctx.FormFile("file"); err == nil {
+		title := ctrl.Request.Title
+		if title == "" {
+			title = strings.TrimSuffix(file.Filename, path.Ext(file.Filename))
+		}
+		savepath, err := saveUploadedFile(ctx, file, title)
+		if err != nil {
+			logs.SugarLogger.Errorf("保存文件失败: %v", err)
+			ctrl.NoDataJSON(common.CodeInvalidParams)
+			return
+		}
+		ctrl.Request.Url = savepath
+	}
+
+	job, code := c.svc.UpdateWiki(ctrl.Request)
+	ctrl.WithDataJSON(code, job)
+}
+
+func (c *WikiController) GetJob(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.GetWikiJobRequest](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+	job, code := c.svc.GetJob(ctrl.Request)
+	ctrl.WithDataJSON(code, job)
+}
+
+func (c *WikiController) ListJobs(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.ListWikiJobRequest](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+	jobs, code := c.svc.ListJobs(ctrl.Request)
+	ctrl.WithDataJSON(code, jobs)
+}
+
+// WatchJob 订阅导入任务进度（SSE），progress事件推送任务最新状态，任务结束时推送done事件
+func (c *WikiController) WatchJob(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.GetWikiJobRequest](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	reqCtx := ctx.Request.Context()
+	code := c.svc.WatchJob(reqCtx, ctrl.Request, func(job *model.WikiJob) error {
+		if err := reqCtx.Err(); err != nil {
+			return err
+		}
+		event := "progress"
+		if job.Finished() {
+			event = "done"
+		}
+		ctrl.Response.SetWithData(common.CodeSuccess, job)
+		ctx.SSEvent(event, ctrl.Response)
+		ctx.Writer.Flush()
+		return nil
+	})
+	if code != common.CodeSuccess {
+		if !ctx.Writer.Written() {
+			ctrl.NoDataJSON(code)
+			return
+		}
+		ctrl.Response.SetNoData(code)
+		ctx.SSEvent("error", ctrl.Response)
+		ctx.Writer.Flush()
+	}
 }
 
 func (c *WikiController) GetWikiList(ctx *gin.Context) {
@@ -153,4 +248,48 @@ func (c *WikiController) GetFileByPath(ctx *gin.Context) {
 
 	// 返回文件内容
 	ctx.File(filePath)
-}
\ No newline at end of file
+}
+func (c *WikiController) GetEmbeddingUsage(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.GetEmbeddingUsageRequest](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+	usages, code := c.svc.GetEmbeddingUsage(ctrl.Request)
+	ctrl.WithDataJSON(code, usages)
+}
+
+func (c *WikiController) GetIndexConfig(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.GetWikiIndexConfigRequest](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+	conf, code := c.svc.GetIndexConfig(ctrl.Request)
+	ctrl.WithDataJSON(code, conf)
+}
+
+// UpdateIndexConfig 修改知识库的向量索引配置，返回重建索引的后台任务
+func (c *WikiController) UpdateIndexConfig(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.UpdateWikiIndexConfigRequest](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	ctrl.Request.UserID = ctx.GetUint("id")
+	job, code := c.svc.UpdateIndexConfig(ctrl.Request)
+	ctrl.WithDataJSON(code, job)
+}
+
+// GetIndexStats 管理员查看任意知识库的索引统计
+func (c *WikiController) GetIndexStats(ctx *gin.Context) {
+	ctrl := controller.NewCtrl[req.GetWikiIndexStatsRequest](ctx)
+	if err := ctx.Bind(ctrl.Request); err != nil {
+		ctrl.NoDataJSON(common.CodeInvalidParams)
+		return
+	}
+	stats, code := c.svc.GetIndexStats(ctrl.Request)
+	ctrl.WithDataJSON(code, stats)
+}
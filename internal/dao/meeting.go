@@ -19,6 +19,22 @@ func (dao *MeetingDAO) Create(meeting *model.Meeting) error {
 	return dao.db.Create(meeting).Error
 }
 
+// CreateWithStages 在同一事务中创建面试及其阶段
+func (dao *MeetingDAO) CreateWithStages(meeting *model.Meeting, stages []model.MeetingStage) error {
+	return dao.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(meeting).Error; err != nil {
+			return err
+		}
+		if len(stages) == 0 {
+			return nil
+		}
+		for i := range stages {
+			stages[i].MeetingID = meeting.ID
+		}
+		return tx.Create(&stages).Error
+	})
+}
+
 func (dao *MeetingDAO) Update(meeting *model.Meeting) error {
 	return dao.db.Save(meeting).Error
 }
@@ -29,6 +45,13 @@ func (dao *MeetingDAO) GetByID(id uint) (*model.Meeting, error) {
 	return &meeting, err
 }
 
+// GetByUser 获取用户自己的面试，面试不属于该用户时返回gorm.ErrRecordNotFound
+func (dao *MeetingDAO) GetByUser(id uint, userID uint) (*model.Meeting, error) {
+	var meeting model.Meeting
+	err := dao.db.Where("id = ? AND user_id = ?", id, userID).First(&meeting).Error
+	return &meeting, err
+}
+
 func (dao *MeetingDAO) List() ([]model.Meeting, error) {
 	var meetings []model.Meeting
 	err := dao.db.Find(&meetings).Error
@@ -49,3 +72,78 @@ func (dao *MeetingDAO) GetResume(candidateID uint) (string, error) {
 	return meeting.Resume, err
 }
 
+func (dao *MeetingDAO) CreateRound(round *model.MeetingRound) error {
+	return dao.db.Create(round).Error
+}
+
+func (dao *MeetingDAO) CountRounds(meetingID, stageID uint) (int64, error) {
+	var count int64
+	err := dao.db.Model(&model.MeetingRound{}).Where("meeting_id = ? AND stage_id = ?", meetingID, stageID).Count(&count).Error
+	return count, err
+}
+
+// CountTopics 统计面试阶段已提出的新问题数，追问的轮次不计
+func (dao *MeetingDAO) CountTopics(meetingID, stageID uint) (int64, error) {
+	var count int64
+	err := dao.db.Model(&model.MeetingRound{}).Where("meeting_id = ? AND stage_id = ? AND follow_up_depth = 0", meetingID, stageID).Count(&count).Error
+	return count, err
+}
+
+func (dao *MeetingDAO) ListRounds(meetingID uint) ([]model.MeetingRound, error) {
+	var rounds []model.MeetingRound
+	err := dao.db.Where("meeting_id = ?", meetingID).Order("stage_id, round").Find(&rounds).Error
+	return rounds, err
+}
+
+func (dao *MeetingDAO) GetLastRound(meetingID, stageID uint) (*model.MeetingRound, error) {
+	var round model.MeetingRound
+	err := dao.db.Where("meeting_id = ? AND stage_id = ?", meetingID, stageID).Order("round desc").First(&round).Error
+	return &round, err
+}
+
+// UsedQuestionIDs 获取面试各阶段已提出的题库题目ID
+func (dao *MeetingDAO) UsedQuestionIDs(meetingID uint) ([]uint, error) {
+	var ids []uint
+	err := dao.db.Model(&model.MeetingRound{}).Where("meeting_id = ? AND question_id > 0", meetingID).Pluck("question_id", &ids).Error
+	return ids, err
+}
+
+func (dao *MeetingDAO) ListStages(meetingID uint) ([]model.MeetingStage, error) {
+	var stages []model.MeetingStage
+	err := dao.db.Where("meeting_id = ?", meetingID).Order("seq").Find(&stages).Error
+	return stages, err
+}
+
+func (dao *MeetingDAO) UpdateStage(stage *model.MeetingStage) error {
+	return dao.db.Save(stage).Error
+}
+
+func (dao *MeetingDAO) CreateReport(report *model.MeetingReport) error {
+	return dao.db.Create(report).Error
+}
+
+// GetLatestReport 获取面试或阶段最新版本的报告
+func (dao *MeetingDAO) GetLatestReport(meetingID, stageID uint) (*model.MeetingReport, error) {
+	var report model.MeetingReport
+	err := dao.db.Where("meeting_id = ? AND stage_id = ?", meetingID, stageID).Order("version desc").First(&report).Error
+	return &report, err
+}
+
+func (dao *MeetingDAO) GetReport(meetingID, stageID uint, version int) (*model.MeetingReport, error) {
+	var report model.MeetingReport
+	err := dao.db.Where("meeting_id = ? AND stage_id = ? AND version = ?", meetingID, stageID, version).First(&report).Error
+	return &report, err
+}
+
+// ListForCompare 获取用户参与对比的面试，指定面试ID时按ID筛选，否则按职位筛选
+func (dao *MeetingDAO) ListForCompare(userID uint, position string, ids []uint) ([]model.Meeting, error) {
+	var meetings []model.Meeting
+	query := dao.db.Where("user_id = ?", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	} else {
+		query = query.Where("position = ?", position)
+	}
+	err := query.Order("id").Find(&meetings).Error
+	return meetings, err
+}
@@ -0,0 +1,100 @@
+package dao
+
+import (
+	"ai_jianli_go/types/model"
+	"ai_jianli_go/types/req"
+
+	"gorm.io/gorm"
+)
+
+type QuestionDAO struct {
+	db *gorm.DB
+}
+
+func NewQuestionDAO(db *gorm.DB) *QuestionDAO {
+	return &QuestionDAO{db: db}
+}
+
+func (dao *QuestionDAO) CreateBatch(questions []*model.Question) error {
+	return dao.db.Create(questions).Error
+}
+
+func (dao *QuestionDAO) GetByID(id uint) (*model.Question, error) {
+	var question model.Question
+	err := dao.db.First(&question, id).Error
+	return &question, err
+}
+
+// Get 获取用户的题目，userID为0时不按用户筛选
+func (dao *QuestionDAO) Get(id uint, userID uint) (*model.Question, error) {
+	var question model.Question
+	err := scopeUser(dao.db, userID).Where("id = ?", id).First(&question).Error
+	return &question, err
+}
+
+func (dao *QuestionDAO) Update(question *model.Question) error {
+	return dao.db.Save(question).Error
+}
+
+// List 按用户、知识库、文章和审核状态筛选题目，返回当前页和总数。UserID为0时不按用户筛选
+func (dao *QuestionDAO) List(request *req.ListQuestionReq) ([]*model.Question, int64, error) {
+	query := scopeUser(dao.db.Model(&model.Question{}), request.UserID)
+	if request.RootID != 0 {
+		query = query.Where("root_id = ?", request.RootID)
+	}
+	if request.WikiID != 0 {
+		query = query.Where("wiki_id = ?", request.WikiID)
+	}
+	if request.Status != "" {
+		query = query.Where("status = ?", request.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var questions []*model.Question
+	err := query.Order("id desc").Offset((request.Page - 1) * request.PageSize).Limit(request.PageSize).Find(&questions).Error
+	return questions, total, err
+}
+
+// UpdateStatus 批量修改用户题目的审核状态，返回修改的题目数。userID为0时不按用户筛选
+func (dao *QuestionDAO) UpdateStatus(userID uint, ids []uint, status string) (int64, error) {
+	result := scopeUser(dao.db.Model(&model.Question{}), userID).Where("id IN ?", ids).Update("status", status)
+	return result.RowsAffected, result.Error
+}
+
+// Delete 删除用户的题目，userID为0时不按用户筛选
+func (dao *QuestionDAO) Delete(id uint, userID uint) (int64, error) {
+	result := scopeUser(dao.db, userID).Where("id = ?", id).Delete(&model.Question{})
+	return result.RowsAffected, result.Error
+}
+
+// ListApproved 获取知识库中审核通过的题目，wikiIDs不为空时只取这些文章的题目，excludeIDs中的题目除外。
+// 文章删除后其题目不再被抽取
+func (dao *QuestionDAO) ListApproved(rootID uint, wikiIDs []uint, excludeIDs []uint) ([]*model.Question, error) {
+	articles := dao.db.Model(&model.Wiki{}).Select("id").Where("root_id = ?", rootID)
+	query := dao.db.Where("root_id = ? AND status = ? AND wiki_id IN (?)", rootID, model.QuestionApproved, articles)
+	if len(wikiIDs) > 0 {
+		query = query.Where("wiki_id IN ?", wikiIDs)
+	}
+	if len(excludeIDs) > 0 {
+		query = query.Where("id NOT IN ?", excludeIDs)
+	}
+	var questions []*model.Question
+	err := query.Find(&questions).Error
+	return questions, err
+}
+
+// IncrUsed 题目被AI面试抽取后增加抽取次数
+func (dao *QuestionDAO) IncrUsed(id uint) error {
+	return dao.db.Model(&model.Question{}).Where("id = ?", id).UpdateColumn("used_count", gorm.Expr("used_count + 1")).Error
+}
+
+// scopeUser 只查询用户自己的记录，管理员操作时userID为0，不按用户筛选
+func scopeUser(query *gorm.DB, userID uint) *gorm.DB {
+	if userID == 0 {
+		return query
+	}
+	return query.Where("user_id = ?", userID)
+}
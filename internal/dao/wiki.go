@@ -15,6 +15,18 @@ func (w *WikiDAO) Create(wiki *model.Wiki) error {
 	return w.db.Create(wiki).Error
 }
 
+// CreateWithJob 创建文章并创建导入任务
+func (w *WikiDAO) CreateWithJob(wiki *model.Wiki, job *model.WikiJob) error {
+	return w.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(wiki).Error; err != nil {
+			return err
+		}
+		job.WikiID = wiki.ID
+		job.UserID = wiki.UserId
+		return tx.Create(job).Error
+	})
+}
+
 func (w *WikiDAO) GetWikiList(userId uint) ([]*model.Wiki, int64, error) {
 	var wikis []*model.Wiki
 	var total int64
@@ -49,24 +61,67 @@ func (w *WikiDAO) GetWiki(id uint, userId uint) (*model.Wiki, error) {
 	return &wiki, err
 }
 
-func (w *WikiDAO) DeleteWiki(request *req.DeleteWikiRequest) int64 {
-	result := w.db.Where("id = ? AND user_id = ?", request.ID, request.UserID).Delete(&model.Wiki{})
-	if result.Error != nil {
-		return 0
+// GetByID 获取条目，不限定用户，用于管理接口
+func (w *WikiDAO) GetByID(id uint) (*model.Wiki, error) {
+	var wiki model.Wiki
+
+	err := w.db.Where("id = ?", id).First(&wiki).Error
+	return &wiki, err
+}
+
+// ListKnowledgeBases 获取知识库列表，userId为0时获取所有用户的知识库
+func (w *WikiDAO) ListKnowledgeBases(userId uint) ([]*model.Wiki, error) {
+	var wikis []*model.Wiki
+
+	query := w.db.Where("type = ?", model.WikiTypeKnowledge)
+	if userId > 0 {
+		query = query.Where("user_id = ?", userId)
 	}
-	return result.RowsAffected
+	err := query.Find(&wikis).Error
+	return wikis, err
 }
 
-func (w *WikiDAO) UpdateWiki(request *req.UpdateWikiRequest) int64 {
-	result := w.db.Model(&model.Wiki{}).Where("id = ?", request.ID).Updates(map[string]interface{}{
-		"title":   request.Title,
-		"content": request.Content,
-	})
+// ListDescendants 获取文件夹或知识库下所有层级的子条目
+func (w *WikiDAO) ListDescendants(userId uint, id uint) ([]*model.Wiki, error) {
+	var result []*model.Wiki
+	visited := map[uint]bool{id: true}
+	queue := []uint{id}
+	for len(queue) > 0 {
+		children, err := w.GetListByParentId(userId, queue[0])
+		if err != nil {
+			return nil, err
+		}
+		queue = queue[1:]
+		for _, child := range children {
+			if visited[child.ID] {
+				continue
+			}
+			visited[child.ID] = true
+			result = append(result, child)
+			queue = append(queue, child.ID)
+		}
+	}
+	return result, nil
+}
+
+// DeleteWikis 批量删除条目
+func (w *WikiDAO) DeleteWikis(userId uint, ids []uint) (int64, error) {
+	result := w.db.Where("id IN ? AND user_id = ?", ids, userId).Delete(&model.Wiki{})
+	return result.RowsAffected, result.Error
+}
 
-	if result.Error != nil {
-		return 0
+func (w *WikiDAO) UpdateWiki(request *req.UpdateWikiRequest) error {
+	updates := map[string]interface{}{}
+	if request.Title != "" {
+		updates["title"] = request.Title
+	}
+	if request.Url != "" {
+		updates["url"] = request.Url
+	}
+	if len(updates) == 0 {
+		return nil
 	}
-	return result.RowsAffected
+	return w.db.Model(&model.Wiki{}).Where("id = ? AND user_id = ?", request.ID, request.UserID).Updates(updates).Error
 }
 
 func NewWikiDAO(db *gorm.DB) *WikiDAO {
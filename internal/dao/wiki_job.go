@@ -0,0 +1,159 @@
+package dao
+
+import (
+	"ai_jianli_go/types/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrJobLeaseLost 任务的租约已过期并被重新领取，原持有者不能再更新任务
+var ErrJobLeaseLost = errors.New("导入任务租约已失效")
+
+// 执行中的任务状态
+var runningJobStatuses = []string{model.WikiJobParsing, model.WikiJobEmbedding}
+
+type WikiJobDAO struct {
+	db *gorm.DB
+}
+
+func NewWikiJobDAO(db *gorm.DB) *WikiJobDAO {
+	return &WikiJobDAO{db: db}
+}
+
+func (dao *WikiJobDAO) Create(job *model.WikiJob) error {
+	return dao.db.Create(job).Error
+}
+
+// Update 由任务的持有者更新任务，租约由RenewLease单独维护。任务已被其他协程接管时返回ErrJobLeaseLost
+func (dao *WikiJobDAO) Update(job *model.WikiJob) error {
+	result := dao.db.Model(job).Where("owner = ?", job.Owner).
+		Select("*").Omit("created_at", "lease_until").Updates(job)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+func (dao *WikiJobDAO) Get(id uint, userID uint) (*model.WikiJob, error) {
+	var job model.WikiJob
+	err := dao.db.Where("id = ? AND user_id = ?", id, userID).First(&job).Error
+	return &job, err
+}
+
+// ListByWiki 获取文章的导入任务，最新的在前
+func (dao *WikiJobDAO) ListByWiki(wikiID uint, userID uint) ([]*model.WikiJob, error) {
+	var jobs []*model.WikiJob
+	err := dao.db.Where("wiki_id = ? AND user_id = ?", wikiID, userID).Order("id desc").Find(&jobs).Error
+	return jobs, err
+}
+
+// Claim 领取一个到期的排队任务并标记为解析中，租约到期时间为now+lease。同一文章同时只有一个执行中的任务，
+// 知识库的索引重建任务与该知识库的其他任务互斥。没有任务时返回gorm.ErrRecordNotFound，
+// 被其他协程抢先领取时返回nil
+func (dao *WikiJobDAO) Claim(owner string, now time.Time, lease time.Duration) (*model.WikiJob, error) {
+	var job model.WikiJob
+	err := dao.db.Where("status = ? AND next_run_at <= ?", model.WikiJobQueued, now).
+		Where("wiki_id NOT IN (?)", dao.running(now).Select("wiki_id")).
+		Where("root_id NOT IN (?)", dao.running(now).Select("root_id").Where("kind = ?", model.WikiJobRebuild)).
+		Where("kind <> ? OR root_id NOT IN (?)", model.WikiJobRebuild, dao.running(now).Select("root_id")).
+		Order("next_run_at, id").First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+
+	leaseUntil := now.Add(lease)
+	claimed := false
+	err = dao.db.Transaction(func(tx *gorm.DB) error {
+		// 锁住知识库行，使同一知识库的领取串行执行，再确认没有冲突的执行中任务
+		lockID := job.RootID
+		if lockID == 0 {
+			lockID = job.WikiID
+		}
+		var wiki model.Wiki
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").Where("id = ?", lockID).Limit(1).Find(&wiki).Error
+		if err != nil {
+			return err
+		}
+		conflicts := tx.Model(&model.WikiJob{}).Where("status IN ? AND lease_until > ?", runningJobStatuses, now)
+		if job.Kind == model.WikiJobRebuild {
+			conflicts = conflicts.Where("wiki_id = ? OR root_id = ?", job.WikiID, job.RootID)
+		} else {
+			conflicts = conflicts.Where("wiki_id = ? OR (root_id = ? AND kind = ?)", job.WikiID, job.RootID, model.WikiJobRebuild)
+		}
+		var count int64
+		if err = conflicts.Count(&count).Error; err != nil || count > 0 {
+			return err
+		}
+
+		result := tx.Model(&model.WikiJob{}).
+			Where("id = ? AND status = ?", job.ID, model.WikiJobQueued).
+			Updates(map[string]interface{}{
+				"status":      model.WikiJobParsing,
+				"attempts":    gorm.Expr("attempts + 1"),
+				"owner":       owner,
+				"lease_until": leaseUntil,
+			})
+		claimed = result.RowsAffected > 0
+		return result.Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, nil
+	}
+	job.Status = model.WikiJobParsing
+	job.Attempts++
+	job.Owner = owner
+	job.LeaseUntil = &leaseUntil
+	return &job, nil
+}
+
+// running 租约未过期的执行中任务
+func (dao *WikiJobDAO) running(now time.Time) *gorm.DB {
+	return dao.db.Model(&model.WikiJob{}).Where("status IN ? AND lease_until > ?", runningJobStatuses, now)
+}
+
+// RenewLease 延长执行中任务的租约，任务已被其他协程接管或已结束时返回ErrJobLeaseLost
+func (dao *WikiJobDAO) RenewLease(id uint, owner string, leaseUntil time.Time) error {
+	result := dao.db.Model(&model.WikiJob{}).
+		Where("id = ? AND owner = ? AND status IN ?", id, owner, runningJobStatuses).
+		Update("lease_until", leaseUntil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+// UpdateProgress 由任务的持有者更新分块进度
+func (dao *WikiJobDAO) UpdateProgress(id uint, owner string, result model.SyncResult) error {
+	return dao.db.Model(&model.WikiJob{}).Where("id = ? AND owner = ?", id, owner).Updates(map[string]interface{}{
+		"total_chunks":    result.Total,
+		"embedded_chunks": result.Added + result.Skipped,
+		"skipped_chunks":  result.Skipped,
+		"removed_chunks":  result.Removed,
+	}).Error
+}
+
+// RequeueExpired 把租约已过期的执行中任务重新排队。持有者所在实例退出或失去响应后租约不再续期，
+// 其他实例的任务仍在续期，不会被接管
+func (dao *WikiJobDAO) RequeueExpired(now time.Time) (int64, error) {
+	result := dao.db.Model(&model.WikiJob{}).
+		Where("status IN ? AND (lease_until IS NULL OR lease_until <= ?)", runningJobStatuses, now).
+		Updates(map[string]interface{}{
+			"status":      model.WikiJobQueued,
+			"next_run_at": now,
+			"owner":       "",
+		})
+	return result.RowsAffected, result.Error
+}
@@ -17,6 +17,10 @@ func Auth() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		res := common.Response{}
 		t := ctx.GetHeader("Authorization") //得到字串开头
+		// 浏览器的WebSocket无法设置请求头，允许通过token参数传递
+		if t == "" && ctx.IsWebsocket() && ctx.Query("token") != "" {
+			t = "Bearer " + ctx.Query("token")
+		}
 		if t == "" || !strings.HasPrefix(t, "Bearer ") {
 			logs.SugarLogger.Errorf("认证失败，无效的Authorization头: %s", t)
 			ctx.JSON(http.StatusUnauthorized, "bearer解析失败")
@@ -11,7 +11,7 @@ import (
 
 func meeting(rg *gin.RouterGroup) {
 	meetingDao := dao.NewMeetingDAO(component.GetMySQLDB())
-	meetingSvc := meetingService.NewMeetingService(meetingDao)
+	meetingSvc := meetingService.NewMeetingService(meetingDao, dao.NewQuestionDAO(component.GetMySQLDB()))
 	meetingCtrl := meetingController.NewMeetingController(meetingSvc)
 
 	rg.POST("", meetingCtrl.Create)
@@ -19,8 +19,16 @@ func meeting(rg *gin.RouterGroup) {
 	rg.GET("", meetingCtrl.Get)
 	rg.DELETE("", meetingCtrl.Delete)
 	rg.GET("/list", meetingCtrl.List)
+	rg.POST("/compare", meetingCtrl.Compare)
+	rg.GET("/rounds", meetingCtrl.GetRounds)
+	rg.GET("/stages", meetingCtrl.GetStages)
+	rg.POST("/stage/next", meetingCtrl.NextStage)
+	rg.GET("/stage/report", meetingCtrl.GetStageReport)
+	rg.GET("/report/export", meetingCtrl.ExportReport)
 
 	rg.POST("/upload_resume", meetingCtrl.UploadResume)
 	rg.POST("/ai_interview", meetingCtrl.AIInterview)
+	rg.POST("/ai_interview/stream", meetingCtrl.AIInterviewStream)
 	rg.GET("/remark", meetingCtrl.GetRemark)
+	rg.POST("/remark/regenerate", meetingCtrl.RegenerateRemark)
 }
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"ai_jianli_go/component"
+	questionController "ai_jianli_go/internal/controller/question"
+	"ai_jianli_go/internal/dao"
+	questionService "ai_jianli_go/internal/service/question"
+
+	"github.com/gin-gonic/gin"
+)
+
+func question(r *gin.RouterGroup) {
+	svc := questionService.NewQuestionService(dao.NewQuestionDAO(component.GetMySQLDB()), dao.NewWikiDAO(component.GetMySQLDB()))
+	ctrl := questionController.NewQuestionController(svc)
+	r.POST("/generate", ctrl.Generate)
+	r.GET("/list", ctrl.List)
+	r.PUT("", ctrl.Update)
+	r.POST("/review", ctrl.Review)
+	r.DELETE("", ctrl.Delete)
+	// 管理所有用户题目的接口，仅super_admin有权限
+	r.GET("/admin/list", ctrl.AdminList)
+	r.PUT("/admin", ctrl.AdminUpdate)
+	r.POST("/admin/review", ctrl.AdminReview)
+	r.DELETE("/admin", ctrl.AdminDelete)
+}
@@ -45,6 +45,7 @@ func Init() *gin.Engine {
 	user(v1.Group("/user", middleware.GeneralRateLimitMiddleware()))
 	speech(v1.Group("/speech", middleware.Auth(), middleware.SpeechRateLimitMiddleware()))
 	wiki(v1.Group("/wiki", middleware.Auth(), middleware.GeneralRateLimitMiddleware()))
+	question(v1.Group("/question", middleware.Auth(), middleware.GeneralRateLimitMiddleware()))
 
 	// 限流管理接口（仅管理员可访问）
 	ratelimit(v1.Group("/ratelimit", middleware.Auth(), middleware.GeneralRateLimitMiddleware()))
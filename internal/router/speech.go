@@ -10,4 +10,5 @@ import (
 func speech(r *gin.RouterGroup) {
 	controller := speechController.NewSpeechController()
 	r.POST("/recognize", controller.Recognize)
+	r.GET("/stream", controller.Stream)
 }
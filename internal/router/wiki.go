@@ -10,12 +10,22 @@ import (
 )
 
 func wiki(r *gin.RouterGroup) {
-	ctrl := wikiController.NewWikiController(wikiService.NewWikiService(dao.NewWikiDAO(component.GetMySQLDB())))
+	svc := wikiService.NewWikiService(dao.NewWikiDAO(component.GetMySQLDB()), dao.NewWikiJobDAO(component.GetMySQLDB()))
+	ctrl := wikiController.NewWikiController(svc)
 	r.POST("", ctrl.CreateWiki)
 	r.GET("/list", ctrl.GetWikiList)
 	r.GET("", ctrl.GetWiki)
+	r.PUT("", ctrl.UpdateWiki)
 	r.DELETE("", ctrl.DeleteWiki)
 	r.POST("/query", ctrl.QueryWiki)
 	r.GET("/file", ctrl.GetFileByPath)
 	r.GET("/list/parent", ctrl.GetListByParentId)
+	r.GET("/job", ctrl.GetJob)
+	r.GET("/job/list", ctrl.ListJobs)
+	r.GET("/job/stream", ctrl.WatchJob)
+	r.GET("/embedding/usage", ctrl.GetEmbeddingUsage)
+	r.GET("/index/config", ctrl.GetIndexConfig)
+	r.PUT("/index/config", ctrl.UpdateIndexConfig)
+	// 管理接口，仅super_admin有权限
+	r.GET("/admin/index/stats", ctrl.GetIndexStats)
 }
\ No newline at end of file
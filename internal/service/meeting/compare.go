@@ -0,0 +1,142 @@
+package meetingService
+
+import (
+	"ai_jianli_go/component"
+	"ai_jianli_go/logs"
+	"ai_jianli_go/types/model"
+	"ai_jianli_go/types/req"
+	"ai_jianli_go/types/resp/common"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	// 单次对比的面试数上限
+	maxCompareMeetings = 50
+	defaultCompareTopN = 3
+)
+
+// Compare 对比同一职位（或指定面试ID）的候选人，按最新评价报告的总体得分排名。
+// 对比不生成报告，未完成或还没有评价报告的面试不参与排名，在Skipped中说明原因
+func (s *MeetingService) Compare(ctx context.Context, request *req.CompareMeetingReq) (*model.CandidateRanking, int64) {
+	request.Position = strings.TrimSpace(request.Position)
+	if request.Position == "" && len(request.MeetingIDs) == 0 {
+		return nil, common.CodeInvalidParams
+	}
+
+	meetings, err := s.dao.ListForCompare(request.UserID, request.Position, request.MeetingIDs)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取对比的面试失败: %v", err)
+		return nil, common.CodeGetMeetingFail
+	}
+	if len(meetings) == 0 {
+		return nil, common.CodeMeetingNotExist
+	}
+	if len(meetings) > maxCompareMeetings {
+		return nil, common.CodeInvalidParams
+	}
+
+	ranking := &model.CandidateRanking{
+		Position:   comparePosition(request.Position, meetings),
+		Candidates: make([]model.CandidateComparison, 0, len(meetings)),
+		Skipped:    make([]model.SkippedMeeting, 0),
+	}
+	for i := range meetings {
+		meeting := &meetings[i]
+		if meeting.Status != COMPLETED {
+			ranking.Skipped = append(ranking.Skipped, model.SkippedMeeting{
+				MeetingID: meeting.ID,
+				Candidate: meeting.Candidate,
+				Reason:    common.GetMsg(common.CodeMeetingNotCompleted),
+			})
+			continue
+		}
+		report, code := s.savedReport(meeting, nil)
+		if code != common.CodeSuccess {
+			ranking.Skipped = append(ranking.Skipped, model.SkippedMeeting{
+				MeetingID: meeting.ID,
+				Candidate: meeting.Candidate,
+				Reason:    common.GetMsg(code),
+			})
+			continue
+		}
+		ranking.Candidates = append(ranking.Candidates, model.NewCandidateComparison(meeting, report))
+	}
+	model.RankCandidates(ranking.Candidates)
+
+	if request.Summary && len(ranking.Candidates) > 1 {
+		topN := request.TopN
+		if topN <= 0 {
+			topN = defaultCompareTopN
+		}
+		top := ranking.Candidates[:min(max(topN, 2), len(ranking.Candidates))]
+		// 总结生成失败不影响排名结果
+		ranking.Summary, err = generateCompareSummary(ctx, top, meetings[0].JobDescription)
+		if err != nil {
+			logs.SugarLogger.Errorf("生成候选人对比总结失败: %v", err)
+		}
+	}
+	return ranking, common.CodeSuccess
+}
+
+// comparePosition 对比的职位，按面试ID对比且各面试职位不同时为空
+func comparePosition(position string, meetings []model.Meeting) string {
+	if position != "" {
+		return position
+	}
+	for _, meeting := range meetings[1:] {
+		if meeting.Position != meetings[0].Position {
+			return ""
+		}
+	}
+	return meetings[0].Position
+}
+
+// generateCompareSummary 总结排名靠前候选人之间的差异
+func generateCompareSummary(ctx context.Context, candidates []model.CandidateComparison, jobDescription string) (string, error) {
+	var input strings.Builder
+	for _, c := range candidates {
+		fmt.Fprintf(&input, "【第%d名 %s】总体得分：%.1f（%s），岗位匹配度：%.0f%%\n", c.Rank, c.Candidate, c.Score, c.Rating, c.MatchPercentage)
+		names := make([]string, 0, len(c.Dimensions))
+		for name := range c.Dimensions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&input, "%s：%.1f\n", name, c.Dimensions[name])
+		}
+		if len(c.MissingKeywords) > 0 {
+			input.WriteString("欠缺：" + strings.Join(c.MissingKeywords, "、") + "\n")
+		}
+	}
+
+	chatModel := component.GetAIComponent().GetFeatureModel(component.FeatureRemark)
+	template := prompt.FromMessages(schema.FString,
+		schema.SystemMessage(
+			"你是一个专业的招聘顾问，需要根据同一岗位多位候选人的面试评价得分以及岗位描述，总结排名靠前候选人之间的差异。\n"+
+				"要求：\n"+
+				"1. 对比各候选人在各胜任力维度和岗位匹配度上的优势与短板，不要复述全部分数。\n"+
+				"2. 说明排名差距的主要原因，并指出各候选人适合的情形或需要进一步考察的点。\n"+
+				"3. 直接返回文字总结，不要返回JSON或Markdown代码块。",
+		),
+		schema.UserMessage("候选人得分：\n{input}\n岗位描述：{job_description}"),
+	)
+	messages, err := template.Format(ctx, map[string]any{
+		"input":           input.String(),
+		"job_description": jobDescription,
+	})
+	if err != nil {
+		return "", fmt.Errorf("format compare prompt failed: %w", err)
+	}
+
+	resp, err := chatModel.Generate(ctx, messages)
+	if err != nil {
+		return "", fmt.Errorf("generate compare summary failed: %w", err)
+	}
+	return resp.Content, nil
+}
@@ -0,0 +1,270 @@
+package meetingService
+
+import (
+	"ai_jianli_go/logs"
+	"ai_jianli_go/pkg/pdf"
+	"ai_jianli_go/types/model"
+	"ai_jianli_go/types/req"
+	"ai_jianli_go/types/resp"
+	"ai_jianli_go/types/resp/common"
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// 面试报告导出格式
+const (
+	ExportFormatPDF      = "pdf"
+	ExportFormatMarkdown = "md"
+	ExportFormatHTML     = "html"
+)
+
+var exportContentTypes = map[string]string{
+	ExportFormatPDF:      "application/pdf",
+	ExportFormatMarkdown: "text/markdown; charset=utf-8",
+	ExportFormatHTML:     "text/html; charset=utf-8",
+}
+
+// exportSection 导出文档的一节，三种格式由同一组章节渲染
+type exportSection struct {
+	Level      int // 标题级别，从1开始
+	Title      string
+	Paragraphs []string
+}
+
+// ExportReport 把面试评价报告、各轮评价和完整面试记录导出为PDF、Markdown或HTML文档。
+// 未指定版本时使用最新的评价报告，还没有报告时先生成
+func (s *MeetingService) ExportReport(ctx context.Context, request *req.ExportReportReq) (*resp.ExportFile, int64) {
+	if request.Format == "" {
+		request.Format = ExportFormatPDF
+	}
+	contentType, ok := exportContentTypes[request.Format]
+	if !ok {
+		return nil, common.CodeInvalidParams
+	}
+
+	meeting, err := s.dao.GetByUser(request.MeetingID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试记录失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+	if meeting.Status != COMPLETED {
+		return nil, common.CodeMeetingNotCompleted
+	}
+
+	var report *model.MeetingReport
+	if request.Version > 0 {
+		if report, err = s.dao.GetReport(meeting.ID, 0, request.Version); err != nil {
+			logs.SugarLogger.Errorf("获取面试评价报告失败: %v", err)
+			return nil, common.CodeRecordNotFound
+		}
+	} else {
+		var code int64
+		if report, code = s.latestReport(ctx, meeting, nil); code != common.CodeSuccess {
+			return nil, code
+		}
+	}
+
+	rounds, err := s.dao.ListRounds(meeting.ID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试轮次失败: %v", err)
+		return nil, common.CodeGetMeetingFail
+	}
+	stages, err := s.dao.ListStages(meeting.ID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试阶段失败: %v", err)
+		return nil, common.CodeGetMeetingFail
+	}
+
+	sections := exportSections(meeting, report, rounds, stages)
+	var data []byte
+	switch request.Format {
+	case ExportFormatPDF:
+		data = renderPDF(sections)
+	case ExportFormatMarkdown:
+		data = renderMarkdown(sections)
+	case ExportFormatHTML:
+		if data, err = renderHTML(sections); err != nil {
+			logs.SugarLogger.Errorf("渲染面试报告失败: %v", err)
+			return nil, common.CodeServerBusy
+		}
+	}
+
+	return &resp.ExportFile{
+		Name:        fmt.Sprintf("面试报告_%s_%d.%s", exportFileName(meeting.Candidate), meeting.ID, request.Format),
+		ASCIIName:   fmt.Sprintf("interview_report_%d.%s", meeting.ID, request.Format),
+		ContentType: contentType,
+		Data:        data,
+	}, common.CodeSuccess
+}
+
+// exportSections 按报告、各轮评价、面试记录的顺序组织导出内容
+func exportSections(meeting *model.Meeting, report *model.MeetingReport, rounds []model.MeetingRound, stages []model.MeetingStage) []exportSection {
+	r := report.Report
+	sections := []exportSection{{
+		Level: 1,
+		Title: "面试报告 - " + meeting.Candidate,
+		Paragraphs: []string{fmt.Sprintf("职位：%s\n报告版本：%d\n报告生成时间：%s",
+			meeting.Position, report.Version, report.CreatedAt.Format("2006-01-02 15:04"))},
+	}}
+
+	overall := r.OverallEvaluation
+	sections = append(sections, exportSection{
+		Level: 2,
+		Title: "总体评价",
+		Paragraphs: []string{
+			fmt.Sprintf("总体得分：%.1f/%.0f（%s）", overall.Score, overall.MaxScore, overall.Rating),
+			overall.Comment,
+		},
+	})
+
+	dimensions := make([]string, 0, len(r.CompetencyDimensions.Dimensions))
+	for _, d := range r.CompetencyDimensions.Dimensions {
+		dimensions = append(dimensions, fmt.Sprintf("%s：%.1f/%.0f", d.Name, d.Score, d.FullMark))
+	}
+	sections = append(sections, exportSection{Level: 2, Title: "胜任力维度", Paragraphs: []string{strings.Join(dimensions, "\n")}})
+
+	match := []string{fmt.Sprintf("匹配度：%.0f%%", r.JDMatch.MatchPercentage)}
+	if len(r.JDMatch.MatchedKeywords) > 0 {
+		match = append(match, "已匹配："+strings.Join(r.JDMatch.MatchedKeywords, "、"))
+	}
+	if len(r.JDMatch.MissingKeywords) > 0 {
+		match = append(match, "欠缺："+strings.Join(r.JDMatch.MissingKeywords, "、"))
+	}
+	sections = append(sections, exportSection{Level: 2, Title: "岗位匹配度", Paragraphs: []string{strings.Join(match, "\n")}})
+
+	if len(r.AnswerAnalysis.KeywordCloud.Keywords) > 0 {
+		keywords := make([]string, 0, len(r.AnswerAnalysis.KeywordCloud.Keywords))
+		for _, k := range r.AnswerAnalysis.KeywordCloud.Keywords {
+			keywords = append(keywords, fmt.Sprintf("%s(%g)", k.Text, k.Value))
+		}
+		sections = append(sections, exportSection{Level: 2, Title: "回答关键词", Paragraphs: []string{strings.Join(keywords, "、")}})
+	}
+
+	if len(r.ImprovablePoints) > 0 {
+		points := make([]string, 0, len(r.ImprovablePoints))
+		for i, p := range r.ImprovablePoints {
+			points = append(points, fmt.Sprintf("%d. %s", i+1, p))
+		}
+		sections = append(sections, exportSection{Level: 2, Title: "可改进点", Paragraphs: []string{strings.Join(points, "\n")}})
+	}
+
+	if len(rounds) > 0 {
+		sections = append(sections, exportSection{Level: 2, Title: "各轮评价"})
+		stageNames := make(map[uint]string, len(stages))
+		for _, stage := range stages {
+			stageNames[stage.ID] = fmt.Sprintf("阶段%d %s", stage.Seq+1, stage.Name)
+		}
+		for i, round := range rounds {
+			title := fmt.Sprintf("第%d轮", round.Round)
+			if name, ok := stageNames[round.StageID]; ok {
+				title = name + " " + title
+			}
+			// 每轮回答的是同一阶段上一轮提出的问题
+			var question string
+			if i > 0 && rounds[i-1].StageID == round.StageID {
+				question = rounds[i-1].NextQuestion
+			}
+			sections = append(sections, exportSection{Level: 3, Title: title, Paragraphs: roundParagraphs(round, question)})
+		}
+	}
+
+	if meeting.InterviewRecord != "" {
+		sections = append(sections, exportSection{Level: 2, Title: "面试记录", Paragraphs: []string{meeting.InterviewRecord}})
+	}
+	return sections
+}
+
+// roundParagraphs 单轮的问题、回答、评分和评价
+func roundParagraphs(round model.MeetingRound, question string) []string {
+	var paragraphs []string
+	if question != "" {
+		paragraphs = append(paragraphs, "问题："+question)
+	}
+	paragraphs = append(paragraphs, "回答："+round.Answer)
+	if round.Delivery != nil {
+		paragraphs = append(paragraphs, "语音表达："+round.Delivery.String())
+	}
+	if round.Score.Scored() {
+		score := fmt.Sprintf("评分：正确性%d 深度%d 表达清晰度%d，总分%.1f",
+			round.Score.Correctness, round.Score.Depth, round.Score.Clarity, round.Score.Total())
+		if round.Score.Comment != "" {
+			score += "（" + round.Score.Comment + "）"
+		}
+		paragraphs = append(paragraphs, score)
+	}
+	if round.Evaluation != "" {
+		paragraphs = append(paragraphs, "评价："+round.Evaluation)
+	}
+	if len(round.Strengths) > 0 {
+		paragraphs = append(paragraphs, "优点："+strings.Join(round.Strengths, "；"))
+	}
+	if len(round.Weaknesses) > 0 {
+		paragraphs = append(paragraphs, "不足："+strings.Join(round.Weaknesses, "；"))
+	}
+	return paragraphs
+}
+
+func renderPDF(sections []exportSection) []byte {
+	doc := pdf.New()
+	for _, section := range sections {
+		doc.Heading(section.Level, section.Title)
+		for _, p := range section.Paragraphs {
+			doc.Paragraph(p)
+		}
+	}
+	return doc.Bytes()
+}
+
+func renderMarkdown(sections []exportSection) []byte {
+	var buf bytes.Buffer
+	for _, section := range sections {
+		fmt.Fprintf(&buf, "%s %s\n\n", strings.Repeat("#", section.Level), section.Title)
+		for _, p := range section.Paragraphs {
+			// 段落内的换行在Markdown中需要用行尾两个空格表示
+			buf.WriteString(strings.ReplaceAll(strings.TrimSpace(p), "\n", "  \n") + "\n\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+var exportHTMLTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{with index . 0}}{{.Title}}{{end}}</title>
+<style>
+body { max-width: 860px; margin: 40px auto; padding: 0 20px; font-family: sans-serif; line-height: 1.6; color: #333; }
+p { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+{{range .}}{{if eq .Level 1}}<h1>{{.Title}}</h1>{{else if eq .Level 2}}<h2>{{.Title}}</h2>{{else}}<h3>{{.Title}}</h3>{{end}}
+{{range .Paragraphs}}<p>{{.}}</p>
+{{end}}{{end}}</body>
+</html>
+`))
+
+func renderHTML(sections []exportSection) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := exportHTMLTemplate.Execute(&buf, sections); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportFileName 去掉候选人姓名中不能用于文件名的字符
+func exportFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return "候选人"
+	}
+	return name
+}
@@ -10,19 +10,21 @@ import (
 	"ai_jianli_go/types/req"
 	"ai_jianli_go/types/resp/common"
 	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"io"
 
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/schema"
 )
 
 type MeetingService struct {
-	dao *dao.MeetingDAO
+	dao         *dao.MeetingDAO
+	questionDAO *dao.QuestionDAO
 }
 
-func NewMeetingService(dao *dao.MeetingDAO) *MeetingService {
-	return &MeetingService{dao: dao}
+func NewMeetingService(dao *dao.MeetingDAO, questionDAO *dao.QuestionDAO) *MeetingService {
+	return &MeetingService{dao: dao, questionDAO: questionDAO}
 }
 
 const (
@@ -34,6 +36,14 @@ const (
 
 // 创建面试
 func (s *MeetingService) Create(request *req.CreateMeetingReq) int64 {
+	plan := model.DefaultInterviewPlan()
+	if request.Plan != nil {
+		plan = *request.Plan
+		if err := plan.Validate(); err != nil {
+			logs.SugarLogger.Errorf("面试计划不合法: %v", err)
+			return common.CodeInvalidInterviewPlan
+		}
+	}
 	meeting := &model.Meeting{
 		UserID:         request.UserID,
 		Candidate:      request.Candidate,
@@ -42,8 +52,14 @@ func (s *MeetingService) Create(request *req.CreateMeetingReq) int64 {
 		Time:           request.Time,
 		Status:         PLANED,
 		WikiID:         request.WikiID,
+		WikiFolderID:   request.WikiFolderID,
+		Plan:           plan,
+	}
+	stages, code := buildStages(request.Stages, plan)
+	if code != common.CodeSuccess {
+		return code
 	}
-	err := s.dao.Create(meeting)
+	err := s.dao.CreateWithStages(meeting, stages)
 	if err != nil {
 		logs.SugarLogger.Errorf("创建面试记录失败: %v", err)
 		return common.CodeCreateMeetingFail
@@ -74,9 +90,27 @@ func (s *MeetingService) Update(request *req.UpdateMeetingReq) int64 {
 	if request.Time != 0 {
 		meeting.Time = request.Time
 	}
+	if request.Plan != nil {
+		plan := *request.Plan
+		if err = plan.Validate(); err != nil {
+			logs.SugarLogger.Errorf("面试计划不合法: %v", err)
+			return common.CodeInvalidInterviewPlan
+		}
+		meeting.Plan = plan
+	}
 	if request.Status != "" {
 		meeting.Status = request.Status
-		if request.Status == COMPLETED {
+		stages, err := s.dao.ListStages(meeting.ID)
+		if err != nil {
+			logs.SugarLogger.Errorf("获取面试阶段失败: %v", err)
+			return common.CodeUpdateMeetingFail
+		}
+		if request.Status == COMPLETED && len(stages) > 0 {
+			if err = s.completeStages(meeting, stages); err != nil {
+				logs.SugarLogger.Errorf("结束面试阶段失败: %v", err)
+				return common.CodeUpdateMeetingFail
+			}
+		} else if request.Status == COMPLETED {
 			memory := rag.NewRedisMemory(rag.RedisMemoryConfig{
 				RedisOptions:  component.GetRedisDB(),
 				MaxWindowSize: 20,
@@ -102,6 +136,11 @@ func (s *MeetingService) Get(id uint) (*model.Meeting, int64) {
 		logs.SugarLogger.Errorf("获取面试记录失败: %v", err)
 		return nil, common.CodeMeetingNotExist
 	}
+	meeting.Stages, err = s.dao.ListStages(id)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试阶段失败: %v", err)
+		return nil, common.CodeGetMeetingFail
+	}
 	return meeting, common.CodeSuccess
 }
 
@@ -125,6 +164,21 @@ func (s *MeetingService) Delete(id uint) int64 {
 	return common.CodeSuccess
 }
 
+// 获取面试各轮结构化结果
+func (s *MeetingService) GetRounds(request *req.GetMeetingReq) ([]model.MeetingRound, int64) {
+	meeting, err := s.dao.GetByUser(request.ID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试记录失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+	rounds, err := s.dao.ListRounds(meeting.ID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试轮次失败: %v", err)
+		return nil, common.CodeGetMeetingFail
+	}
+	return rounds, common.CodeSuccess
+}
+
 // 上传简历
 func (s *MeetingService) UploadResume(request *req.UploadResumeReq) int64 {
 	err := s.dao.UploadResume(request.MeetingID, request.Resume)
@@ -144,42 +198,205 @@ func (s *MeetingService) GetResume(meetingID uint) (string, int64) {
 	return resume, common.CodeSuccess
 }
 
+// interviewTurn 一轮AI面试的上下文
+type interviewTurn struct {
+	meeting  *model.Meeting
+	stages   []model.MeetingStage
+	stage    *model.MeetingStage // 当前阶段，单阶段面试为nil
+	plan     model.InterviewPlan
+	round    int // 本轮轮次，从1开始
+	con      *rag.Conversation
+	answer   string
+	delivery *model.SpeechDelivery // 语音回答的表达情况，文字回答为nil
+	question *model.Question       // 本轮提供给模型的题库题目，没有时为nil
+	basis    string                // 本轮回答的评分依据
+	messages []*schema.Message
+}
+
 // AI面试主流程
-func (s *MeetingService) AIInterview(request *req.AIInterviewReq) (string, int64) {
+func (s *MeetingService) AIInterview(request *req.AIInterviewReq) (*model.MeetingRound, int64) {
+	ctx := context.Background()
+	turn, code := s.prepareInterview(ctx, request)
+	if code != common.CodeSuccess {
+		return nil, code
+	}
+
+	chatModel := component.GetAIComponent().GetFeatureModel(component.FeatureInterview)
+	res, err := chatModel.Generate(ctx, turn.messages)
+	if err != nil {
+		logs.SugarLogger.Error(err)
+		return nil, common.CodeServerBusy
+	}
+
+	return s.finishInterview(turn, res)
+}
+
+// AIInterviewStream 流式AI面试，模型输出的字符串字段每生成一段文本、每完成一个顶层字段就回调onField，生成结束后才写入对话记录
+func (s *MeetingService) AIInterviewStream(ctx context.Context, request *req.AIInterviewReq, onField func(field model.TurnField) error) (*model.MeetingRound, int64) {
+	turn, code := s.prepareInterview(ctx, request)
+	if code != common.CodeSuccess {
+		return nil, code
+	}
+
+	chatModel := component.GetAIComponent().GetFeatureModel(component.FeatureInterview)
+	stream, err := chatModel.Stream(ctx, turn.messages)
+	if err != nil {
+		logs.SugarLogger.Errorf("创建流式回答失败: %v", err)
+		return nil, common.CodeInterviewGenerateFail
+	}
+	defer stream.Close()
+
+	chunks := make([]*schema.Message, 0)
+	var fields model.TurnStream
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			logs.SugarLogger.Errorf("接收流式回答失败: %v", err)
+			return nil, common.CodeInterviewGenerateFail
+		}
+		chunks = append(chunks, chunk)
+		if chunk.Content == "" {
+			continue
+		}
+		// 客户端断开时直接放弃本轮，不写入对话记录
+		for _, field := range fields.Write(chunk.Content) {
+			if err = onField(field); err != nil {
+				logs.SugarLogger.Errorf("推送流式回答失败: %v", err)
+				return nil, common.CodeServerBusy
+			}
+		}
+	}
+
+	res, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		logs.SugarLogger.Errorf("合并流式回答失败: %v", err)
+		return nil, common.CodeInterviewGenerateFail
+	}
+
+	return s.finishInterview(turn, res)
+}
+
+// prepareInterview 校验面试状态，检索知识库并构建本轮提示
+func (s *MeetingService) prepareInterview(ctx context.Context, request *req.AIInterviewReq) (*interviewTurn, int64) {
 	meeting, err := s.dao.GetByID(request.MeetingID)
 	if err != nil {
 		logs.SugarLogger.Errorf("获取面试记录失败: %v", err)
-		return "", common.CodeMeetingNotExist
+		return nil, common.CodeMeetingNotExist
 	}
 
 	// 检查面试状态
 	if meeting.Status == CANCELED {
-		return "", common.CodeMeetingCompleted
+		return nil, common.CodeMeetingCompleted
 	}
 
 	if meeting.Resume == "" {
-		return "", common.CodeResumeNotExist
+		return nil, common.CodeResumeNotExist
+	}
+
+	delivery, code := s.answerDelivery(ctx, request)
+	if code != common.CodeSuccess {
+		return nil, code
+	}
+
+	// 多阶段面试使用当前阶段的人设、知识库和计划
+	stages, stage, code := s.currentStage(meeting)
+	if code != common.CodeSuccess {
+		return nil, code
+	}
+	plan := meeting.GetPlan()
+	wikiID, wikiFolderID := meeting.WikiID, meeting.WikiFolderID
+	persona := defaultPersona
+	var stageID uint
+	if stage != nil {
+		if meeting.Status == COMPLETED {
+			return nil, common.CodeMeetingCompleted
+		}
+		if stage.Status == COMPLETED {
+			return nil, common.CodeStageCompleted
+		}
+		plan = stage.GetPlan()
+		wikiID, wikiFolderID = stage.WikiID, stage.WikiFolderID
+		persona = stage.GetPersona()
+		stageID = stage.ID
 	}
 
 	// 获取历史对话
-	ctx := context.Background()
 	memory := rag.NewRedisMemory(rag.RedisMemoryConfig{
 		MaxWindowSize: 20,
 		RedisOptions:  component.GetRedisDB(),
 	})
 
-	con := memory.GetConversation(fmt.Sprintf("%d", request.MeetingID), false)
+	con := memory.GetConversation(conversationID(meeting.ID, stage), false)
 
-	// 检查面试轮数
-	if con.GetRoundCount() >= 20 {
+	// 按面试计划检查面试轮数
+	count, err := s.dao.CountRounds(meeting.ID, stageID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试轮次失败: %v", err)
+		return nil, common.CodeServerBusy
+	}
+	if int(count) >= plan.TotalRounds {
+		if stage != nil {
+			if err = s.finishStage(meeting, stages, stage, con); err != nil {
+				logs.SugarLogger.Errorf("结束面试阶段失败: %v", err)
+				return nil, common.CodeServerBusy
+			}
+			return nil, common.CodeStageCompleted
+		}
 		meeting.Status = COMPLETED
 		meeting.InterviewNumber = con.GetRoundCount()
 		meeting.InterviewRecord = con.String()
 		if err = s.dao.Update(meeting); err != nil {
 			logs.SugarLogger.Errorf("更新面试轮数失败: %v", err)
-			return "", common.CodeServerBusy
+			return nil, common.CodeServerBusy
+		}
+		return nil, common.CodeInterviewRoundLimit
+	}
+	round := int(count) + 1
+
+	if stage != nil && stage.Status == PLANED {
+		stage.Status = INTERVIEWING
+		if err = s.dao.UpdateStage(stage); err != nil {
+			logs.SugarLogger.Errorf("更新面试阶段失败: %v", err)
+			return nil, common.CodeServerBusy
+		}
+	}
+
+	// 第一轮和上一轮追问已达上限时，本轮必须换新的知识点
+	followUpRule := "追问深度未达上限，可以继续追问，也可以提出新的知识点"
+	last, err := s.dao.GetLastRound(meeting.ID, stageID)
+	if err != nil {
+		last = nil
+	}
+	newTopic := last == nil || last.FollowUpDepth >= plan.MaxFollowUps
+	if last != nil && newTopic {
+		followUpRule = "上一轮追问深度已达上限，本轮必须提出新的知识点，follow_up_depth为0"
+	}
+	// 简历和知识库问题的比例按新问题计算，追问不计
+	topics, err := s.dao.CountTopics(meeting.ID, stageID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试轮次失败: %v", err)
+		return nil, common.CodeServerBusy
+	}
+	source := plan.QuestionSourceAt(int(topics) + 1)
+	if wikiID == 0 {
+		source = model.QuestionSourceResume
+	}
+	sourceRule := "新的知识点从简历内容中选取"
+	// 从知识库出题时优先使用题库中审核通过的题目，只在提出新的知识点时使用，追问不受影响
+	var question *model.Question
+	if source == model.QuestionSourceWiki {
+		sourceRule = "新的知识点从知识库上下文中选取"
+		question = s.pickQuestion(request.UserID, meeting.ID, wikiID, wikiFolderID, plan.DifficultyAt(round))
+		switch {
+		case question == nil:
+		case newTopic:
+			sourceRule = "next_question必须原样使用题库题目：" + question.Content
+		default:
+			sourceRule = "next_question必须原样使用题库题目：" + question.Content + "；继续追问时不使用该题目"
 		}
-		return "", common.CodeInterviewRoundLimit
 	}
 
 	if con.GetLastConversationsKnowledge() == "" {
@@ -187,176 +404,276 @@ func (s *MeetingService) AIInterview(request *req.AIInterviewReq) (string, int64
 	}
 
 	var wiki string
-	var code int64
-	if meeting.WikiID != 0 {
-		wikiService := wikiService.NewWikiService(dao.NewWikiDAO(component.GetMySQLDB()))
-		wiki, code = wikiService.Query(&req.QueryWikiRequest{
+	if wikiID != 0 {
+		// 面试限定在某个文件夹时只检索该文件夹（含子文件夹）下的文章
+		var filter map[string]any
+		if wikiFolderID != 0 {
+			filter = map[string]any{model.FilterFolderID: wikiFolderID}
+		}
+		wikiService := wikiService.NewWikiService(dao.NewWikiDAO(component.GetMySQLDB()), dao.NewWikiJobDAO(component.GetMySQLDB()))
+		answer, code := wikiService.Query(&req.QueryWikiRequest{
 			UserID: request.UserID,
-			RootId: meeting.WikiID,
+			RootId: wikiID,
 			Query:  con.GetLastConversationsKnowledge(),
+			Filter: filter,
 		})
 		if code != common.CodeSuccess {
-			return "", code
+			return nil, code
 		}
-	}else{
+		wiki = answer.Answer
+	} else {
 		wiki = con.GetLastConversationsKnowledge()
 	}
 
-	// 创建对话模型
-	chatModel := component.GetAIComponent().GetChatModel("gpt-4o")
+	// 评价本轮回答时优先以上一题的参考答案为准，其次是知识库上下文
+	basis := model.ScoreBasisResume
+	if wikiID != 0 {
+		basis = model.ScoreBasisWiki
+	}
+	reference := s.referenceAnswer(last)
+	if reference != "" {
+		basis = model.ScoreBasisReference
+	} else {
+		reference = "无"
+	}
 
 	// 创建提示模板
 	template := prompt.FromMessages(schema.FString,
 		schema.SystemMessage(
-			"你是一个专业面试官，需要完成以下任务：\n"+
+			"{persona}，需要完成以下任务：\n"+
 				"1. 基于专业知识库内容提出精准问题\n"+
 				"2. 对用户回答进行结构化评价（优点/不足）， 评价后要接着提出问题\n"+
 				"3. 针对不足点给出专业解释\n"+
-				"4. 根据用户回答生成1-4轮深度追问， 追问结束继续根据简历内容提问\n"+
+				"4. 根据用户回答生成1-{max_follow_ups}轮深度追问， 追问结束继续提出新的知识点\n"+
 				"5. 每次回答都需要返回要问的知识点（关键词）以便后续知识库检索提问\n\n"+
-				"6. 追问每次只追问一道题目， 后续在根据用户回答继续追问，最多追问4轮\n"+
-				"7. 如果用户表示不会， 请不要继续追问， 提问简历的其他知识点\n"+
-				"8. 当前是第"+fmt.Sprintf("%d", con.GetRoundCount()+1)+"轮面试，总共20轮\n"+
+				"6. 追问每次只追问一道题目， 后续在根据用户回答继续追问，最多追问{max_follow_ups}轮，{follow_up_rule}\n"+
+				"7. 如果用户表示不会， 请不要继续追问， 提问新的知识点\n"+
+				"8. 当前是第{round}轮面试，总共{total_rounds}轮，本轮题目难度为{difficulty}级（1-5级，1最简单）\n"+
 				"9. 如果用户回答与面试内容无关， 请统一提醒它正在面试（返回知识点继承上次对话的）\n"+
+				"10. 提出新的知识点时，{source_rule}\n"+
+				"11. 按评分标准对应聘者本轮回答打分（每项1-10分）：correctness正确性，有上一题的参考答案时以参考答案为准，否则以知识库上下文为准；"+
+				"depth深度，是否讲清原理、边界和实际经验；clarity表达清晰度，是否条理清楚、重点突出。"+
+				"本轮回答不是对面试问题的作答（如开场问候）时各项为0，comment写明评分理由\n\n"+
 				"当前知识库上下文：{context}\n\n"+
+				"上一题的参考答案：{reference_answer}\n\n"+
 				"当前对话记录：{history}\n\n"+
 				"用户简历内容:{resume}\n"+
 				"职位描述:{job_description}\n"+
 				"输出格式要求：\n"+
-				"- 评价使用✅和❌标识优劣点\n",
+				"1. 你必须只返回一个纯净的JSON对象，不要有任何额外的前缀、后缀、解释或Markdown代码块标记（如```json）。\n"+
+				"2. strengths和weaknesses每项只写一个优点或不足，不要带✅/❌标识。\n"+
+				"3. follow_up_depth为当前问题的追问深度，提问新的知识点时为0，最大为{max_follow_ups}。\n",
 		),
 		schema.UserMessage("【应聘者回答】\n{answer}"),
 		schema.AssistantMessage(
-			"请按以下结构组织回答：\n"+
-				"1. 评价总结（含具体不足点分析）\n"+
-				"2. 可追问的知识点:knowledgepoint\n"+
-				"3. 问题:question\n",
+			"返回数据格式：{output}\n",
 			[]schema.ToolCall{},
 		),
 	)
 
 	// 构建提示
 	prompt := map[string]any{
-		"context":        wiki,
-		"answer":          request.Answer,
-		"resume":          meeting.Resume,
-		"history":         con.String(),
-		"job_description": meeting.JobDescription,
+		"persona":          persona,
+		"context":          wiki,
+		"answer":           request.Answer,
+		"resume":           meeting.Resume,
+		"history":          con.String(),
+		"job_description":  meeting.JobDescription,
+		"output":           turnOutput,
+		"round":            round,
+		"total_rounds":     plan.TotalRounds,
+		"difficulty":       plan.DifficultyAt(round),
+		"max_follow_ups":   plan.MaxFollowUps,
+		"follow_up_rule":   followUpRule,
+		"source_rule":      sourceRule,
+		"reference_answer": reference,
 	}
 
-	// 9. 生成回答
 	messages, err := template.Format(ctx, prompt)
 	if err != nil {
 		logs.SugarLogger.Errorf("生成回答失败: %v", err)
-		return "", common.CodeInterviewGenerateFail
+		return nil, common.CodeInterviewGenerateFail
+	}
+
+	return &interviewTurn{
+		meeting:  meeting,
+		stages:   stages,
+		stage:    stage,
+		plan:     plan,
+		round:    round,
+		con:      con,
+		answer:   request.Answer,
+		delivery: delivery,
+		question: question,
+		basis:    basis,
+		messages: messages,
+	}, common.CodeSuccess
+}
+
+// answerDelivery 按识别ID读取服务端保存的识别结果并分析表达情况，文字回答返回nil
+func (s *MeetingService) answerDelivery(ctx context.Context, request *req.AIInterviewReq) (*model.SpeechDelivery, int64) {
+	if request.RecognitionID == "" {
+		return nil, common.CodeSuccess
 	}
+	transcript, err := model.LoadRecognition(ctx, component.GetRedisDB(), request.UserID, request.RecognitionID)
+	if errors.Is(err, model.ErrRecognitionNotFound) {
+		return nil, common.CodeRecognitionNotFound
+	}
+	if err != nil {
+		logs.SugarLogger.Errorf("获取语音识别结果失败: %v", err)
+		return nil, common.CodeInterviewGenerateFail
+	}
+	// 用户可能修正了低置信度的词，回答内容以提交的文本为准，表达分析使用识别结果的词级时间
+	if distance := transcript.EditDistance(request.Answer); distance > 0 {
+		logs.SugarLogger.Infof("面试%d的回答修改了语音识别结果, 编辑距离: %d", request.MeetingID, distance)
+	}
+	return model.AnalyzeDelivery(transcript), common.CodeSuccess
+}
+
+// finishInterview 模型回答完成后解析结构化输出，写入对话记录并保存本轮结果，达到计划轮数时结束面试
+func (s *MeetingService) finishInterview(turn *interviewTurn, res *schema.Message) (*model.MeetingRound, int64) {
+	con := turn.con
 
-	con.Append(schema.UserMessage(request.Answer))
-	res, err := chatModel.Generate(ctx, messages)
+	output, err := model.ParseInterviewTurn(res.Content, turn.plan.MaxFollowUps)
 	if err != nil {
-		logs.SugarLogger.Error(err)
-		return "", common.CodeServerBusy
+		logs.SugarLogger.Errorf("解析面试回答失败: %v, content: %s", err, res.Content)
+		return nil, common.CodeInterviewGenerateFail
 	}
 
-	// 10. 提取知识点并更新对话
-	knowledgePoint := extractKnowledgePoint(res.Content)
-	con.SetLastConversationKnowledge(knowledgePoint)
-	con.Append(res)
+	round := &model.MeetingRound{
+		MeetingID:     turn.meeting.ID,
+		StageID:       stageIDOf(turn.stage),
+		Round:         turn.round,
+		Answer:        turn.answer,
+		Delivery:      turn.delivery,
+		InterviewTurn: *output,
+	}
+	if round.Score.Scored() {
+		round.Score.Basis = turn.basis
+	}
+	useQuestion(round, turn.question)
+	if err = s.dao.CreateRound(round); err != nil {
+		logs.SugarLogger.Errorf("保存面试轮次失败: %v", err)
+		return nil, common.CodeServerBusy
+	}
+	if round.QuestionID != 0 {
+		if err = s.questionDAO.IncrUsed(round.QuestionID); err != nil {
+			logs.SugarLogger.Errorf("更新题目抽取次数失败: %v", err)
+		}
+	}
+
+	// 更新知识点和对话，知识点为空时沿用上一轮
+	if query := output.KnowledgeQuery(); query != "" {
+		con.SetLastConversationKnowledge(query)
+	}
+	con.Append(schema.UserMessage(turn.answer))
+	con.Append(schema.AssistantMessage(output.String(), nil))
 
-	// 如果达到最大轮数，更新面试状态为已完成
-	if con.GetRoundCount() >= 20 {
+	// 如果达到计划轮数，更新面试状态为已完成，多阶段面试只结束当前阶段
+	if turn.round >= turn.plan.TotalRounds && turn.stage != nil {
+		if err := s.finishStage(turn.meeting, turn.stages, turn.stage, con); err != nil {
+			logs.SugarLogger.Errorf("结束面试阶段失败: %v", err)
+			return nil, common.CodeServerBusy
+		}
+	} else if turn.round >= turn.plan.TotalRounds {
+		meeting := turn.meeting
 		meeting.Status = COMPLETED
 		meeting.InterviewRecord = con.String()
 		meeting.InterviewNumber = con.GetRoundCount()
 		if err := s.dao.Update(meeting); err != nil {
 			logs.SugarLogger.Errorf("更新面试记录失败: %v", err)
-			return "", common.CodeServerBusy
+			return nil, common.CodeServerBusy
 		}
 	}
-
-	return res.Content, common.CodeSuccess
-}
-
-// 提取知识点
-func extractKnowledgePoint(input string) string {
-	// 查找"可追问的知识点："的位置
-	prefix := "可追问的知识点："
-	index := strings.Index(input, prefix)
-
-	if index >= 0 {
-		// 提取前缀之后的所有内容
-		result := input[index+len(prefix):]
-		// 去除开头可能的多余空格
-		return strings.TrimSpace(result)
-	}
-
-	// 兼容可能使用英文冒号的情况
-	prefix = "可追问的知识点:"
-	index = strings.Index(input, prefix)
-	if index >= 0 {
-		result := input[index+len(prefix):]
-		return strings.TrimSpace(result)
-	}
-
-	return "" // 未找到匹配
+	return round, common.CodeSuccess
 }
 
-// 获取面试评价
-func (s *MeetingService) GetRemark(ctx context.Context, req *req.GetRemarkReq) (string, int64) {
-	meeting, err := s.dao.GetByID(req.MeetingID)
+// GetRemark 获取面试评价报告，未指定版本时获取最新版本，还没有报告时先生成
+func (s *MeetingService) GetRemark(ctx context.Context, req *req.GetRemarkReq) (*model.MeetingReport, int64) {
+	meeting, err := s.dao.GetByUser(req.MeetingID, req.UserID)
 	if err != nil {
 		logs.SugarLogger.Errorf("获取面试记录失败: %v", err)
-		return "", common.CodeGetMeetingFail
+		return nil, common.CodeRecordNotFound
 	}
 
 	if meeting.Status != COMPLETED {
-		return "", common.CodeMeetingNotCompleted
+		return nil, common.CodeMeetingNotCompleted
 	}
 
-	if meeting.Remark != "" {
-		return meeting.Remark, common.CodeSuccess
+	if req.Version > 0 {
+		report, err := s.dao.GetReport(meeting.ID, 0, req.Version)
+		if err != nil {
+			logs.SugarLogger.Errorf("获取面试评价报告失败: %v", err)
+			return nil, common.CodeRecordNotFound
+		}
+		return report, common.CodeSuccess
 	}
+	return s.latestReport(ctx, meeting, nil)
+}
 
-	model := component.GetAIComponent().GetChatModel("gpt-4o")
+// generateRemark 汇总各轮回答的评分，结合岗位描述和评分标准生成评价报告。
+// 模型返回的内容不符合报告格式时，带上错误原因要求模型修正，最多尝试maxReportAttempts次
+func generateRemark(ctx context.Context, rounds []model.MeetingRound, jobDescription, rubric string) (*model.InterviewReport, model.ScoreSummary, error) {
+	summary := model.SummarizeScores(rounds)
+	chatModel := component.GetAIComponent().GetFeatureModel(component.FeatureRemark)
 	template := prompt.FromMessages(schema.FString,
 		schema.SystemMessage(
-			"你是一个专业的面试官，需要根据面试记录以及岗位描述，生成胜任力维度得分、答题内容分析和总体得分, 面试文字评价和可改进点五大板块json数据。\n"+
+			"你是一个专业的面试官，需要根据面试各轮回答的评分记录以及岗位描述，生成总体评价、胜任力维度得分、答题内容分析、岗位匹配度和可改进点五大板块json数据。\n"+
+				"评分标准：{rubric}\n"+
+				"得分要求：{score_rule}\n"+
 				"重要要求：\n"+
 				"1. 你必须只返回一个纯净的JSON对象，不要有任何额外的前缀、后缀、解释或Markdown代码块标记（如```json）。\n"+
 				"2. JSON必须严格遵循我已提供的格式。\n"+
 				"3. 不要返回任何非JSON文本。",
 		),
-		schema.UserMessage("面试记录和总结：\n{input}"),
+		schema.UserMessage("各轮回答评分记录：\n{input}"),
 		schema.AssistantMessage(
 			"岗位描述：{job_description}\n返回数据格式：{output}\n",
 			[]schema.ToolCall{},
 		),
 	)
 	prompt := map[string]any{
-		"input":           meeting.InterviewRecord,
-		"job_description": meeting.JobDescription,
+		"input":           scoreRecord(rounds),
+		"job_description": jobDescription,
+		"rubric":          rubric,
+		"score_rule":      scoreRule(summary),
 		"output":          output,
 	}
 	messages, err := template.Format(ctx, prompt)
 	if err != nil {
-		logs.SugarLogger.Errorf("生成提示失败: %v", err)
-		return "", common.CodeInterviewGenerateFail
-	}
-
-	resp, err := model.Generate(ctx, messages)
-	if err != nil {
-		logs.SugarLogger.Errorf("生成面试评价失败: %v", err)
-		return "", common.CodeInterviewGenerateFail
+		return nil, summary, fmt.Errorf("format remark prompt failed: %w", err)
 	}
 
-	meeting.Remark = resp.Content
-	if err := s.dao.Update(meeting); err != nil {
-		logs.SugarLogger.Errorf("更新面试记录失败: %v", err)
-		return "", common.CodeServerBusy
+	var parseErr error
+	for attempt := 1; attempt <= maxReportAttempts; attempt++ {
+		resp, err := chatModel.Generate(ctx, messages)
+		if err != nil {
+			return nil, summary, fmt.Errorf("generate remark failed: %w", err)
+		}
+		report, err := model.ParseInterviewReport(resp.Content)
+		if err == nil {
+			report.ApplyScores(summary, rounds)
+			return report, summary, nil
+		}
+		parseErr = err
+		logs.SugarLogger.Warnf("第%d次生成的面试评价不符合格式: %v", attempt, err)
+		messages = append(messages, resp, schema.UserMessage(
+			fmt.Sprintf("返回的内容不符合要求：%v。请修正后重新返回完整的JSON对象，不要返回其他内容。", err)))
 	}
+	return nil, summary, fmt.Errorf("invalid remark after %d attempts: %w", maxReportAttempts, parseErr)
+}
 
-	return resp.Content, common.CodeSuccess
+const turnOutput = `
+{
+  "evaluation": "对应聘者本轮回答的评价总结，包含具体不足点分析",
+  "strengths": ["回答中的优点"],
+  "weaknesses": ["回答中的不足及专业解释"],
+  "knowledge_points": ["下一轮可追问的知识点关键词"],
+  "next_question": "下一个问题，只包含一道题目",
+  "follow_up_depth": 1,
+  "score": {"correctness": 7, "depth": 6, "clarity": 8, "comment": "评分理由"}
 }
+`
 
 const output = `
 {
@@ -364,39 +681,25 @@ const output = `
     "score": 85,
     "maxScore": 100,
     "rating": "良好",
+    "comment": "候选人整体表现良好，具备扎实的专业技术功底和清晰的逻辑思维能力，能够围绕Spring Boot、微服务架构和MySQL等核心技术栈展开深入讨论，但在分布式中间件和云原生技术领域存在经验缺口。",
     "chartType": "gauge"
   },
   "competencyDimensions": {
     "chartType": "radar",
     "dimensions": [
-      {
-        "name": "沟通表达",
-        "score": 82,
-        "fullMark": 100
-      },
-      {
-        "name": "逻辑思维",
-        "score": 88,
-        "fullMark": 100
-      },
       {
         "name": "专业知识",
         "score": 90,
         "fullMark": 100
       },
       {
-        "name": "学习能力",
-        "score": 85,
-        "fullMark": 100
-      },
-      {
-        "name": "抗压性",
-        "score": 80,
+        "name": "思考深度",
+        "score": 88,
         "fullMark": 100
       },
       {
-        "name": "团队合作",
-        "score": 78,
+        "name": "沟通表达",
+        "score": 82,
         "fullMark": 100
       }
     ]
@@ -416,30 +719,19 @@ const output = `
         {
           "text": "MySQL",
           "value": 25
-        },
-        {
-          "text": "分布式系统",
-          "value": 22
-        },
-        {
-          "text": "问题解决",
-          "value": 19
         }
       ]
-    },
-    "jdMatch": {
-      "chartType": "doughnut",
-      "matchPercentage": 76,
-      "matchedKeywords": ["Java", "Spring Boot", "MySQL", "云计算"],
-      "missingKeywords": ["Redis", "消息队列", "容器化"]
-    },
-	"interviewEvaluation": "候选人整体表现良好，具备扎实的专业技术功底和清晰的逻辑思维能力。在面试过程中能够围绕Spring Boot、微服务架构和MySQL等核心技术栈展开深入讨论，展现出较强的系统设计能力和问题解决导向。候选人学习能力较好，对新知识保持求知欲，但在高压环境下的稳定性与团队协作意识有进一步提升空间。其技术能力与当前岗位要求有较高匹配度，但在分布式中间件和云原生技术领域存在经验缺口。",
-  	"improvablePoints": [
-    "团队协作能力有待加强：在跨部门沟通和团队项目协作中表现较为被动，需提升倾听他人意见、整合团队资源的意识与能力[1](@ref)。",
-    "抗压性与情绪管理：在压力情境下表现出一定的紧张感，需增强应对复杂问题和紧迫任务的稳定性与韧性[5](@ref)。",
-    "技术广度需扩展：缺乏Redis缓存应用、消息队列及容器化技术（如Docker/K8s）的实战经验，需针对性补充分布式系统相关知识[3](@ref)。",
-    "表达精炼度不足：技术描述有时过于细节，需提升结构化表达和总结概括能力，增强与非技术人员的沟通效果[1](@ref)。",
-    "岗位匹配度提升：虽然基础技能扎实，但仍需弥补JD中明确的‘消息队列’和‘容器化’要求，可通过快速学习或项目实践补足[3](@ref)。"
-  	]
+    }
+  },
+  "jdMatch": {
+    "chartType": "doughnut",
+    "matchPercentage": 76,
+    "matchedKeywords": ["Java", "Spring Boot", "MySQL"],
+    "missingKeywords": ["Redis", "消息队列", "容器化"]
+  },
+  "improvablePoints": [
+    "技术广度需扩展：缺乏Redis缓存应用、消息队列及容器化技术的实战经验，需针对性补充分布式系统相关知识。",
+    "表达精炼度不足：技术描述有时过于细节，需提升结构化表达和总结概括能力。"
+  ]
 }
 `
@@ -0,0 +1,67 @@
+package meetingService
+
+import (
+	"ai_jianli_go/component"
+	"ai_jianli_go/internal/dao"
+	"ai_jianli_go/logs"
+	"ai_jianli_go/types/model"
+)
+
+// pickQuestion 从知识库题库中为本轮挑选一道审核通过、本场面试未提过的题目，优先难度接近、抽取次数少的题目。
+// 面试限定文件夹时只从该文件夹（含子文件夹）下的文章中挑选，题库中没有可用题目时返回nil，由模型根据检索内容出题
+func (s *MeetingService) pickQuestion(userID, meetingID, wikiID, wikiFolderID uint, difficulty int) *model.Question {
+	used, err := s.dao.UsedQuestionIDs(meetingID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试已使用的题目失败: %v", err)
+		return nil
+	}
+
+	var wikiIDs []uint
+	if wikiFolderID != 0 {
+		descendants, err := dao.NewWikiDAO(component.GetMySQLDB()).ListDescendants(userID, wikiFolderID)
+		if err != nil {
+			logs.SugarLogger.Errorf("获取知识库文件夹%d的文章失败: %v", wikiFolderID, err)
+			return nil
+		}
+		for _, item := range descendants {
+			if item.Type == model.WikiTypeArticle {
+				wikiIDs = append(wikiIDs, item.ID)
+			}
+		}
+		if len(wikiIDs) == 0 {
+			return nil
+		}
+	}
+
+	candidates, err := s.questionDAO.ListApproved(wikiID, wikiIDs, used)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取题库题目失败: %v", err)
+		return nil
+	}
+	return model.PickQuestion(candidates, difficulty)
+}
+
+// referenceAnswer 上一轮提出的题库题目的参考答案，用于评价本轮回答，上一轮不是题库题目时返回空
+func (s *MeetingService) referenceAnswer(last *model.MeetingRound) string {
+	if last == nil || last.QuestionID == 0 {
+		return ""
+	}
+	question, err := s.questionDAO.GetByID(last.QuestionID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取题库题目%d失败: %v", last.QuestionID, err)
+		return ""
+	}
+	return question.ReferenceAnswer
+}
+
+// useQuestion 模型提出新的知识点时以题库题目为准，记录本轮使用的题目
+func useQuestion(round *model.MeetingRound, question *model.Question) {
+	if question == nil || round.FollowUpDepth != 0 {
+		return
+	}
+	round.QuestionID = question.ID
+	round.NextQuestion = question.Content
+	if len(question.Tags) > 0 {
+		round.KnowledgePoints = question.Tags
+	}
+}
@@ -0,0 +1,149 @@
+package meetingService
+
+import (
+	"ai_jianli_go/logs"
+	"ai_jianli_go/types/model"
+	"ai_jianli_go/types/req"
+	"ai_jianli_go/types/resp/common"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// 模型返回的报告不符合格式时最多尝试的次数
+const maxReportAttempts = 3
+
+// latestReport 获取整场面试（stage为nil）或某个阶段的最新报告，还没有报告时生成报告
+func (s *MeetingService) latestReport(ctx context.Context, meeting *model.Meeting, stage *model.MeetingStage) (*model.MeetingReport, int64) {
+	report, code := s.savedReport(meeting, stage)
+	if code == common.CodeReportNotGenerated {
+		return s.createReport(ctx, meeting, stage)
+	}
+	return report, code
+}
+
+// savedReport 获取已保存的最新报告，不调用模型生成。整场面试还没有报告时，旧版本保存在面试评价字段中的报告
+// 能解析则导入为第一个版本，否则返回CodeReportNotGenerated
+func (s *MeetingService) savedReport(meeting *model.Meeting, stage *model.MeetingStage) (*model.MeetingReport, int64) {
+	var stageID uint
+	legacy := meeting.Remark
+	if stage != nil {
+		stageID = stage.ID
+		// 阶段报告在引入评价报告表之后才有，没有旧版本数据
+		legacy = ""
+	}
+
+	report, err := s.dao.GetLatestReport(meeting.ID, stageID)
+	if err == nil {
+		return report, common.CodeSuccess
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logs.SugarLogger.Errorf("获取面试评价报告失败: %v", err)
+		return nil, common.CodeServerBusy
+	}
+
+	if legacy != "" {
+		if parsed, err := model.ParseInterviewReport(legacy); err == nil {
+			report = &model.MeetingReport{MeetingID: meeting.ID, StageID: stageID, Version: 1, Report: *parsed}
+			if err = s.dao.CreateReport(report); err != nil {
+				logs.SugarLogger.Errorf("保存面试评价报告失败: %v", err)
+				return nil, common.CodeServerBusy
+			}
+			return report, common.CodeSuccess
+		}
+	}
+	return nil, common.CodeReportNotGenerated
+}
+
+// createReport 根据面试轮次的评分生成整场面试（stage为nil）或某个阶段的报告，保存为新版本
+func (s *MeetingService) createReport(ctx context.Context, meeting *model.Meeting, stage *model.MeetingStage) (*model.MeetingReport, int64) {
+	rounds, err := s.dao.ListRounds(meeting.ID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试轮次失败: %v", err)
+		return nil, common.CodeGetMeetingFail
+	}
+
+	var stageID uint
+	rubric := defaultRubric
+	if stage != nil {
+		stageID = stage.ID
+		rounds = roundsOfStage(rounds, stage.ID)
+		if stage.Rubric != "" {
+			rubric = stage.Rubric
+		}
+	}
+
+	content, summary, err := generateRemark(ctx, rounds, meeting.JobDescription, rubric)
+	if err != nil {
+		logs.SugarLogger.Errorf("生成面试评价失败: %v", err)
+		return nil, common.CodeInterviewGenerateFail
+	}
+
+	version := 1
+	latest, err := s.dao.GetLatestReport(meeting.ID, stageID)
+	if err == nil {
+		version = latest.Version + 1
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logs.SugarLogger.Errorf("获取面试评价报告失败: %v", err)
+		return nil, common.CodeServerBusy
+	}
+
+	report := &model.MeetingReport{
+		MeetingID: meeting.ID,
+		StageID:   stageID,
+		Version:   version,
+		Scores:    summary,
+		Report:    *content,
+	}
+	if err = s.dao.CreateReport(report); err != nil {
+		logs.SugarLogger.Errorf("保存面试评价报告失败: %v", err)
+		return nil, common.CodeServerBusy
+	}
+	return report, common.CodeSuccess
+}
+
+// RegenerateRemark 重新生成整场面试或某个阶段的评价报告，保存为新版本，历史版本仍可查询。
+// 阶段报告重新生成后，跨阶段综合评价会在下次获取综合报告时重新生成
+func (s *MeetingService) RegenerateRemark(ctx context.Context, request *req.RegenerateRemarkReq) (*model.MeetingReport, int64) {
+	meeting, err := s.dao.GetByUser(request.MeetingID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试记录失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+	if meeting.Status != COMPLETED {
+		return nil, common.CodeMeetingNotCompleted
+	}
+	if request.StageID == 0 {
+		return s.createReport(ctx, meeting, nil)
+	}
+
+	stages, err := s.dao.ListStages(meeting.ID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试阶段失败: %v", err)
+		return nil, common.CodeGetMeetingFail
+	}
+	var stage *model.MeetingStage
+	for i := range stages {
+		if stages[i].ID == request.StageID {
+			stage = &stages[i]
+			break
+		}
+	}
+	// 未进行的阶段没有面试记录，不生成评价
+	if stage == nil || stage.InterviewRecord == "" {
+		return nil, common.CodeInvalidMeetingStage
+	}
+
+	report, code := s.createReport(ctx, meeting, stage)
+	if code != common.CodeSuccess {
+		return nil, code
+	}
+	if meeting.InterviewSummary != "" {
+		meeting.InterviewSummary = ""
+		if err = s.dao.Update(meeting); err != nil {
+			logs.SugarLogger.Errorf("更新面试记录失败: %v", err)
+		}
+	}
+	return report, common.CodeSuccess
+}
@@ -0,0 +1,87 @@
+package meetingService
+
+import (
+	"ai_jianli_go/types/model"
+	"fmt"
+	"strings"
+)
+
+// 评分依据的说明，写入评价输入
+var scoreBasisNames = map[string]string{
+	model.ScoreBasisReference: "题库参考答案",
+	model.ScoreBasisWiki:      "知识库内容",
+	model.ScoreBasisResume:    "简历内容",
+}
+
+// roundsOfStage 筛选某个阶段的面试轮次
+func roundsOfStage(rounds []model.MeetingRound, stageID uint) []model.MeetingRound {
+	res := make([]model.MeetingRound, 0, len(rounds))
+	for _, round := range rounds {
+		if round.StageID == stageID {
+			res = append(res, round)
+		}
+	}
+	return res
+}
+
+// scoreRecord 把各轮回答的评分渲染为文本，作为生成面试评价的输入。
+// 每轮回答的是上一轮提出的问题，轮次按阶段和轮次排序
+func scoreRecord(rounds []model.MeetingRound) string {
+	var sb strings.Builder
+	for i, round := range rounds {
+		fmt.Fprintf(&sb, "【第%d轮】\n", round.Round)
+		if i > 0 && rounds[i-1].StageID == round.StageID {
+			sb.WriteString("问题：" + rounds[i-1].NextQuestion + "\n")
+		}
+		sb.WriteString("回答：" + round.Answer + "\n")
+		if round.Delivery != nil {
+			sb.WriteString("语音表达：" + round.Delivery.String() + "\n")
+		}
+		if round.Score.Scored() {
+			fmt.Fprintf(&sb, "评分：正确性%d 深度%d 表达清晰度%d，总分%.1f（依据%s）",
+				round.Score.Correctness, round.Score.Depth, round.Score.Clarity, round.Score.Total(), scoreBasisNames[round.Score.Basis])
+			if round.Score.Comment != "" {
+				sb.WriteString("，" + round.Score.Comment)
+			}
+			sb.WriteString("\n")
+		} else {
+			sb.WriteString("评分：未评分\n")
+		}
+		if round.Evaluation != "" {
+			sb.WriteString("评价：" + round.Evaluation + "\n")
+		}
+		for _, v := range round.Strengths {
+			sb.WriteString("优点：" + v + "\n")
+		}
+		for _, v := range round.Weaknesses {
+			sb.WriteString("不足：" + v + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// scoreRule 要求模型按已记录的评分填写总分和维度得分，没有评分记录时由模型根据各轮评价估计。
+// 有语音回答时沟通表达由实测的语速、停顿和口头禅得出
+func scoreRule(summary model.ScoreSummary) string {
+	var delivery string
+	if summary.SpokenRounds > 0 {
+		delivery = fmt.Sprintf("%s得分由%d轮语音回答实测的语速、停顿和口头禅得出，评价表达能力时以语音表达数据为准", model.DimensionCommunication, summary.SpokenRounds)
+	}
+	if summary.ScoredRounds == 0 {
+		if delivery != "" {
+			return fmt.Sprintf("各轮回答没有评分记录，根据各轮评价估计总体得分和胜任力维度得分；%s必须为%.1f，%s",
+				model.DimensionCommunication, summary.Delivery*10, delivery)
+		}
+		return "各轮回答没有评分记录，根据各轮评价估计总体得分和胜任力维度得分"
+	}
+	// 维度得分由各项平均分(1-10)换算为百分制
+	rule := fmt.Sprintf("总体得分(overallEvaluation.score)必须为%.1f；胜任力维度只包含%s、%s、%s三项，得分必须分别为%.1f、%.1f、%.1f。"+
+		"这些分数由%d轮回答的评分汇总得出，不要修改",
+		summary.Total, model.DimensionKnowledge, model.DimensionDepth, model.DimensionCommunication,
+		summary.Correctness*10, summary.Depth*10, summary.Communication()*10, summary.ScoredRounds)
+	if delivery != "" {
+		rule += "；" + delivery
+	}
+	return rule
+}
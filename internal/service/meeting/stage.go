@@ -0,0 +1,307 @@
+package meetingService
+
+import (
+	"ai_jianli_go/component"
+	"ai_jianli_go/logs"
+	"ai_jianli_go/pkg/rag"
+	"ai_jianli_go/types/model"
+	"ai_jianli_go/types/req"
+	"ai_jianli_go/types/resp/common"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	defaultPersona = "你是一个专业面试官"
+	defaultRubric  = "结合岗位描述，从专业知识、逻辑思维、沟通表达等维度综合评估"
+)
+
+// buildStages 根据请求构建面试阶段，阶段未设置计划时沿用面试计划
+func buildStages(stageReqs []req.MeetingStageReq, plan model.InterviewPlan) ([]model.MeetingStage, int64) {
+	stages := make([]model.MeetingStage, 0, len(stageReqs))
+	for i, r := range stageReqs {
+		stagePlan := plan
+		if r.Plan != nil {
+			stagePlan = *r.Plan
+			if err := stagePlan.Validate(); err != nil {
+				logs.SugarLogger.Errorf("面试阶段%d计划不合法: %v", i+1, err)
+				return nil, common.CodeInvalidInterviewPlan
+			}
+		}
+		stages = append(stages, model.MeetingStage{
+			Seq:          i,
+			Name:         r.Name,
+			Type:         r.Type,
+			Persona:      r.Persona,
+			WikiID:       r.WikiID,
+			WikiFolderID: r.WikiFolderID,
+			Rubric:       r.Rubric,
+			Plan:         stagePlan,
+			Status:       PLANED,
+		})
+	}
+	return stages, common.CodeSuccess
+}
+
+// conversationID 对话记录的Redis键，多阶段面试每个阶段独立对话
+func conversationID(meetingID uint, stage *model.MeetingStage) string {
+	if stage == nil {
+		return fmt.Sprintf("%d", meetingID)
+	}
+	return fmt.Sprintf("%d_stage_%d", meetingID, stage.ID)
+}
+
+func stageIDOf(stage *model.MeetingStage) uint {
+	if stage == nil {
+		return 0
+	}
+	return stage.ID
+}
+
+// stageConversation 获取阶段对话记录
+func stageConversation(meetingID uint, stage *model.MeetingStage) *rag.Conversation {
+	memory := rag.NewRedisMemory(rag.RedisMemoryConfig{
+		MaxWindowSize: 20,
+		RedisOptions:  component.GetRedisDB(),
+	})
+	return memory.GetConversation(conversationID(meetingID, stage), false)
+}
+
+// currentStage 获取面试的全部阶段和当前阶段，单阶段面试返回nil
+func (s *MeetingService) currentStage(meeting *model.Meeting) ([]model.MeetingStage, *model.MeetingStage, int64) {
+	stages, err := s.dao.ListStages(meeting.ID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试阶段失败: %v", err)
+		return nil, nil, common.CodeServerBusy
+	}
+	if len(stages) == 0 {
+		return nil, nil, common.CodeSuccess
+	}
+	if meeting.StageIndex < 0 || meeting.StageIndex >= len(stages) {
+		logs.SugarLogger.Errorf("面试%d当前阶段序号%d越界", meeting.ID, meeting.StageIndex)
+		return nil, nil, common.CodeInvalidMeetingStage
+	}
+	return stages, &stages[meeting.StageIndex], common.CodeSuccess
+}
+
+// completeStage 保存阶段对话记录并标记为已完成
+func (s *MeetingService) completeStage(stage *model.MeetingStage, con *rag.Conversation) error {
+	stage.Status = COMPLETED
+	stage.InterviewRecord = con.String()
+	stage.InterviewNumber = con.GetRoundCount()
+	return s.dao.UpdateStage(stage)
+}
+
+// finishStage 结束当前阶段，最后一个阶段结束时整场面试随之结束
+func (s *MeetingService) finishStage(meeting *model.Meeting, stages []model.MeetingStage, stage *model.MeetingStage, con *rag.Conversation) error {
+	if err := s.completeStage(stage, con); err != nil {
+		return err
+	}
+	if meeting.StageIndex < len(stages)-1 {
+		return nil
+	}
+	if err := s.completeStages(meeting, stages); err != nil {
+		return err
+	}
+	return s.dao.Update(meeting)
+}
+
+// completeStages 结束当前阶段并把各阶段面试记录汇总到面试，调用方负责保存面试
+func (s *MeetingService) completeStages(meeting *model.Meeting, stages []model.MeetingStage) error {
+	if meeting.StageIndex >= 0 && meeting.StageIndex < len(stages) {
+		stage := &stages[meeting.StageIndex]
+		if stage.Status != COMPLETED {
+			con := stageConversation(meeting.ID, stage)
+			if err := s.completeStage(stage, con); err != nil {
+				return err
+			}
+		}
+	}
+
+	var record strings.Builder
+	number := 0
+	for _, stage := range stages {
+		if stage.InterviewRecord == "" {
+			continue
+		}
+		fmt.Fprintf(&record, "【阶段%d %s】\n%s\n", stage.Seq+1, stage.Name, stage.InterviewRecord)
+		number += stage.InterviewNumber
+	}
+	meeting.Status = COMPLETED
+	meeting.InterviewRecord = record.String()
+	meeting.InterviewNumber = number
+	return nil
+}
+
+// 获取面试阶段列表
+func (s *MeetingService) GetStages(request *req.GetMeetingReq) ([]model.MeetingStage, int64) {
+	meeting, err := s.dao.GetByUser(request.ID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试记录失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+	stages, err := s.dao.ListStages(meeting.ID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试阶段失败: %v", err)
+		return nil, common.CodeGetMeetingFail
+	}
+	return stages, common.CodeSuccess
+}
+
+// NextStage 结束当前阶段并进入下一阶段，返回新的当前阶段，最后一个阶段结束后面试完成并返回nil
+func (s *MeetingService) NextStage(request *req.NextStageReq) (*model.MeetingStage, int64) {
+	meeting, err := s.dao.GetByUser(request.MeetingID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试记录失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+	if meeting.Status == COMPLETED || meeting.Status == CANCELED {
+		return nil, common.CodeMeetingCompleted
+	}
+
+	stages, stage, code := s.currentStage(meeting)
+	if code != common.CodeSuccess {
+		return nil, code
+	}
+	if stage == nil {
+		return nil, common.CodeMeetingHasNoStage
+	}
+
+	// 最后一个阶段，结束整场面试
+	if meeting.StageIndex == len(stages)-1 {
+		if err = s.completeStages(meeting, stages); err != nil {
+			logs.SugarLogger.Errorf("结束面试阶段失败: %v", err)
+			return nil, common.CodeServerBusy
+		}
+		if err = s.dao.Update(meeting); err != nil {
+			logs.SugarLogger.Errorf("更新面试记录失败: %v", err)
+			return nil, common.CodeUpdateMeetingFail
+		}
+		return nil, common.CodeSuccess
+	}
+
+	if stage.Status != COMPLETED {
+		con := stageConversation(meeting.ID, stage)
+		if err = s.completeStage(stage, con); err != nil {
+			logs.SugarLogger.Errorf("结束面试阶段失败: %v", err)
+			return nil, common.CodeServerBusy
+		}
+	}
+
+	meeting.StageIndex++
+	meeting.Status = INTERVIEWING
+	next := &stages[meeting.StageIndex]
+	next.Status = INTERVIEWING
+	if err = s.dao.UpdateStage(next); err != nil {
+		logs.SugarLogger.Errorf("更新面试阶段失败: %v", err)
+		return nil, common.CodeServerBusy
+	}
+	if err = s.dao.Update(meeting); err != nil {
+		logs.SugarLogger.Errorf("更新面试记录失败: %v", err)
+		return nil, common.CodeUpdateMeetingFail
+	}
+	return next, common.CodeSuccess
+}
+
+// GetStageReport 获取多阶段面试综合报告，缺少的阶段评价和综合评价会先生成并保存
+func (s *MeetingService) GetStageReport(ctx context.Context, request *req.GetRemarkReq) (*model.CombinedReport, int64) {
+	meeting, err := s.dao.GetByUser(request.MeetingID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试记录失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+	if meeting.Status != COMPLETED {
+		return nil, common.CodeMeetingNotCompleted
+	}
+
+	stages, err := s.dao.ListStages(meeting.ID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取面试阶段失败: %v", err)
+		return nil, common.CodeGetMeetingFail
+	}
+	if len(stages) == 0 {
+		return nil, common.CodeMeetingHasNoStage
+	}
+
+	report := &model.CombinedReport{
+		MeetingID: meeting.ID,
+		Candidate: meeting.Candidate,
+		Position:  meeting.Position,
+		Stages:    make([]model.StageReport, 0, len(stages)),
+	}
+	for i := range stages {
+		stage := &stages[i]
+		item := model.StageReport{
+			StageID: stage.ID,
+			Seq:     stage.Seq,
+			Name:    stage.Name,
+			Type:    stage.Type,
+			Status:  stage.Status,
+		}
+		// 未进行的阶段没有面试记录，不生成评价
+		if stage.InterviewRecord != "" {
+			stageReport, code := s.latestReport(ctx, meeting, stage)
+			if code != common.CodeSuccess {
+				return nil, code
+			}
+			item.Report = &stageReport.Report
+			item.Version = stageReport.Version
+		}
+		report.Stages = append(report.Stages, item)
+	}
+
+	if meeting.InterviewSummary == "" {
+		meeting.InterviewSummary, err = generateSummary(ctx, report.Stages, meeting.JobDescription)
+		if err != nil {
+			logs.SugarLogger.Errorf("生成综合评价失败: %v", err)
+			return nil, common.CodeInterviewGenerateFail
+		}
+		if err = s.dao.Update(meeting); err != nil {
+			logs.SugarLogger.Errorf("更新面试记录失败: %v", err)
+			return nil, common.CodeServerBusy
+		}
+	}
+	report.Summary = meeting.InterviewSummary
+
+	return report, common.CodeSuccess
+}
+
+// generateSummary 根据各阶段评价生成跨阶段综合评价
+func generateSummary(ctx context.Context, stages []model.StageReport, jobDescription string) (string, error) {
+	var input strings.Builder
+	for _, stage := range stages {
+		if stage.Report == nil {
+			continue
+		}
+		fmt.Fprintf(&input, "【阶段%d %s（%s）】\n%s\n", stage.Seq+1, stage.Name, stage.Type, stage.Report.Brief())
+	}
+
+	chatModel := component.GetAIComponent().GetFeatureModel(component.FeatureRemark)
+	template := prompt.FromMessages(schema.FString,
+		schema.SystemMessage(
+			"你是一个专业的面试官，需要根据候选人在HR面、技术面、行为面等各阶段的评价以及岗位描述，给出跨阶段的综合评价。\n"+
+				"要求：\n"+
+				"1. 总结候选人在各阶段的表现和一致性，指出突出优势和主要风险。\n"+
+				"2. 给出明确的录用建议（建议录用/待定/不建议录用）及理由。\n"+
+				"3. 直接返回文字评价，不要返回JSON或Markdown代码块。",
+		),
+		schema.UserMessage("各阶段评价：\n{input}\n岗位描述：{job_description}"),
+	)
+	messages, err := template.Format(ctx, map[string]any{
+		"input":           input.String(),
+		"job_description": jobDescription,
+	})
+	if err != nil {
+		return "", fmt.Errorf("format summary prompt failed: %w", err)
+	}
+
+	resp, err := chatModel.Generate(ctx, messages)
+	if err != nil {
+		return "", fmt.Errorf("generate summary failed: %w", err)
+	}
+	return resp.Content, nil
+}
@@ -0,0 +1,246 @@
+package questionService
+
+import (
+	"ai_jianli_go/component"
+	"ai_jianli_go/internal/dao"
+	"ai_jianli_go/logs"
+	"ai_jianli_go/pkg/rag"
+	"ai_jianli_go/types/model"
+	"ai_jianli_go/types/req"
+	"ai_jianli_go/types/resp"
+	"ai_jianli_go/types/resp/common"
+	"context"
+	"fmt"
+	"sync"
+
+	chatmodel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+)
+
+type QuestionService struct {
+	questionDAO *dao.QuestionDAO
+	wikiDAO     *dao.WikiDAO
+}
+
+func NewQuestionService(questionDAO *dao.QuestionDAO, wikiDAO *dao.WikiDAO) *QuestionService {
+	return &QuestionService{questionDAO: questionDAO, wikiDAO: wikiDAO}
+}
+
+const (
+	// 出题在请求内同步完成，限制分块数并并发调用模型，最多两轮模型调用
+	defaultGenerateChunks = 5
+	maxGenerateChunks     = 10
+	generateConcurrency   = 5
+	defaultPerChunk       = 2
+	maxPerChunk           = 5
+	// 过短的分块（目录、标题等）不出题
+	minChunkRunes = 50
+
+	defaultQuestionPageSize = 20
+	maxQuestionPageSize     = 100
+)
+
+// 出题的输出格式
+const questionOutput = `[{"question":"题目","answer":"参考答案","difficulty":3,"tags":["知识点"]}]`
+
+// Generate 根据文章的分块生成题目，生成的题目为待审核状态，审核通过后才会在AI面试中使用
+func (s *QuestionService) Generate(ctx context.Context, request *req.GenerateQuestionReq) (*resp.GenerateQuestionResp, int64) {
+	wiki, err := s.wikiDAO.GetWiki(request.WikiID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取文章失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+	if wiki.Type != model.WikiTypeArticle {
+		return nil, common.CodeInvalidParams
+	}
+
+	docs, err := rag.LoadDocument(ctx, wiki.Url)
+	if err != nil {
+		logs.SugarLogger.Errorf("加载文章%d失败: %v", wiki.ID, err)
+		return nil, common.CodeGenerateQuestionFail
+	}
+
+	maxChunks := request.MaxChunks
+	if maxChunks <= 0 {
+		maxChunks = defaultGenerateChunks
+	}
+	perChunk := request.PerChunk
+	if perChunk <= 0 {
+		perChunk = defaultPerChunk
+	}
+	chunks := selectChunks(docs, min(maxChunks, maxGenerateChunks))
+	perChunk = min(perChunk, maxPerChunk)
+
+	chatModel := component.GetAIComponent().GetFeatureModel(component.FeatureQuestion)
+	generated := make([][]*model.Question, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, generateConcurrency)
+	var wg sync.WaitGroup
+	for i, doc := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			generated[i], errs[i] = generateQuestions(ctx, chatModel, wiki.Title, doc.Content, perChunk)
+		}()
+	}
+	wg.Wait()
+
+	result := &resp.GenerateQuestionResp{Chunks: len(chunks)}
+	for i, doc := range chunks {
+		questions, err := generated[i], errs[i]
+		if err != nil {
+			logs.SugarLogger.Errorf("文章%d分块生成题目失败: %v", wiki.ID, err)
+			result.Failed++
+			continue
+		}
+		chunkID, _ := doc.MetaData[model.MetaChunkID].(int)
+		chunkKey := wiki.ChunkKey(doc)
+		for _, q := range questions {
+			q.UserID = wiki.UserId
+			q.RootID = wiki.RootId
+			q.WikiID = wiki.ID
+			q.ChunkID = chunkID
+			q.ChunkKey = chunkKey
+		}
+		result.Questions = append(result.Questions, questions...)
+	}
+	if len(result.Questions) == 0 {
+		return nil, common.CodeGenerateQuestionFail
+	}
+
+	if err = s.questionDAO.CreateBatch(result.Questions); err != nil {
+		logs.SugarLogger.Errorf("保存题目失败: %v", err)
+		return nil, common.CodeGenerateQuestionFail
+	}
+	logs.SugarLogger.Infof("文章%d生成%d道题目，%d个分块失败", wiki.ID, len(result.Questions), result.Failed)
+	return result, common.CodeSuccess
+}
+
+// selectChunks 选取用于出题的分块，跳过过短的分块
+func selectChunks(docs []*schema.Document, limit int) []*schema.Document {
+	chunks := make([]*schema.Document, 0, min(len(docs), limit))
+	for _, doc := range docs {
+		if len(chunks) >= limit {
+			break
+		}
+		if len([]rune(doc.Content)) < minChunkRunes {
+			continue
+		}
+		chunks = append(chunks, doc)
+	}
+	return chunks
+}
+
+// generateQuestions 根据单个分块生成题目，最多返回count道
+func generateQuestions(ctx context.Context, chatModel chatmodel.ChatModel, title, content string, count int) ([]*model.Question, error) {
+	template := prompt.FromMessages(schema.FString,
+		schema.SystemMessage(
+			"你是资深技术面试官，需要根据知识库文章片段出面试题，要求：\n"+
+				"1. 出{count}道题目，题目的答案必须能从片段内容中得出，不要出与片段无关的题目\n"+
+				"2. 每道题给出参考答案，参考答案要点完整、表述简洁\n"+
+				"3. difficulty为题目难度（1-5级，1最简单）：1-2级考察概念，3级考察原理，4-5级考察综合运用和场景设计\n"+
+				"4. tags为题目考察的知识点关键词，1-3个\n"+
+				"输出格式要求：只返回一个JSON数组，不要有任何额外的解释或Markdown代码块标记（如```json），格式：{output}",
+		),
+		schema.UserMessage("文章标题：{title}\n文章片段：\n{content}"),
+	)
+	messages, err := template.Format(ctx, map[string]any{
+		"count":   count,
+		"output":  questionOutput,
+		"title":   title,
+		"content": content,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("format prompt failed: %w", err)
+	}
+
+	res, err := chatModel.Generate(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("generate questions failed: %w", err)
+	}
+	questions, err := model.ParseGeneratedQuestions(res.Content)
+	if err != nil {
+		return nil, err
+	}
+	return questions[:min(len(questions), count)], nil
+}
+
+// List 分页获取题目
+func (s *QuestionService) List(request *req.ListQuestionReq) (*resp.QuestionListResp, int64) {
+	if request.Status != "" && !model.ValidQuestionStatus(request.Status) {
+		return nil, common.CodeInvalidParams
+	}
+	if request.Page <= 0 {
+		request.Page = 1
+	}
+	if request.PageSize <= 0 {
+		request.PageSize = defaultQuestionPageSize
+	}
+	request.PageSize = min(request.PageSize, maxQuestionPageSize)
+
+	questions, total, err := s.questionDAO.List(request)
+	if err != nil {
+		logs.SugarLogger.Errorf("查询题库失败: %v", err)
+		return nil, common.CodeQueryQuestionFail
+	}
+	return &resp.QuestionListResp{Total: total, List: questions}, common.CodeSuccess
+}
+
+// Update 修改题目内容，审核状态不变。UserID为0时为管理员修改任意用户的题目
+func (s *QuestionService) Update(request *req.UpdateQuestionReq) (*model.Question, int64) {
+	question, err := s.questionDAO.Get(request.ID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取题目失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+	if request.Content != "" {
+		question.Content = request.Content
+	}
+	if request.ReferenceAnswer != "" {
+		question.ReferenceAnswer = request.ReferenceAnswer
+	}
+	if request.Difficulty != 0 {
+		question.Difficulty = request.Difficulty
+	}
+	if request.Tags != nil {
+		question.Tags = request.Tags
+	}
+	if err = question.Validate(); err != nil {
+		logs.SugarLogger.Errorf("题目不合法: %v", err)
+		return nil, common.CodeInvalidParams
+	}
+	if err = s.questionDAO.Update(question); err != nil {
+		logs.SugarLogger.Errorf("修改题目失败: %v", err)
+		return nil, common.CodeUpdateQuestionFail
+	}
+	return question, common.CodeSuccess
+}
+
+// Review 批量审核题目，返回修改的题目数。UserID为0时为管理员审核任意用户的题目
+func (s *QuestionService) Review(request *req.ReviewQuestionReq) (int64, int64) {
+	if len(request.IDs) == 0 || !model.ValidQuestionStatus(request.Status) {
+		return 0, common.CodeInvalidParams
+	}
+	n, err := s.questionDAO.UpdateStatus(request.UserID, request.IDs, request.Status)
+	if err != nil {
+		logs.SugarLogger.Errorf("审核题目失败: %v", err)
+		return 0, common.CodeUpdateQuestionFail
+	}
+	return n, common.CodeSuccess
+}
+
+// Delete 删除题目，已使用过该题目的面试轮次记录不受影响。UserID为0时为管理员删除任意用户的题目
+func (s *QuestionService) Delete(request *req.DeleteQuestionReq) int64 {
+	n, err := s.questionDAO.Delete(request.ID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("删除题目失败: %v", err)
+		return common.CodeDeleteQuestionFail
+	}
+	if n == 0 {
+		return common.CodeRecordNotFound
+	}
+	return common.CodeSuccess
+}
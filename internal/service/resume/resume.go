@@ -79,7 +79,7 @@ template := prompt.FromMessages(schema.FString,
 	// 从配置中获取API密钥，而不是硬编码
 	// 这里假设已经有一个配置服务可以获取API密钥
 	// apiKey := "" // 实际应用中应该从配置或环境变量中获取
-	chatModel := component.GetAIComponent().GetChatModel("gpt-4o")
+	chatModel := component.GetAIComponent().GetFeatureModel(component.FeatureResume)
 
 	res, err := chatModel.Generate(ctx, messages)
 
@@ -0,0 +1,72 @@
+package wikiService
+
+import (
+	"ai_jianli_go/types/model"
+	"ai_jianli_go/types/resp"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 引用摘要的最大字符数
+const citationSnippetRunes = 120
+
+// 回答中的引用标记，如[1]、[2][3]
+var citationMarkerRe = regexp.MustCompile(`\[(\d+)\]`)
+
+// buildCitations 按检索结果顺序生成引用，编号与上下文中的[n]一致。
+// titles为文章ID到文章标题的映射，answer中出现的编号标记为已引用
+func buildCitations(docs []*schema.Document, titles map[uint]string, answer string) []resp.WikiCitation {
+	cited := citedIndexes(answer)
+	citations := make([]resp.WikiCitation, len(docs))
+	for i, doc := range docs {
+		wikiID := uint(metaInt(doc, model.MetaWikiID))
+		snippet := []rune(strings.TrimSpace(doc.Content))
+		if len(snippet) > citationSnippetRunes {
+			snippet = append(snippet[:citationSnippetRunes], []rune("...")...)
+		}
+		score, _ := doc.MetaData[model.MetaScore].(float64)
+		section, _ := doc.MetaData[model.MetaTitle].(string)
+		source, _ := doc.MetaData[model.MetaSource].(string)
+		citations[i] = resp.WikiCitation{
+			Index:   i + 1,
+			WikiID:  wikiID,
+			Title:   titles[wikiID],
+			Section: section,
+			Source:  source,
+			Page:    metaInt(doc, model.MetaPage),
+			ChunkID: metaInt(doc, model.MetaChunkID),
+			Score:   score,
+			Snippet: string(snippet),
+			Cited:   cited[i+1],
+		}
+	}
+	return citations
+}
+
+// citedIndexes 解析回答中的引用编号
+func citedIndexes(answer string) map[int]bool {
+	cited := make(map[int]bool)
+	for _, m := range citationMarkerRe.FindAllStringSubmatch(answer, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			cited[n] = true
+		}
+	}
+	return cited
+}
+
+// metaInt 读取数值元数据，Redis返回的字段为字符串
+func metaInt(doc *schema.Document, key string) int {
+	switch v := doc.MetaData[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package wikiService
+
+import (
+	"ai_jianli_go/types/model"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestBuildCitations(t *testing.T) {
+	docs := []*schema.Document{
+		{
+			ID:      "doc1",
+			Content: "goroutine是轻量级线程",
+			MetaData: map[string]any{
+				model.MetaWikiID:  "3",
+				model.MetaTitle:   "Go语言 > 并发",
+				model.MetaSource:  "upload/go.pdf",
+				model.MetaPage:    "2",
+				model.MetaChunkID: "1",
+				model.MetaScore:   0.8,
+			},
+		},
+		{
+			ID:       "doc2",
+			Content:  "channel用于通信",
+			MetaData: map[string]any{model.MetaWikiID: "4"},
+		},
+	}
+	titles := map[uint]string{3: "Go语言入门"}
+
+	citations := buildCitations(docs, titles, "goroutine由运行时调度[1]。")
+	if len(citations) != 2 {
+		t.Fatalf("got %d citations, want 2", len(citations))
+	}
+	c := citations[0]
+	if c.Index != 1 || c.WikiID != 3 || c.Title != "Go语言入门" || c.Section != "Go语言 > 并发" ||
+		c.Source != "upload/go.pdf" || c.Page != 2 || c.ChunkID != 1 || c.Score != 0.8 || !c.Cited {
+		t.Errorf("unexpected citation: %+v", c)
+	}
+	if c := citations[1]; c.Index != 2 || c.WikiID != 4 || c.Title != "" || c.Cited {
+		t.Errorf("unexpected citation: %+v", c)
+	}
+}
+
+func TestCitedIndexes(t *testing.T) {
+	cited := citedIndexes("见[1][3]，另见[x]与[12]")
+	for _, n := range []int{1, 3, 12} {
+		if !cited[n] {
+			t.Errorf("index %d should be cited", n)
+		}
+	}
+	if len(cited) != 3 {
+		t.Errorf("got %v", cited)
+	}
+}
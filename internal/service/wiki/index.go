@@ -0,0 +1,133 @@
+package wikiService
+
+import (
+	"ai_jianli_go/component"
+	"ai_jianli_go/config"
+	"ai_jianli_go/logs"
+	"ai_jianli_go/types/model"
+	"ai_jianli_go/types/req"
+	"ai_jianli_go/types/resp"
+	"ai_jianli_go/types/resp/common"
+	"context"
+	"fmt"
+	"time"
+)
+
+// knowledgeBase 获取用户的知识库，知识库的RootId为自身ID
+func (s *WikiService) knowledgeBase(id, userID uint) (*model.Wiki, error) {
+	wiki, err := s.wikiDAO.GetWiki(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if wiki.Type != model.WikiTypeKnowledge {
+		return nil, fmt.Errorf("wiki %d is not a knowledge base", id)
+	}
+	wiki.RootId = wiki.ID
+	return wiki, nil
+}
+
+// GetIndexConfig 获取知识库的向量索引配置
+func (s *WikiService) GetIndexConfig(request *req.GetWikiIndexConfigRequest) (*model.WikiIndexConfig, int64) {
+	wiki, err := s.knowledgeBase(request.RootId, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取知识库失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+	conf, err := wiki.LoadIndexConfig(context.Background(), component.GetRedisDB())
+	if err != nil {
+		logs.SugarLogger.Errorf("获取知识库索引配置失败: %v", err)
+		return nil, common.CodeGetIndexInfoFailed
+	}
+	return &conf, common.CodeSuccess
+}
+
+// UpdateIndexConfig 修改知识库的向量索引配置，返回按新配置原地重建索引的后台任务。
+// 重建任务与该知识库的文档导入任务互斥，避免导入按旧配置写入分块
+func (s *WikiService) UpdateIndexConfig(request *req.UpdateWikiIndexConfigRequest) (*model.WikiJob, int64) {
+	conf := request.WikiIndexConfig
+	if err := conf.Normalize(); err != nil {
+		logs.SugarLogger.Errorf("知识库索引配置错误: %v", err)
+		return nil, common.CodeInvalidParams
+	}
+	wiki, err := s.knowledgeBase(request.RootId, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取知识库失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+
+	job := model.NewWikiJob(model.WikiJobRebuild, config.GetIngestConfig().MaxAttempts)
+	job.WikiID = wiki.ID
+	job.RootID = wiki.ID
+	job.UserID = wiki.UserId
+	job.IndexConfig = &conf
+	if err = s.jobDAO.Create(job); err != nil {
+		logs.SugarLogger.Errorf("创建索引重建任务失败: %v", err)
+		return nil, common.CodeRebuildIndexFailed
+	}
+	notifyIngest()
+	return job, common.CodeSuccess
+}
+
+// runRebuild 按任务中的配置重建知识库索引，领取时已保证该知识库没有执行中的导入任务
+func (s *WikiService) runRebuild(ctx context.Context, job *model.WikiJob) {
+	wiki, err := s.knowledgeBase(job.WikiID, job.UserID)
+	if err != nil {
+		s.failJob(job, nil, fmt.Errorf("获取知识库失败: %w", err))
+		return
+	}
+	if job.IndexConfig == nil {
+		s.failJob(job, nil, fmt.Errorf("重建任务缺少索引配置"))
+		return
+	}
+
+	job.Status = model.WikiJobEmbedding
+	if err = s.jobDAO.Update(job); err != nil {
+		logs.SugarLogger.Errorf("更新导入任务失败: %v", err)
+	}
+	if err = wiki.RebuildIndex(ctx, component.GetRedisDB(), *job.IndexConfig); err != nil {
+		s.retryJob(job, nil, err)
+		return
+	}
+
+	now := time.Now()
+	job.Status = model.WikiJobDone
+	job.Error = ""
+	job.FinishedAt = &now
+	if err = s.jobDAO.Update(job); err != nil {
+		logs.SugarLogger.Errorf("更新导入任务失败: %v", err)
+	}
+	logs.SugarLogger.Infof("知识库%d索引已按新配置重建: %+v", wiki.ID, *job.IndexConfig)
+}
+
+// GetIndexStats 获取任意知识库的索引统计，供管理员排查索引问题
+func (s *WikiService) GetIndexStats(request *req.GetWikiIndexStatsRequest) (*resp.WikiIndexStats, int64) {
+	wiki, err := s.wikiDAO.GetByID(request.RootId)
+	if err != nil || wiki.Type != model.WikiTypeKnowledge {
+		logs.SugarLogger.Errorf("获取知识库%d失败: %v", request.RootId, err)
+		return nil, common.CodeRecordNotFound
+	}
+	wiki.RootId = wiki.ID
+
+	info, err := wiki.GetIndexInfo(context.Background(), component.GetRedisDB())
+	if err != nil {
+		logs.SugarLogger.Errorf("获取知识库索引信息失败: %v", err)
+		return nil, common.CodeGetIndexInfoFailed
+	}
+	return &resp.WikiIndexStats{
+		RootID:               wiki.ID,
+		UserID:               wiki.UserId,
+		Title:                wiki.Title,
+		IndexName:            info.IndexName,
+		Version:              wiki.IndexVersion(),
+		Config:               wiki.IndexConfig(),
+		NumDocs:              info.NumDocs,
+		PercentIndexed:       info.PercentIndexed,
+		Indexing:             info.Indexing != 0,
+		IndexingFailures:     max(info.HashIndexingFailures, info.IndexErrors.IndexingFailures),
+		LastIndexingError:    info.IndexErrors.LastIndexingError,
+		LastIndexingErrorKey: info.IndexErrors.LastIndexingErrorKey,
+		VectorIndexSizeMB:    info.VectorIndexSzMB,
+		TotalIndexMemoryMB:   info.TotalIndexMemorySzMB,
+		DocTableSizeMB:       info.DocTableSizeMB,
+	}, common.CodeSuccess
+}
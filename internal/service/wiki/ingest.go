@@ -0,0 +1,286 @@
+package wikiService
+
+import (
+	"ai_jianli_go/component"
+	"ai_jianli_go/config"
+	"ai_jianli_go/internal/dao"
+	"ai_jianli_go/logs"
+	"ai_jianli_go/pkg/rag"
+	"ai_jianli_go/types/model"
+	"ai_jianli_go/types/req"
+	"ai_jianli_go/types/resp/common"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ingestPollInterval = 2 * time.Second // 没有新任务通知时轮询数据库的间隔
+	jobWatchInterval   = time.Second     // 订阅任务进度时的刷新间隔
+	maxIngestBackoff   = 5 * time.Minute
+	jobLease           = time.Minute // 任务租约时长，执行期间每jobLease/3续约一次
+)
+
+// 有新任务时唤醒空闲的导入协程
+var ingestNotify = make(chan struct{}, 1)
+
+func notifyIngest() {
+	select {
+	case ingestNotify <- struct{}{}:
+	default:
+	}
+}
+
+// ingestBackoff 第attempt次失败后的重试等待时间，按指数增长
+func ingestBackoff(attempt int, base time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < attempt && backoff < maxIngestBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxIngestBackoff)
+}
+
+// StartIngestWorkers 启动文档导入任务的后台协程，应在应用启动时调用一次
+func (s *WikiService) StartIngestWorkers() {
+	instance, _ := os.Hostname()
+	instance = fmt.Sprintf("%s-%d-%d", instance, os.Getpid(), time.Now().UnixNano())
+
+	s.requeueExpiredJobs()
+	go s.requeueLoop()
+	for i := 0; i < config.GetIngestConfig().Workers; i++ {
+		go s.ingestLoop(fmt.Sprintf("%s-%d", instance, i))
+	}
+}
+
+// requeueLoop 定期把租约过期的任务重新排队，接管已退出实例未完成的任务
+func (s *WikiService) requeueLoop() {
+	ticker := time.NewTicker(jobLease)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.requeueExpiredJobs()
+	}
+}
+
+func (s *WikiService) requeueExpiredJobs() {
+	if n, err := s.jobDAO.RequeueExpired(time.Now()); err != nil {
+		logs.SugarLogger.Errorf("恢复导入任务失败: %v", err)
+	} else if n > 0 {
+		logs.SugarLogger.Infof("恢复%d个租约过期的导入任务", n)
+		notifyIngest()
+	}
+}
+
+// ingestLoop 导入协程，owner为协程标识，记录在领取的任务上
+func (s *WikiService) ingestLoop(owner string) {
+	ticker := time.NewTicker(ingestPollInterval)
+	defer ticker.Stop()
+	for {
+		for s.runNextJob(owner) {
+		}
+		select {
+		case <-ingestNotify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNextJob 领取并执行一个任务，没有可执行的任务时返回false
+func (s *WikiService) runNextJob(owner string) bool {
+	job, err := s.jobDAO.Claim(owner, time.Now(), jobLease)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false
+	}
+	if err != nil {
+		logs.SugarLogger.Errorf("领取导入任务失败: %v", err)
+		return false
+	}
+	// 被其他协程抢先领取，继续尝试下一个
+	if job == nil {
+		return true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.keepLease(ctx, cancel, job)
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.failJob(job, nil, fmt.Errorf("panic: %v", r))
+		}
+	}()
+	s.runJob(ctx, job)
+	return true
+}
+
+// keepLease 任务执行期间定期续约，租约已被其他协程接管时取消任务
+func (s *WikiService) keepLease(ctx context.Context, cancel context.CancelFunc, job *model.WikiJob) {
+	ticker := time.NewTicker(jobLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := s.jobDAO.RenewLease(job.ID, job.Owner, time.Now().Add(jobLease))
+		if errors.Is(err, dao.ErrJobLeaseLost) {
+			logs.SugarLogger.Errorf("导入任务%d的租约已被接管，停止执行", job.ID)
+			cancel()
+			return
+		}
+		if err != nil {
+			logs.SugarLogger.Errorf("导入任务%d续约失败: %v", job.ID, err)
+		}
+	}
+}
+
+// runJob 解析文档并增量写入索引
+func (s *WikiService) runJob(ctx context.Context, job *model.WikiJob) {
+	if job.Kind == model.WikiJobRebuild {
+		s.runRebuild(ctx, job)
+		return
+	}
+	ctx = rag.WithEmbeddingUser(ctx, job.UserID)
+	wiki, err := s.wikiDAO.GetWiki(job.WikiID, job.UserID)
+	if err != nil {
+		s.failJob(job, nil, fmt.Errorf("获取文章失败: %w", err))
+		return
+	}
+
+	// 解析失败不会因重试而成功，直接标记失败
+	docs, err := s.loadDocuments(wiki.Url)
+	if err != nil {
+		s.failJob(job, wiki, err)
+		return
+	}
+
+	job.Status = model.WikiJobEmbedding
+	job.TotalChunks = len(docs)
+	if err = s.jobDAO.Update(job); err != nil {
+		logs.SugarLogger.Errorf("更新导入任务失败: %v", err)
+	}
+
+	if err = wiki.Init(ctx, component.GetRedisDB(), rag.GetEmbedding()); err != nil {
+		s.retryJob(job, wiki, err)
+		return
+	}
+	wiki.SetFolderIDs(s.folderIDs(wiki))
+
+	result, err := wiki.Sync(ctx, docs, func(progress model.SyncResult) {
+		if err := s.jobDAO.UpdateProgress(job.ID, job.Owner, progress); err != nil {
+			logs.SugarLogger.Errorf("更新导入任务进度失败: %v", err)
+		}
+	})
+	if err != nil {
+		s.retryJob(job, wiki, err)
+		return
+	}
+
+	now := time.Now()
+	job.Status = model.WikiJobDone
+	job.TotalChunks = result.Total
+	job.EmbeddedChunks = result.Added + result.Skipped
+	job.SkippedChunks = result.Skipped
+	job.RemovedChunks = result.Removed
+	job.Error = ""
+	job.FinishedAt = &now
+	if err = s.jobDAO.Update(job); err != nil {
+		logs.SugarLogger.Errorf("更新导入任务失败: %v", err)
+	}
+	logs.SugarLogger.Infof("文章%d导入完成: 新增%d, 未变化%d, 删除%d", wiki.ID, result.Added, result.Skipped, result.Removed)
+}
+
+// retryJob 向量计算或写入失败时按指数退避重新排队，超过最大尝试次数后标记失败
+func (s *WikiService) retryJob(job *model.WikiJob, wiki *model.Wiki, cause error) {
+	if job.Attempts >= job.MaxAttempts {
+		s.failJob(job, wiki, cause)
+		return
+	}
+	backoff := ingestBackoff(job.Attempts, time.Duration(config.GetIngestConfig().Backoff)*time.Second)
+	logs.SugarLogger.Errorf("导入任务%d第%d次执行失败，%v后重试: %v", job.ID, job.Attempts, backoff, cause)
+
+	job.Status = model.WikiJobQueued
+	job.Error = cause.Error()
+	job.NextRunAt = time.Now().Add(backoff)
+	if err := s.jobDAO.Update(job); err != nil {
+		logs.SugarLogger.Errorf("更新导入任务失败: %v", err)
+	}
+}
+
+// failJob 标记任务失败。新建文章的任务失败时删除已写入的分块和文章，避免留下不完整的文章。
+// 先以任务持有者的身份标记失败再清理，租约已被接管或标记失败时不清理，避免删除新持有者正在写入的内容
+func (s *WikiService) failJob(job *model.WikiJob, wiki *model.Wiki, cause error) {
+	logs.SugarLogger.Errorf("导入任务%d失败: %v", job.ID, cause)
+
+	now := time.Now()
+	job.Status = model.WikiJobFailed
+	job.Error = cause.Error()
+	job.FinishedAt = &now
+	if err := s.jobDAO.Update(job); err != nil {
+		logs.SugarLogger.Errorf("更新导入任务失败: %v", err)
+		return
+	}
+
+	if wiki != nil && job.Kind == model.WikiJobCreate {
+		if err := s.deleteDocuments(context.Background(), wiki); err != nil {
+			logs.SugarLogger.Errorf("清理文章%d的分块失败: %v", wiki.ID, err)
+		}
+		if _, err := s.wikiDAO.DeleteWikis(wiki.UserId, []uint{wiki.ID}); err != nil {
+			logs.SugarLogger.Errorf("删除文章%d失败: %v", wiki.ID, err)
+		}
+	}
+}
+
+// GetJob 获取导入任务
+func (s *WikiService) GetJob(request *req.GetWikiJobRequest) (*model.WikiJob, int64) {
+	job, err := s.jobDAO.Get(request.ID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取导入任务失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+	return job, common.CodeSuccess
+}
+
+// ListJobs 获取文章的导入任务
+func (s *WikiService) ListJobs(request *req.ListWikiJobRequest) ([]*model.WikiJob, int64) {
+	jobs, err := s.jobDAO.ListByWiki(request.WikiID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取导入任务失败: %v", err)
+		return nil, common.CodeServerBusy
+	}
+	return jobs, common.CodeSuccess
+}
+
+// WatchJob 订阅导入任务进度，任务变化时调用onChange，任务结束或ctx取消时返回
+func (s *WikiService) WatchJob(ctx context.Context, request *req.GetWikiJobRequest, onChange func(*model.WikiJob) error) int64 {
+	ticker := time.NewTicker(jobWatchInterval)
+	defer ticker.Stop()
+
+	var lastUpdate time.Time
+	for {
+		job, err := s.jobDAO.Get(request.ID, request.UserID)
+		if err != nil {
+			logs.SugarLogger.Errorf("获取导入任务失败: %v", err)
+			return common.CodeRecordNotFound
+		}
+		if !job.UpdatedAt.Equal(lastUpdate) || job.Finished() {
+			lastUpdate = job.UpdatedAt
+			if err = onChange(job); err != nil {
+				return common.CodeSuccess
+			}
+		}
+		if job.Finished() {
+			return common.CodeSuccess
+		}
+
+		select {
+		case <-ctx.Done():
+			return common.CodeSuccess
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package wikiService
+
+import (
+	"ai_jianli_go/internal/dao"
+	"ai_jianli_go/logs"
+	"ai_jianli_go/types/model"
+	"ai_jianli_go/types/req"
+	"ai_jianli_go/types/resp/common"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	logs.Init()
+	os.Exit(m.Run())
+}
+
+// fakeJobStore 记录任务的领取、创建和更新，未实现的方法调用时panic
+type fakeJobStore struct {
+	jobStore
+	claim     func(owner string, now time.Time, lease time.Duration) (*model.WikiJob, error)
+	updates   []model.WikiJob
+	updateErr error // 不为nil时Update返回该错误，模拟租约被接管
+}
+
+func (f *fakeJobStore) Claim(owner string, now time.Time, lease time.Duration) (*model.WikiJob, error) {
+	return f.claim(owner, now, lease)
+}
+
+func (f *fakeJobStore) Create(job *model.WikiJob) error {
+	f.updates = append(f.updates, *job)
+	return nil
+}
+
+func (f *fakeJobStore) Update(job *model.WikiJob) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	f.updates = append(f.updates, *job)
+	return nil
+}
+
+// fakeWikiStore 记录删除的文章
+type fakeWikiStore struct {
+	wikiStore
+	wikis   map[uint]*model.Wiki
+	deleted []uint
+}
+
+func (f *fakeWikiStore) GetWiki(id uint, userId uint) (*model.Wiki, error) {
+	if wiki, ok := f.wikis[id]; ok && wiki.UserId == userId {
+		return wiki, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeWikiStore) DeleteWikis(userId uint, ids []uint) (int64, error) {
+	f.deleted = append(f.deleted, ids...)
+	return int64(len(ids)), nil
+}
+
+func TestIngestBackoff(t *testing.T) {
+	base := 5 * time.Second
+	cases := map[int]time.Duration{
+		1:  5 * time.Second,
+		2:  10 * time.Second,
+		3:  20 * time.Second,
+		10: maxIngestBackoff,
+	}
+	for attempt, want := range cases {
+		if got := ingestBackoff(attempt, base); got != want {
+			t.Errorf("ingestBackoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestRunNextJobClaim(t *testing.T) {
+	jobs := &fakeJobStore{}
+	s := &WikiService{wikiDAO: &fakeWikiStore{}, jobDAO: jobs}
+
+	jobs.claim = func(string, time.Time, time.Duration) (*model.WikiJob, error) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if s.runNextJob("worker-0") {
+		t.Error("no queued job: runNextJob() = true, want false")
+	}
+
+	jobs.claim = func(string, time.Time, time.Duration) (*model.WikiJob, error) {
+		return nil, errors.New("db down")
+	}
+	if s.runNextJob("worker-0") {
+		t.Error("claim error: runNextJob() = true, want false")
+	}
+
+	// 被其他协程抢先领取时继续领取下一个
+	jobs.claim = func(string, time.Time, time.Duration) (*model.WikiJob, error) {
+		return nil, nil
+	}
+	if !s.runNextJob("worker-0") {
+		t.Error("lost race: runNextJob() = false, want true")
+	}
+	if len(jobs.updates) != 0 {
+		t.Fatalf("lost race updated jobs: %+v", jobs.updates)
+	}
+
+	// 领取成功后以领取者的身份执行并更新任务，文章不存在时任务失败
+	var gotOwner string
+	var gotLease time.Duration
+	jobs.claim = func(owner string, now time.Time, lease time.Duration) (*model.WikiJob, error) {
+		gotOwner, gotLease = owner, lease
+		leaseUntil := now.Add(lease)
+		return &model.WikiJob{Model: gorm.Model{ID: 1}, WikiID: 7, UserID: 3, Kind: model.WikiJobUpdate,
+			Status: model.WikiJobParsing, Attempts: 1, MaxAttempts: 3, Owner: owner, LeaseUntil: &leaseUntil}, nil
+	}
+	if !s.runNextJob("worker-1") {
+		t.Error("claimed: runNextJob() = false, want true")
+	}
+	if gotOwner != "worker-1" || gotLease != jobLease {
+		t.Errorf("Claim(owner=%q, lease=%v), want worker-1, %v", gotOwner, gotLease, jobLease)
+	}
+	if len(jobs.updates) != 1 {
+		t.Fatalf("updates = %d, want 1", len(jobs.updates))
+	}
+	if got := jobs.updates[0]; got.Status != model.WikiJobFailed || got.Owner != "worker-1" {
+		t.Errorf("job = %s owned by %q, want failed owned by worker-1", got.Status, got.Owner)
+	}
+}
+
+func TestRetryJobThenFail(t *testing.T) {
+	jobs := &fakeJobStore{}
+	wikis := &fakeWikiStore{}
+	s := &WikiService{wikiDAO: wikis, jobDAO: jobs}
+	job := &model.WikiJob{Model: gorm.Model{ID: 1}, WikiID: 7, UserID: 3, Kind: model.WikiJobUpdate,
+		Status: model.WikiJobEmbedding, Attempts: 1, MaxAttempts: 2}
+	cause := errors.New("embedding timeout")
+
+	before := time.Now()
+	s.retryJob(job, nil, cause)
+	if job.Status != model.WikiJobQueued || job.Error != cause.Error() {
+		t.Fatalf("after attempt 1: status %s error %q, want queued with cause", job.Status, job.Error)
+	}
+	if !job.NextRunAt.After(before) {
+		t.Errorf("NextRunAt = %v, want after %v", job.NextRunAt, before)
+	}
+	if job.FinishedAt != nil {
+		t.Error("queued job has FinishedAt")
+	}
+
+	job.Status = model.WikiJobEmbedding
+	job.Attempts = 2
+	s.retryJob(job, nil, cause)
+	if job.Status != model.WikiJobFailed || job.FinishedAt == nil {
+		t.Fatalf("after attempt 2: status %s, want failed with FinishedAt", job.Status)
+	}
+	if len(jobs.updates) != 2 {
+		t.Errorf("updates = %d, want 2", len(jobs.updates))
+	}
+	if len(wikis.deleted) != 0 {
+		t.Errorf("update job deleted wikis %v", wikis.deleted)
+	}
+}
+
+func TestFailJobRollback(t *testing.T) {
+	cause := errors.New("parse failed")
+	// RootId为0时不访问索引，只验证文章的回滚
+	wiki := &model.Wiki{UserId: 3}
+	wiki.ID = 7
+
+	jobs := &fakeJobStore{}
+	wikis := &fakeWikiStore{}
+	s := &WikiService{wikiDAO: wikis, jobDAO: jobs}
+	s.failJob(&model.WikiJob{WikiID: 7, UserID: 3, Kind: model.WikiJobCreate}, wiki, cause)
+	if len(wikis.deleted) != 1 || wikis.deleted[0] != 7 {
+		t.Errorf("create job: deleted %v, want [7]", wikis.deleted)
+	}
+	if len(jobs.updates) != 1 || jobs.updates[0].Status != model.WikiJobFailed {
+		t.Errorf("create job: updates %+v, want one failed", jobs.updates)
+	}
+
+	// 更新任务失败时保留文章
+	wikis.deleted = nil
+	s.failJob(&model.WikiJob{WikiID: 7, UserID: 3, Kind: model.WikiJobUpdate}, wiki, cause)
+	if len(wikis.deleted) != 0 {
+		t.Errorf("update job: deleted %v, want none", wikis.deleted)
+	}
+
+	// 租约已被接管时不删除新持有者正在导入的文章
+	jobs.updateErr = dao.ErrJobLeaseLost
+	s.failJob(&model.WikiJob{WikiID: 7, UserID: 3, Kind: model.WikiJobCreate}, wiki, cause)
+	if len(wikis.deleted) != 0 {
+		t.Errorf("lease lost: deleted %v, want none", wikis.deleted)
+	}
+}
+
+func TestUpdateIndexConfigQueuesRebuild(t *testing.T) {
+	jobs := &fakeJobStore{}
+	kb := &model.Wiki{UserId: 3, Type: model.WikiTypeKnowledge}
+	kb.ID = 2
+	s := &WikiService{wikiDAO: &fakeWikiStore{wikis: map[uint]*model.Wiki{2: kb}}, jobDAO: jobs}
+
+	request := &req.UpdateWikiIndexConfigRequest{RootId: 2, UserID: 3}
+	request.Algorithm = model.IndexAlgorithmHNSW
+	job, code := s.UpdateIndexConfig(request)
+	if code != common.CodeSuccess {
+		t.Fatalf("code = %d", code)
+	}
+	if job.Kind != model.WikiJobRebuild || job.Status != model.WikiJobQueued || job.RootID != 2 || job.WikiID != 2 {
+		t.Errorf("job = %+v, want queued rebuild of knowledge base 2", job)
+	}
+	if job.IndexConfig == nil || job.IndexConfig.Algorithm != model.IndexAlgorithmHNSW {
+		t.Errorf("job config = %+v", job.IndexConfig)
+	}
+	if len(jobs.updates) != 1 {
+		t.Errorf("created %d jobs, want 1", len(jobs.updates))
+	}
+
+	// 其他用户的知识库
+	request.UserID = 4
+	if _, code = s.UpdateIndexConfig(request); code != common.CodeRecordNotFound {
+		t.Errorf("other user's knowledge base: code = %d, want %d", code, common.CodeRecordNotFound)
+	}
+}
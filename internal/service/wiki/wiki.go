@@ -2,29 +2,59 @@ package wikiService
 
 import (
 	"ai_jianli_go/component"
+	"ai_jianli_go/config"
 	"ai_jianli_go/internal/dao"
 	"ai_jianli_go/logs"
 	"ai_jianli_go/pkg/rag"
 	"ai_jianli_go/types/model"
 	"ai_jianli_go/types/req"
+	"ai_jianli_go/types/resp"
 	"ai_jianli_go/types/resp/common"
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/schema"
 )
 
+// wikiStore 文章的存储，由dao.WikiDAO实现
+type wikiStore interface {
+	Create(wiki *model.Wiki) error
+	CreateWithJob(wiki *model.Wiki, job *model.WikiJob) error
+	GetWikiList(userId uint) ([]*model.Wiki, int64, error)
+	GetListByParentId(userId uint, parentId uint) ([]*model.Wiki, error)
+	GetWiki(id uint, userId uint) (*model.Wiki, error)
+	GetByID(id uint) (*model.Wiki, error)
+	ListDescendants(userId uint, id uint) ([]*model.Wiki, error)
+	DeleteWikis(userId uint, ids []uint) (int64, error)
+	UpdateWiki(request *req.UpdateWikiRequest) error
+}
+
+// jobStore 导入任务的存储，由dao.WikiJobDAO实现
+type jobStore interface {
+	Create(job *model.WikiJob) error
+	Update(job *model.WikiJob) error
+	Get(id uint, userID uint) (*model.WikiJob, error)
+	ListByWiki(wikiID uint, userID uint) ([]*model.WikiJob, error)
+	Claim(owner string, now time.Time, lease time.Duration) (*model.WikiJob, error)
+	RenewLease(id uint, owner string, leaseUntil time.Time) error
+	UpdateProgress(id uint, owner string, result model.SyncResult) error
+	RequeueExpired(now time.Time) (int64, error)
+}
+
 type WikiService struct {
-	wikiDAO *dao.WikiDAO
+	wikiDAO wikiStore
+	jobDAO  jobStore
 }
 
-func NewWikiService(wikiDAO *dao.WikiDAO) *WikiService {
-	return &WikiService{wikiDAO: wikiDAO}
+func NewWikiService(wikiDAO *dao.WikiDAO, jobDAO *dao.WikiJobDAO) *WikiService {
+	return &WikiService{wikiDAO: wikiDAO, jobDAO: jobDAO}
 }
 
-func (s *WikiService) CreateWiki(request *req.CreateWikiRequest) int64 {
+// CreateWiki 创建条目，文章的解析和向量计算在后台导入任务中执行，返回导入任务
+func (s *WikiService) CreateWiki(request *req.CreateWikiRequest) (*model.WikiJob, int64) {
 
 	wiki := &model.Wiki{
 		UserId:   request.UserID,
@@ -41,47 +71,33 @@ func (s *WikiService) CreateWiki(request *req.CreateWikiRequest) int64 {
 		err := s.wikiDAO.Create(wiki)
 		if err != nil {
 			logs.SugarLogger.Errorf("创建知识库失败: %v", err)
-			return common.CodeCreateWikiFailed
+			return nil, common.CodeCreateWikiFailed
 		}
 		wiki.RootId = wiki.ID
 		err = wiki.CreateIndex(context.Background(), component.GetRedisDB())
 		if err != nil {
 			logs.SugarLogger.Errorf("创建知识库索引失败: %v", err)
-			return common.CodeCreateIndexFailed
+			return nil, common.CodeCreateIndexFailed
 		}
 
 	case model.WikiTypeFolder:
 		err := s.wikiDAO.Create(wiki)
 		if err != nil {
 			logs.SugarLogger.Errorf("创建知识库失败: %v", err)
-			return common.CodeCreateWikiFailed
+			return nil, common.CodeCreateWikiFailed
 		}
 	case model.WikiTypeArticle:
-		err := s.wikiDAO.Create(wiki)
+		job := model.NewWikiJob(model.WikiJobCreate, config.GetIngestConfig().MaxAttempts)
+		job.RootID = wiki.RootId
+		err := s.wikiDAO.CreateWithJob(wiki, job)
 		if err != nil {
 			logs.SugarLogger.Errorf("创建知识库失败: %v", err)
-			return common.CodeCreateWikiFailed
-		}
-		err = wiki.Init(context.Background(), component.GetRedisDB(), rag.GetEmbedding())
-		if err != nil {
-			logs.SugarLogger.Errorf("初始化知识库失败: %v", err)
-			return common.CodeCreateWikiFailed
-		}
-
-		// 根据文件类型选择不同的处理方式
-		docs, err := s.loadDocuments(wiki.Url)
-		if err != nil {
-			logs.SugarLogger.Errorf("加载知识库失败: %v", err)
-			return common.CodeCreateWikiFailed
-		}
-
-		err = wiki.Store(context.Background(), docs)
-		if err != nil {
-			logs.SugarLogger.Errorf("存储知识库失败: %v", err)
-			return common.CodeCreateWikiFailed
+			return nil, common.CodeCreateWikiFailed
 		}
+		notifyIngest()
+		return job, common.CodeSuccess
 	}
-	return common.CodeSuccess
+	return nil, common.CodeSuccess
 }
 
 // loadDocuments 根据文件类型加载文档
@@ -119,38 +135,117 @@ func (s *WikiService) GetWiki(request *req.GetWikiRequest) (*model.Wiki, int64)
 	return wiki, common.CodeSuccess
 }
 
+// UpdateWiki 更新条目，文章会在后台导入任务中重新加载，按内容哈希增量更新索引，返回导入任务
+func (s *WikiService) UpdateWiki(request *req.UpdateWikiRequest) (*model.WikiJob, int64) {
+	wiki, err := s.wikiDAO.GetWiki(request.ID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取知识库条目失败: %v", err)
+		return nil, common.CodeRecordNotFound
+	}
+	if err = s.wikiDAO.UpdateWiki(request); err != nil {
+		logs.SugarLogger.Errorf("更新知识库条目失败: %v", err)
+		return nil, common.CodeUpdateWikiFailed
+	}
+	if wiki.Type != model.WikiTypeArticle {
+		return nil, common.CodeSuccess
+	}
+
+	job := model.NewWikiJob(model.WikiJobUpdate, config.GetIngestConfig().MaxAttempts)
+	job.WikiID = wiki.ID
+	job.RootID = wiki.RootId
+	job.UserID = wiki.UserId
+	if err = s.jobDAO.Create(job); err != nil {
+		logs.SugarLogger.Errorf("创建导入任务失败: %v", err)
+		return nil, common.CodeUpdateWikiFailed
+	}
+	notifyIngest()
+	return job, common.CodeSuccess
+}
+
+// DeleteWiki 删除条目及其在索引中的分块，删除文件夹或知识库时级联删除所有子条目
 func (s *WikiService) DeleteWiki(request *req.DeleteWikiRequest) int64 {
-	s.wikiDAO.DeleteWiki(request)
+	wiki, err := s.wikiDAO.GetWiki(request.ID, request.UserID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取知识库条目失败: %v", err)
+		return common.CodeRecordNotFound
+	}
+	descendants, err := s.wikiDAO.ListDescendants(wiki.UserId, wiki.ID)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取知识库子条目失败: %v", err)
+		return common.CodeDeleteWikiFailed
+	}
+
+	ctx := context.Background()
+	if wiki.Type == model.WikiTypeKnowledge {
+		// 知识库直接删除整个索引及其文档
+		wiki.RootId = wiki.ID
+		if err = wiki.DeleteIndexAndDocuments(ctx, component.GetRedisDB()); err != nil {
+			logs.SugarLogger.Errorf("删除知识库索引失败: %v", err)
+			return common.CodeDeleteWikiFailed
+		}
+	} else {
+		for _, item := range append(descendants, wiki) {
+			if item.Type != model.WikiTypeArticle {
+				continue
+			}
+			if err = s.deleteDocuments(ctx, item); err != nil {
+				logs.SugarLogger.Errorf("删除文章%d的索引失败: %v", item.ID, err)
+				return common.CodeDeleteWikiFailed
+			}
+		}
+	}
+
+	ids := []uint{wiki.ID}
+	for _, item := range descendants {
+		ids = append(ids, item.ID)
+	}
+	if _, err = s.wikiDAO.DeleteWikis(wiki.UserId, ids); err != nil {
+		logs.SugarLogger.Errorf("删除知识库条目失败: %v", err)
+		return common.CodeDeleteWikiFailed
+	}
 	return common.CodeSuccess
 }
 
-func (s *WikiService) Query(request *req.QueryWikiRequest) (string, int64) {
-	wiki := &model.Wiki{
-		UserId: request.UserID,
-		RootId: request.RootId,
+// deleteDocuments 删除文章在索引中的所有分块
+func (s *WikiService) deleteDocuments(ctx context.Context, article *model.Wiki) error {
+	if err := article.Init(ctx, component.GetRedisDB(), rag.GetEmbedding()); err != nil {
+		return err
 	}
-	wiki.Init(context.Background(), component.GetRedisDB(), rag.GetEmbedding())
+	_, err := article.DeleteDocuments(ctx)
+	return err
+}
 
-	docs, err := wiki.Search(context.Background(), request.Query)
+func (s *WikiService) Query(request *req.QueryWikiRequest) (*resp.WikiQueryResp, int64) {
+	if request.ScoreThreshold < 0 || request.ScoreThreshold > 1 {
+		return nil, common.CodeInvalidParams
+	}
+	if _, err := model.BuildFilterQuery(request.Filter); err != nil {
+		logs.SugarLogger.Errorf("知识库过滤条件错误: %v", err)
+		return nil, common.CodeInvalidParams
+	}
+	docs, err := s.retrieve(context.Background(), request)
 	if err != nil {
 		logs.SugarLogger.Errorf("查询知识库失败: %v", err)
-		return "", common.CodeQueryWikiFailed
+		return nil, common.CodeQueryWikiFailed
 	}
 
-	// 构建上下文
+	// 构建上下文，片段编号即引用编号
 	contexts := ""
 	if len(docs) > 0 {
 		contextParts := make([]string, len(docs))
 		for i, doc := range docs {
-			contextParts[i] = fmt.Sprintf("文档片段[%d]:\n%s\n", i+1, doc.Content)
+			title, _ := doc.MetaData[model.MetaTitle].(string)
+			contextParts[i] = fmt.Sprintf("[%d] %s\n%s\n", i+1, title, doc.Content)
 		}
 		contexts = strings.Join(contextParts, "\n---\n")
 	}
 
-	model := component.GetAIComponent().GetChatModel("gpt-4o")
+	chatModel := component.GetAIComponent().GetFeatureModel(component.FeatureWikiQA)
 	template := prompt.FromMessages(schema.FString,
 		schema.SystemMessage(
-			"你是AI知识助手，需要根据用户问题以及知识库内容，回答用户问题。",
+			"你是AI知识助手，需要根据用户问题以及知识库内容，回答用户问题。\n"+
+				"知识库内容中每个片段以[编号]开头，回答中使用某个片段的信息时，在相应句子末尾用[编号]标注来源，如[1]或[1][3]；"+
+				"不要标注不存在的编号，知识库内容与问题无关时不需要标注。",
 		),
 		schema.UserMessage("用户问题：\n{input}"),
 		schema.AssistantMessage(
@@ -166,14 +261,134 @@ func (s *WikiService) Query(request *req.QueryWikiRequest) (string, int64) {
 	messages, err := template.Format(ctx, prompt)
 	if err != nil {
 		logs.SugarLogger.Errorf("生成提示失败: %v", err)
-		return "", common.CodeQueryWikiFailed
+		return nil, common.CodeQueryWikiFailed
 	}
 
-	resp, err := model.Generate(ctx, messages)
+	answer, err := chatModel.Generate(ctx, messages)
 	if err != nil {
 		logs.SugarLogger.Errorf("生成知识库回答失败: %v", err)
-		return "", common.CodeQueryWikiFailed
+		return nil, common.CodeQueryWikiFailed
+	}
+
+	return &resp.WikiQueryResp{
+		Answer:    answer.Content,
+		Citations: buildCitations(docs, s.articleTitles(docs, request.UserID), answer.Content),
+	}, common.CodeSuccess
+}
+
+// articleTitles 查询检索结果所属文章的标题
+func (s *WikiService) articleTitles(docs []*schema.Document, userID uint) map[uint]string {
+	titles := make(map[uint]string)
+	for _, doc := range docs {
+		id := uint(metaInt(doc, model.MetaWikiID))
+		if id == 0 {
+			continue
+		}
+		if _, ok := titles[id]; ok {
+			continue
+		}
+		wiki, err := s.wikiDAO.GetWiki(id, userID)
+		if err != nil {
+			logs.SugarLogger.Errorf("获取知识库文章失败: %v", err)
+			titles[id] = ""
+			continue
+		}
+		titles[id] = wiki.Title
+	}
+	return titles
+}
+
+const (
+	defaultQueryTopK = 10
+	maxQueryTopK     = 50
+)
+
+// retrieve 检索知识库：召回、可选重排，然后按内容向量相似度阈值过滤并截取TopK
+func (s *WikiService) retrieve(ctx context.Context, request *req.QueryWikiRequest) ([]*schema.Document, error) {
+	ctx = rag.WithEmbeddingUser(ctx, request.UserID)
+	wiki := &model.Wiki{
+		UserId: request.UserID,
+		RootId: request.RootId,
+	}
+	if err := wiki.Init(ctx, component.GetRedisDB(), rag.GetEmbedding()); err != nil {
+		return nil, err
+	}
+
+	topK := request.TopK
+	if topK <= 0 {
+		topK = defaultQueryTopK
+	}
+	topK = min(topK, maxQueryTopK)
+	// 重排前多召回一些候选
+	candidates := max(topK*3, 20)
+
+	var docs []*schema.Document
+	var err error
+	switch request.Mode {
+	case model.SearchModeVector:
+		docs, err = wiki.VectorSearch(ctx, request.Query, candidates, request.Filter)
+	default:
+		docs, err = wiki.HybridSearch(ctx, request.Query, model.HybridSearchOptions{
+			Candidates: candidates,
+			Filter:     request.Filter,
+		})
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return resp.Content, common.CodeSuccess
+	// 重排失败时沿用召回顺序
+	if reranked, err := rag.RerankDocuments(ctx, rag.GetReranker(), request.Query, docs, model.MetaScore); err != nil {
+		logs.SugarLogger.Errorf("知识库检索结果重排失败: %v", err)
+	} else {
+		docs = reranked
+	}
+
+	result := make([]*schema.Document, 0, topK)
+	for _, doc := range docs {
+		if len(result) >= topK {
+			break
+		}
+		// 阈值按内容向量相似度过滤，与检索模式和是否重排无关
+		if score, ok := doc.MetaData[model.MetaVectorScore].(float64); ok && score < request.ScoreThreshold {
+			continue
+		}
+		result = append(result, doc)
+	}
+	return result, nil
+}
+
+// 文件夹最大层级，防止父子关系成环时死循环
+const maxFolderDepth = 32
+
+// folderIDs 获取文章所在的各级文件夹ID，从直接父文件夹到最上层文件夹
+func (s *WikiService) folderIDs(wiki *model.Wiki) []uint {
+	var ids []uint
+	parentID := wiki.ParentID
+	for parentID != 0 && len(ids) < maxFolderDepth {
+		parent, err := s.wikiDAO.GetWiki(parentID, wiki.UserId)
+		if err != nil || parent.Type != model.WikiTypeFolder {
+			break
+		}
+		ids = append(ids, parent.ID)
+		parentID = parent.ParentID
+	}
+	return ids
+}
+
+const maxEmbeddingUsageDays = 90
+
+// GetEmbeddingUsage 获取用户最近几天的嵌入用量
+func (s *WikiService) GetEmbeddingUsage(request *req.GetEmbeddingUsageRequest) ([]rag.EmbeddingUsage, int64) {
+	days := request.Days
+	if days <= 0 {
+		days = 7
+	}
+	days = min(days, maxEmbeddingUsageDays)
+	usages, err := rag.GetEmbeddingUsage(context.Background(), component.GetRedisDB(), request.UserID, days)
+	if err != nil {
+		logs.SugarLogger.Errorf("获取嵌入用量失败: %v", err)
+		return nil, common.CodeServerBusy
+	}
+	return usages, common.CodeSuccess
 }
@@ -4,7 +4,9 @@ import (
 	"ai_jianli_go/component"
 	"ai_jianli_go/component/auth/role"
 	"ai_jianli_go/config"
+	"ai_jianli_go/internal/dao"
 	"ai_jianli_go/internal/router"
+	wikiService "ai_jianli_go/internal/service/wiki"
 	"ai_jianli_go/logs"
 	"ai_jianli_go/pkg/rag"
 )
@@ -16,6 +18,9 @@ func main() {
 	rag.Init()
 	role.InitCasbin()
 
+	// 启动知识库文档导入任务协程
+	wikiService.NewWikiService(dao.NewWikiDAO(component.GetMySQLDB()), dao.NewWikiJobDAO(component.GetMySQLDB())).StartIngestWorkers()
+
 	router := router.Init()
 
 	router.Run(":8080")
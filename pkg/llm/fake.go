@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// FakeChatModel 本地脚本化模型，按提示词关键字返回预设回答，用于离线测试
+type FakeChatModel struct {
+	mu sync.Mutex
+
+	// keys 按长度降序排列，优先匹配更具体的关键字
+	keys      []string
+	responses map[string]string
+	// 没有关键字命中时返回的回答，为空时返回错误
+	defaultResponse string
+	// 每次调用收到的消息，便于测试断言
+	calls [][]*schema.Message
+}
+
+var _ model.ChatModel = (*FakeChatModel)(nil)
+
+// NewFakeChatModel 创建脚本化模型，responses的key为提示词中包含的关键字
+func NewFakeChatModel(responses map[string]string, defaultResponse string) *FakeChatModel {
+	m := &FakeChatModel{
+		responses:       make(map[string]string, len(responses)),
+		defaultResponse: defaultResponse,
+	}
+	for k, v := range responses {
+		m.AddResponse(k, v)
+	}
+	return m
+}
+
+// AddResponse 添加一条预设回答
+func (m *FakeChatModel) AddResponse(key, response string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.responses[key]; !ok {
+		m.keys = append(m.keys, key)
+		sort.SliceStable(m.keys, func(i, j int) bool {
+			return len(m.keys[i]) > len(m.keys[j])
+		})
+	}
+	m.responses[key] = response
+}
+
+// Calls 返回所有调用记录
+func (m *FakeChatModel) Calls() [][]*schema.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+func (m *FakeChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	content, err := m.match(input)
+	if err != nil {
+		return nil, err
+	}
+	return schema.AssistantMessage(content, nil), nil
+}
+
+// Stream 按字符逐个返回预设回答
+func (m *FakeChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	content, err := m.match(input)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make([]*schema.Message, 0, len(content))
+	for _, r := range content {
+		chunks = append(chunks, schema.AssistantMessage(string(r), nil))
+	}
+	return schema.StreamReaderFromArray(chunks), nil
+}
+
+func (m *FakeChatModel) BindTools(tools []*schema.ToolInfo) error {
+	return nil
+}
+
+func (m *FakeChatModel) match(input []*schema.Message) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, input)
+
+	var sb strings.Builder
+	for _, msg := range input {
+		sb.WriteString(msg.Content)
+		sb.WriteString("\n")
+	}
+	prompt := sb.String()
+
+	for _, key := range m.keys {
+		if strings.Contains(prompt, key) {
+			return m.responses[key], nil
+		}
+	}
+	if m.defaultResponse != "" {
+		return m.defaultResponse, nil
+	}
+	return "", fmt.Errorf("fake chat model: no response matches prompt")
+}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestFakeChatModelGenerate(t *testing.T) {
+	m := NewFakeChatModel(map[string]string{
+		"Redis":    "redis",
+		"Redis持久化": "persistence",
+	}, "")
+
+	ctx := context.Background()
+	res, err := m.Generate(ctx, []*schema.Message{schema.UserMessage("聊聊Redis持久化")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Content != "persistence" {
+		t.Fatalf("longest key should win, got %q", res.Content)
+	}
+
+	if _, err = m.Generate(ctx, []*schema.Message{schema.UserMessage("MySQL")}); err == nil {
+		t.Fatal("expected error when nothing matches and no default response")
+	}
+	if len(m.Calls()) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(m.Calls()))
+	}
+}
+
+func TestFakeChatModelStream(t *testing.T) {
+	m := NewFakeChatModel(nil, "你好")
+	sr, err := m.Stream(context.Background(), []*schema.Message{schema.UserMessage("hi")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sr.Close()
+
+	var chunks []*schema.Message
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("recv failed: %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected one chunk per rune, got %d", len(chunks))
+	}
+	msg, err := schema.ConcatMessages(chunks)
+	if err != nil || msg.Content != "你好" {
+		t.Fatalf("concat mismatch: %v %q", err, msg.Content)
+	}
+}
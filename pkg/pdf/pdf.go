@@ -0,0 +1,204 @@
+// Package pdf 纯Go实现的简单PDF文本文档生成，用于导出面试报告。
+// 使用PDF阅读器内置的STSong-Light中文字体（Adobe-GB1字符集），不嵌入字体文件，
+// 只支持基本多文种平面内的字符，超出范围的字符输出为?
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// A4纸张尺寸和页边距，单位为pt
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+	Margin     = 50.0
+
+	BodySize = 11.0
+	// 行高为字号的倍数
+	lineSpacing = 1.5
+	tabSpaces   = "    "
+)
+
+// 各级标题字号
+var headingSizes = []float64{18, 15, 13}
+
+// Document 按顺序写入标题和段落，自动换行和分页
+type Document struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	// 下一行文字的基线位置
+	y float64
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+// Heading 写入标题，level从1开始，超过3级按3级处理
+func (d *Document) Heading(level int, text string) {
+	size := headingSizes[min(max(level, 1), len(headingSizes))-1]
+	d.Space(size * 0.5)
+	d.write(text, size)
+	d.Space(size * 0.3)
+}
+
+// Paragraph 写入正文段落，保留原文的换行
+func (d *Document) Paragraph(text string) {
+	d.write(text, BodySize)
+	d.Space(BodySize * 0.5)
+}
+
+// Space 空出指定高度，空间不足时换页
+func (d *Document) Space(height float64) {
+	if d.page == nil {
+		return
+	}
+	d.y -= height
+	if d.y < Margin {
+		d.newPage()
+	}
+}
+
+// PageCount 已生成的页数
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) write(text string, size float64) {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\t", tabSpaces)
+	for _, line := range strings.Split(text, "\n") {
+		for _, l := range wrap(line, PageWidth-2*Margin, size) {
+			d.line(l, size)
+		}
+	}
+}
+
+func (d *Document) line(text string, size float64) {
+	height := size * lineSpacing
+	if d.page == nil || d.y-height < Margin {
+		d.newPage()
+	}
+	d.y -= height
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(d.page, "BT /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", size, Margin, d.y, encode(text))
+}
+
+func (d *Document) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = PageHeight - Margin
+}
+
+// Bytes 输出PDF文件内容，没有写入内容时输出一个空白页
+func (d *Document) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.newPage()
+	}
+
+	w := &writer{}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 对象编号：1目录 2页面树 3字体 4CID字体 5字体描述，之后每页依次为页面和内容流
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	w.object("<< /Type /Catalog /Pages 2 0 R >>")
+	w.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	w.object("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>")
+	// 半角字符(CID 1-95)宽度为500，其余字符为全角
+	w.object("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> " +
+		"/FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>")
+	w.object("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	for i, page := range d.pages {
+		w.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", PageWidth, PageHeight, 7+2*i))
+		w.object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+	w.finish()
+	return w.buf.Bytes()
+}
+
+// writer 记录每个对象的偏移量用于生成交叉引用表
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (w *writer) object(body string) {
+	w.offsets = append(w.offsets, w.buf.Len())
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", len(w.offsets), body)
+}
+
+func (w *writer) finish() {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, xref)
+}
+
+// encode 按UCS-2大端编码为十六进制字符串
+func encode(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if r > 0xFFFF || !unicode.IsGraphic(r) {
+			r = '?'
+		}
+		fmt.Fprintf(&sb, "%04X", r)
+	}
+	return sb.String()
+}
+
+// runeWidth 字符宽度，半角字符为半个字号
+func runeWidth(r rune, size float64) float64 {
+	if r < 0x80 {
+		return size / 2
+	}
+	return size
+}
+
+// wrap 按宽度折行，英文单词尽量不从中间断开，中文可在任意字符处断开
+func wrap(text string, width, size float64) []string {
+	runes := []rune(strings.TrimRight(text, " "))
+	if len(runes) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	start, lineWidth := 0, 0.0
+	// 当前行中最后一个可断开位置（空格之后）
+	lastBreak := -1
+	for i := 0; i < len(runes); i++ {
+		w := runeWidth(runes[i], size)
+		if lineWidth+w > width && i > start {
+			end := i
+			if runes[i] != ' ' && runes[i] < 0x80 && lastBreak > start {
+				end = lastBreak
+			}
+			lines = append(lines, strings.TrimRight(string(runes[start:end]), " "))
+			// 换行处的空格不保留到下一行
+			for end < len(runes) && runes[end] == ' ' {
+				end++
+			}
+			start, lineWidth, lastBreak = end, 0, -1
+			i = end - 1
+			continue
+		}
+		lineWidth += w
+		if runes[i] == ' ' || runes[i] >= 0x80 {
+			lastBreak = i + 1
+		}
+	}
+	if start < len(runes) {
+		lines = append(lines, string(runes[start:]))
+	}
+	return lines
+}
@@ -0,0 +1,61 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	if got := encode("A中\U0001F600"); got != "00414E2D003F" {
+		t.Fatalf("encode mismatch: %s", got)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	// 宽度可容纳10个半角字符
+	lines := wrap("hello world foo", 50, 10)
+	if strings.Join(lines, "|") != "hello|world foo" {
+		t.Fatalf("english wrap mismatch: %q", lines)
+	}
+	lines = wrap("中文可以在任意位置断开", 50, 10)
+	if strings.Join(lines, "|") != "中文可以在|任意位置断|开" {
+		t.Fatalf("chinese wrap mismatch: %q", lines)
+	}
+	if lines = wrap("", 50, 10); len(lines) != 1 || lines[0] != "" {
+		t.Fatalf("empty line mismatch: %q", lines)
+	}
+}
+
+func TestDocumentBytes(t *testing.T) {
+	doc := New()
+	doc.Heading(1, "面试报告")
+	for i := 0; i < 100; i++ {
+		doc.Paragraph(fmt.Sprintf("第%d段内容", i))
+	}
+	if doc.PageCount() < 2 {
+		t.Fatalf("expected multiple pages, got %d", doc.PageCount())
+	}
+
+	data := doc.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("invalid pdf header or trailer")
+	}
+	if !bytes.Contains(data, []byte(fmt.Sprintf("/Count %d", doc.PageCount()))) {
+		t.Fatalf("page count not written")
+	}
+
+	// 交叉引用表中的偏移量必须指向对应的对象
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(data)
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := strings.Split(string(data[xref:]), "\n")[3:]
+	for i := 0; i < 5+2*doc.PageCount(); i++ {
+		offset, _ := strconv.Atoi(entries[i][:10])
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Fatalf("xref entry %d points to wrong object", i+1)
+		}
+	}
+}
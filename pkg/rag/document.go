@@ -192,8 +192,8 @@ func (pl *pdfLoader) Load(ctx context.Context, source string) ([]*schema.Documen
 		return nil, fmt.Errorf("failed to load PDF file %s: %w", source, err)
 	}
 
-	// 为每个文档添加元数据
-	for _, doc := range docs {
+	// 为每个文档添加元数据，按页解析时每个文档对应一页
+	for i, doc := range docs {
 		if doc.MetaData == nil {
 			doc.MetaData = make(map[string]any)
 		}
@@ -201,6 +201,9 @@ func (pl *pdfLoader) Load(ctx context.Context, source string) ([]*schema.Documen
 		doc.MetaData["source_type"] = "file"
 		doc.MetaData["file_type"] = ".pdf"
 		doc.MetaData["file_name"] = filepath.Base(source)
+		doc.MetaData["page"] = i + 1
+		doc.MetaData["chunk_id"] = i
+		doc.MetaData["total_chunks"] = len(docs)
 		doc.MetaData["loader"] = "PDF专用加载器"
 	}
 
@@ -229,16 +232,11 @@ func (tfl *textFileLoader) Load(ctx context.Context, source string) ([]*schema.D
 	}
 
 	ext := strings.ToLower(filepath.Ext(source))
-	var processedContent string
-
-	switch ext {
-	case ".md", ".markdown":
-		processedContent = processMarkdown(content)
-	default:
-		processedContent = content
+	if ext == ".md" || ext == ".markdown" {
+		return loadMarkdown(source, content), nil
 	}
 
-	chunks := chunkText(processedContent, 1000)
+	chunks := chunkText(content, defaultChunkSize)
 	documents := make([]*schema.Document, len(chunks))
 
 	for i, chunk := range chunks {
@@ -260,6 +258,37 @@ func (tfl *textFileLoader) Load(ctx context.Context, source string) ([]*schema.D
 	return documents, nil
 }
 
+// loadMarkdown 按章节层级切分Markdown，标题路径作为分块的title
+func loadMarkdown(source, content string) []*schema.Document {
+	ext := strings.ToLower(filepath.Ext(source))
+	chunks := newMarkdownSplitter(defaultChunkSize, defaultChunkOverlap, sizeUnitRune).Split(content)
+
+	documents := make([]*schema.Document, len(chunks))
+	for i, chunk := range chunks {
+		headingPath := strings.Join(chunk.HeadingPath, headingPathSep)
+		metaData := map[string]any{
+			"source":       source,
+			"source_type":  "file",
+			"file_type":    ext,
+			"file_name":    filepath.Base(source),
+			"chunk_id":     i,
+			"total_chunks": len(chunks),
+			"heading_path": headingPath,
+			"loader":       "文本文件加载器",
+		}
+		if headingPath != "" {
+			metaData["title"] = headingPath
+		}
+		documents[i] = &schema.Document{
+			ID:       fmt.Sprintf("text_%s_chunk_%d", filepath.Base(source), i),
+			Content:  chunk.Content,
+			MetaData: metaData,
+		}
+	}
+
+	return documents
+}
+
 func (tfl *textFileLoader) GetSupportedFormats() []string {
 	return []string{".txt", ".md", ".markdown"}
 }
@@ -276,29 +305,58 @@ func newDOCXLoader() *docxLoader {
 }
 
 func (dl *docxLoader) Load(ctx context.Context, source string) ([]*schema.Document, error) {
-	// 这里应该使用实际的DOCX解析器
-	// 为了示例，我们创建一些模拟的文档
-	content, err := readFileContent(source)
+	f, err := os.Open(source)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read DOCX file %s: %w", source, err)
 	}
+	defer f.Close()
 
-	// 简单的文本提取（实际应该使用专门的DOCX解析器）
-	docs := []*schema.Document{
-		{
-			ID:      fmt.Sprintf("docx_%s", filepath.Base(source)),
-			Content: content,
-			MetaData: map[string]any{
-				"source":      source,
-				"source_type": "file",
-				"file_type":   ".docx",
-				"file_name":   filepath.Base(source),
-				"loader":      "Word文档加载器",
-			},
-		},
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DOCX file %s: %w", source, err)
 	}
 
-	return docs, nil
+	blocks, err := parseDOCX(f, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DOCX file %s: %w", source, err)
+	}
+
+	// 按标题划分章节后再分块，每个分块记录所属章节的标题路径
+	type docxChunk struct {
+		headingPath string
+		content     string
+	}
+	var chunks []docxChunk
+	for _, section := range groupDOCXSections(blocks) {
+		headingPath := strings.Join(section.HeadingPath, headingPathSep)
+		for _, chunk := range chunkText(section.Content, defaultChunkSize) {
+			chunks = append(chunks, docxChunk{headingPath: headingPath, content: chunk})
+		}
+	}
+
+	documents := make([]*schema.Document, len(chunks))
+	for i, chunk := range chunks {
+		metaData := map[string]any{
+			"source":       source,
+			"source_type":  "file",
+			"file_type":    ".docx",
+			"file_name":    filepath.Base(source),
+			"chunk_id":     i,
+			"total_chunks": len(chunks),
+			"heading_path": chunk.headingPath,
+			"loader":       "Word文档加载器",
+		}
+		if chunk.headingPath != "" {
+			metaData["title"] = chunk.headingPath
+		}
+		documents[i] = &schema.Document{
+			ID:       fmt.Sprintf("docx_%s_chunk_%d", filepath.Base(source), i),
+			Content:  chunk.content,
+			MetaData: metaData,
+		}
+	}
+
+	return documents, nil
 }
 
 func (dl *docxLoader) GetSupportedFormats() []string {
@@ -323,7 +381,7 @@ func (hl *htmlLoader) Load(ctx context.Context, source string) ([]*schema.Docume
 	}
 
 	processedContent := cleanHTML(content)
-	chunks := chunkText(processedContent, 1000)
+	chunks := chunkText(processedContent, defaultChunkSize)
 
 	documents := make([]*schema.Document, len(chunks))
 	for i, chunk := range chunks {
@@ -419,43 +477,6 @@ func readFileContent(filePath string) (string, error) {
 	return string(content), nil
 }
 
-// processMarkdown 处理Markdown内容
-func processMarkdown(content string) string {
-	lines := strings.Split(content, "\n")
-	var processedLines []string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// 移除标题标记
-		line = strings.TrimPrefix(line, "#")
-		line = strings.TrimPrefix(line, "##")
-		line = strings.TrimPrefix(line, "###")
-		line = strings.TrimPrefix(line, "####")
-		line = strings.TrimPrefix(line, "#####")
-		line = strings.TrimPrefix(line, "######")
-
-		// 移除列表标记
-		line = strings.TrimPrefix(line, "-")
-		line = strings.TrimPrefix(line, "*")
-		line = strings.TrimPrefix(line, "+")
-
-		// 移除代码块标记
-		line = strings.TrimPrefix(line, "```")
-		line = strings.TrimSuffix(line, "```")
-
-		line = strings.TrimSpace(line)
-		if line != "" {
-			processedLines = append(processedLines, line)
-		}
-	}
-
-	return strings.Join(processedLines, "\n")
-}
-
 // cleanHTML 清理HTML标签
 func cleanHTML(content string) string {
 	// 简单的HTML标签清理
@@ -473,33 +494,33 @@ func cleanHTML(content string) string {
 	return content
 }
 
-// chunkText 将文本分块
+// chunkText 将文本分块，按字符数计算大小，避免切断多字节字符
 func chunkText(text string, chunkSize int) []string {
-	if len(text) <= chunkSize {
+	runes := []rune(text)
+	if len(runes) <= chunkSize {
 		return []string{text}
 	}
 
 	var chunks []string
 	start := 0
 
-	for start < len(text) {
+	for start < len(runes) {
 		end := start + chunkSize
-		if end > len(text) {
-			end = len(text)
+		if end > len(runes) {
+			end = len(runes)
 		}
 
-		// 尝试在句子边界分割
-		if end < len(text) {
-			// 向后查找最近的句号、换行符或空格
-			for end > start && end < len(text) {
-				if text[end] == '.' || text[end] == '\n' || text[end] == ' ' {
+		// 尝试在句子边界分割，找不到边界时按chunkSize切分
+		if end < len(runes) {
+			for i := end; i > start; i-- {
+				if isChunkBoundary(runes[i-1]) {
+					end = i
 					break
 				}
-				end--
 			}
 		}
 
-		chunk := strings.TrimSpace(text[start:end])
+		chunk := strings.TrimSpace(string(runes[start:end]))
 		if chunk != "" {
 			chunks = append(chunks, chunk)
 		}
@@ -0,0 +1,352 @@
+package rag
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	docxDocumentPath = "word/document.xml"
+	docxStylesPath   = "word/styles.xml"
+	headingPathSep   = " > "
+)
+
+// docx内容块类型
+const (
+	docxBlockParagraph = "paragraph"
+	docxBlockHeading   = "heading"
+	docxBlockList      = "list"
+	docxBlockTable     = "table"
+)
+
+// docxBlock 从word/document.xml中提取的内容块
+type docxBlock struct {
+	Kind  string
+	Level int // 标题级别(1-9)或列表缩进级别(0开始)
+	Text  string
+}
+
+// docxSection 按标题划分的章节
+type docxSection struct {
+	HeadingPath []string
+	Content     string
+}
+
+// 标题样式名，如 "heading 1"、"Heading1"、"标题 1"
+var headingStyleRe = regexp.MustCompile(`(?i)^(?:heading|标题)\s*([1-9])$`)
+
+// parseDOCX 解析docx文件，按文档顺序返回段落、标题、列表和表格
+func parseDOCX(r io.ReaderAt, size int64) ([]docxBlock, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open docx archive failed: %w", err)
+	}
+
+	var documentFile, stylesFile *zip.File
+	for _, f := range zr.File {
+		switch f.Name {
+		case docxDocumentPath:
+			documentFile = f
+		case docxStylesPath:
+			stylesFile = f
+		}
+	}
+	if documentFile == nil {
+		return nil, fmt.Errorf("%s not found in docx", docxDocumentPath)
+	}
+
+	headingStyles := map[string]int{}
+	if stylesFile != nil {
+		rc, err := stylesFile.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s failed: %w", docxStylesPath, err)
+		}
+		headingStyles, err = parseDOCXHeadingStyles(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rc, err := documentFile.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s failed: %w", docxDocumentPath, err)
+	}
+	defer rc.Close()
+
+	return parseDOCXDocument(rc, headingStyles)
+}
+
+// parseDOCXHeadingStyles 解析styles.xml，返回标题样式ID到标题级别的映射
+func parseDOCXHeadingStyles(r io.Reader) (map[string]int, error) {
+	styles := map[string]int{}
+	decoder := xml.NewDecoder(r)
+
+	var styleID string
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return styles, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s failed: %w", docxStylesPath, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "style":
+				styleID = xmlAttr(t, "styleId")
+				if level := headingLevelFromName(styleID); level > 0 {
+					styles[styleID] = level
+				}
+			case "name":
+				if level := headingLevelFromName(xmlAttr(t, "val")); level > 0 && styleID != "" {
+					styles[styleID] = level
+				}
+			case "outlineLvl":
+				if lvl, err := strconv.Atoi(xmlAttr(t, "val")); err == nil && styleID != "" && lvl < 9 {
+					styles[styleID] = lvl + 1
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "style" {
+				styleID = ""
+			}
+		}
+	}
+}
+
+func headingLevelFromName(name string) int {
+	name = strings.TrimSpace(name)
+	if strings.EqualFold(name, "title") {
+		return 1
+	}
+	m := headingStyleRe.FindStringSubmatch(name)
+	if m == nil {
+		return 0
+	}
+	level, _ := strconv.Atoi(m[1])
+	return level
+}
+
+// docxParagraph 解析中的段落状态
+type docxParagraph struct {
+	text    strings.Builder
+	style   string
+	outline int // 段落直接设置的大纲级别，-1表示未设置
+	isList  bool
+	ilvl    int
+}
+
+// parseDOCXDocument 流式解析document.xml
+func parseDOCXDocument(r io.Reader, headingStyles map[string]int) ([]docxBlock, error) {
+	decoder := xml.NewDecoder(r)
+
+	var (
+		blocks    []docxBlock
+		paras     []*docxParagraph // 文本框中的段落会嵌套在段落内
+		inText    bool
+		tableRows [][]string // 最外层表格的行
+		row       []string
+		cell      []string
+		tblDepth  int
+	)
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return blocks, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s failed: %w", docxDocumentPath, err)
+		}
+
+		var para *docxParagraph
+		if len(paras) > 0 {
+			para = paras[len(paras)-1]
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				paras = append(paras, &docxParagraph{outline: -1})
+			case "pStyle":
+				if para != nil {
+					para.style = xmlAttr(t, "val")
+				}
+			case "numPr":
+				if para != nil {
+					para.isList = true
+				}
+			case "ilvl":
+				if para != nil {
+					para.ilvl, _ = strconv.Atoi(xmlAttr(t, "val"))
+				}
+			case "outlineLvl":
+				if para != nil {
+					if lvl, err := strconv.Atoi(xmlAttr(t, "val")); err == nil && lvl < 9 {
+						para.outline = lvl
+					}
+				}
+			case "t":
+				inText = true
+			case "tab":
+				if para != nil {
+					para.text.WriteString("\t")
+				}
+			case "br", "cr":
+				if para != nil {
+					para.text.WriteString("\n")
+				}
+			case "tbl":
+				tblDepth++
+				if tblDepth == 1 {
+					tableRows = nil
+				}
+			case "tr":
+				if tblDepth == 1 {
+					row = nil
+				}
+			case "tc":
+				if tblDepth == 1 {
+					cell = nil
+				}
+			}
+		case xml.CharData:
+			if inText && para != nil {
+				para.text.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if para == nil {
+					continue
+				}
+				text := strings.TrimSpace(para.text.String())
+				if tblDepth > 0 {
+					// 表格内的段落（含嵌套表格）合并到最外层单元格
+					if text != "" {
+						cell = append(cell, text)
+					}
+				} else if text != "" {
+					blocks = append(blocks, para.block(text, headingStyles))
+				}
+				paras = paras[:len(paras)-1]
+			case "tc":
+				if tblDepth == 1 {
+					row = append(row, strings.Join(cell, " "))
+				}
+			case "tr":
+				if tblDepth == 1 && len(row) > 0 {
+					tableRows = append(tableRows, row)
+				}
+			case "tbl":
+				tblDepth--
+				if tblDepth == 0 && len(tableRows) > 0 {
+					blocks = append(blocks, docxBlock{Kind: docxBlockTable, Text: renderDOCXTable(tableRows)})
+				}
+			}
+		}
+	}
+}
+
+// block 根据段落样式判断段落类型
+func (p *docxParagraph) block(text string, headingStyles map[string]int) docxBlock {
+	level := headingStyles[p.style]
+	if level == 0 {
+		level = headingLevelFromName(p.style)
+	}
+	if level == 0 && p.outline >= 0 {
+		level = p.outline + 1
+	}
+	if level > 0 {
+		return docxBlock{Kind: docxBlockHeading, Level: level, Text: text}
+	}
+	if p.isList {
+		return docxBlock{Kind: docxBlockList, Level: p.ilvl, Text: text}
+	}
+	return docxBlock{Kind: docxBlockParagraph, Text: text}
+}
+
+// renderDOCXTable 把表格渲染为Markdown表格文本，第一行作为表头
+func renderDOCXTable(rows [][]string) string {
+	var sb strings.Builder
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, c := range row {
+			cells[j] = strings.ReplaceAll(strings.ReplaceAll(c, "\n", " "), "|", "\\|")
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			sb.WriteString("|" + strings.Repeat(" --- |", len(row)) + "\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// groupDOCXSections 按标题层级把内容块划分为章节，每个章节记录完整的标题路径
+func groupDOCXSections(blocks []docxBlock) []docxSection {
+	var (
+		sections []docxSection
+		headings []docxBlock
+		content  []string
+	)
+
+	headingPath := func() []string {
+		path := make([]string, len(headings))
+		for i, h := range headings {
+			path[i] = h.Text
+		}
+		return path
+	}
+	flush := func() {
+		// 只有标题没有正文的章节跳过，标题已包含在下级章节的标题路径中
+		if len(content) == 0 || (len(content) == 1 && len(headings) > 0 && content[0] == headings[len(headings)-1].Text) {
+			content = nil
+			return
+		}
+		sections = append(sections, docxSection{
+			HeadingPath: headingPath(),
+			Content:     strings.Join(content, "\n"),
+		})
+		content = nil
+	}
+
+	for _, b := range blocks {
+		switch b.Kind {
+		case docxBlockHeading:
+			flush()
+			// 弹出同级及更低级的标题
+			for len(headings) > 0 && headings[len(headings)-1].Level >= b.Level {
+				headings = headings[:len(headings)-1]
+			}
+			headings = append(headings, b)
+			content = append(content, b.Text)
+		case docxBlockList:
+			content = append(content, strings.Repeat("  ", b.Level)+"- "+b.Text)
+		default:
+			content = append(content, b.Text)
+		}
+	}
+	flush()
+
+	return sections
+}
+
+func xmlAttr(t xml.StartElement, local string) string {
+	for _, attr := range t.Attr {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package rag
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testDocxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:style w:type="paragraph" w:styleId="1"><w:name w:val="heading 1"/></w:style>
+  <w:style w:type="paragraph" w:styleId="2"><w:name w:val="heading 2"/></w:style>
+  <w:style w:type="paragraph" w:styleId="a3"><w:name w:val="List Paragraph"/></w:style>
+</w:styles>`
+
+const testDocxDocument = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:body>
+    <w:p><w:r><w:t>前言内容</w:t></w:r></w:p>
+    <w:p><w:pPr><w:pStyle w:val="1"/></w:pPr><w:r><w:t>Go语言</w:t></w:r></w:p>
+    <w:p><w:pPr><w:pStyle w:val="2"/></w:pPr><w:r><w:t>并发</w:t></w:r></w:p>
+    <w:p><w:r><w:t xml:space="preserve">goroutine是</w:t></w:r><w:r><w:t>轻量级线程</w:t></w:r></w:p>
+    <w:p><w:pPr><w:pStyle w:val="a3"/><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>channel</w:t></w:r></w:p>
+    <w:p><w:pPr><w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>select</w:t></w:r></w:p>
+    <w:tbl>
+      <w:tr><w:tc><w:p><w:r><w:t>关键字</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>说明</w:t></w:r></w:p></w:tc></w:tr>
+      <w:tr><w:tc><w:p><w:r><w:t>go</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>启动协程</w:t></w:r></w:p><w:p><w:r><w:t>a|b</w:t></w:r></w:p></w:tc></w:tr>
+    </w:tbl>
+    <w:p><w:pPr><w:outlineLvl w:val="1"/></w:pPr><w:r><w:t>内存模型</w:t></w:r></w:p>
+    <w:p><w:r><w:t>happens</w:t></w:r><w:r><w:tab/><w:t>before</w:t></w:r></w:p>
+  </w:body>
+</w:document>`
+
+func buildTestDocx(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestParseDOCX(t *testing.T) {
+	r := buildTestDocx(t, map[string]string{
+		docxDocumentPath: testDocxDocument,
+		docxStylesPath:   testDocxStyles,
+	})
+	blocks, err := parseDOCX(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []docxBlock{
+		{Kind: docxBlockParagraph, Text: "前言内容"},
+		{Kind: docxBlockHeading, Level: 1, Text: "Go语言"},
+		{Kind: docxBlockHeading, Level: 2, Text: "并发"},
+		{Kind: docxBlockParagraph, Text: "goroutine是轻量级线程"},
+		{Kind: docxBlockList, Level: 0, Text: "channel"},
+		{Kind: docxBlockList, Level: 1, Text: "select"},
+		{Kind: docxBlockTable, Text: "| 关键字 | 说明 |\n| --- | --- |\n| go | 启动协程 a\\|b |"},
+		{Kind: docxBlockHeading, Level: 2, Text: "内存模型"},
+		{Kind: docxBlockParagraph, Text: "happens\tbefore"},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("got %d blocks, want %d: %+v", len(blocks), len(want), blocks)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("block %d = %+v, want %+v", i, blocks[i], want[i])
+		}
+	}
+
+	sections := groupDOCXSections(blocks)
+	wantPaths := []string{"", "Go语言 > 并发", "Go语言 > 内存模型"}
+	if len(sections) != len(wantPaths) {
+		t.Fatalf("got %d sections, want %d: %+v", len(sections), len(wantPaths), sections)
+	}
+	for i, s := range sections {
+		if got := strings.Join(s.HeadingPath, headingPathSep); got != wantPaths[i] {
+			t.Errorf("section %d heading path = %q, want %q", i, got, wantPaths[i])
+		}
+	}
+	if want := "并发\ngoroutine是轻量级线程\n- channel\n  - select\n| 关键字"; !strings.HasPrefix(sections[1].Content, want) {
+		t.Errorf("unexpected section content: %q", sections[1].Content)
+	}
+}
+
+func TestParseDOCXMissingDocument(t *testing.T) {
+	r := buildTestDocx(t, map[string]string{docxStylesPath: testDocxStyles})
+	if _, err := parseDOCX(r, r.Size()); err == nil {
+		t.Fatal("expected error for docx without document.xml")
+	}
+	if _, err := parseDOCX(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Fatal("expected error for non-zip input")
+	}
+}
@@ -1,38 +1,59 @@
 package rag
 
 import (
+	"ai_jianli_go/component"
+	"ai_jianli_go/config"
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/cloudwego/eino-ext/components/embedding/openai"
+	"github.com/cloudwego/eino/components/embedding"
 )
 
 var (
-	embedder *openai.Embedder
+	embedder embedding.Embedder
 	// format    = aclopenai.EmbeddingEncodingFormatFloat
-	dimension = 2560     // 1536 is the default dimension for text-embedding-ada-002
-	user      = "system" // system is the default user for embedding
-
-	embeddingAPIKey = "6dcde77d-fbc4-4ae4-9842-7f11a4ce61a1"
-	Model           = "doubao-embedding-text-240715"
-	BaseURL         = "https://ark.cn-beijing.volces.com/api/v3"
+	user = "system" // system is the default user for embedding
 )
 
 func initEmbedding(ctx context.Context) (err error) {
-	embedder, err = openai.NewEmbedder(ctx, &openai.EmbeddingConfig{
+	conf := config.GetEmbeddingConfig()
+	if conf.Model == "" || conf.APIKey == "" {
+		return fmt.Errorf("embedding model or apiKey not configured")
+	}
+
+	dimension := conf.Dimension
+	base, err := openai.NewEmbedder(ctx, &openai.EmbeddingConfig{
 		// OpenAI API 配置
-		APIKey:  embeddingAPIKey,
-		Model:   Model,
+		APIKey:  conf.APIKey,
+		Model:   conf.Model,
 		Timeout: 30 * time.Second,
 
-		BaseURL: BaseURL,
+		BaseURL: conf.BaseURL,
 
 		Dimensions: &dimension, // 向量维度
 		User:       &user,      // 用户标识
 	})
-	return
+	if err != nil {
+		return err
+	}
+
+	var ttl time.Duration
+	if conf.CacheTTL > 0 {
+		ttl = time.Duration(conf.CacheTTL) * time.Hour
+	}
+	embedder = NewCachedEmbedder(base, component.GetRedisDB(), CachedEmbedderConfig{
+		Model:       conf.Model,
+		Dimension:   dimension,
+		BatchSize:   conf.BatchSize,
+		Concurrency: conf.Concurrency,
+		CacheTTL:    ttl,
+	})
+	return nil
 }
 
-func GetEmbedding() *openai.Embedder {
+// GetEmbedding 获取带缓存的嵌入器
+func GetEmbedding() embedding.Embedder {
 	return embedder
 }
@@ -0,0 +1,338 @@
+package rag
+
+import (
+	"ai_jianli_go/logs"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	embeddingCacheKey = "embedding_cache:%s:%d:%s" // 模型:维度:内容哈希
+	embeddingUsageKey = "embedding_usage:%d:%s"    // 用户ID:日期
+	embeddingUsageTTL = 90 * 24 * time.Hour
+	embedCallTimeout  = 2 * time.Minute // 合并后的嵌入请求不随发起者取消，以该超时为上限
+)
+
+// 嵌入用量统计字段
+const (
+	UsageTexts     = "texts"      // 请求的文本数
+	UsageCacheHits = "cache_hits" // 命中缓存的文本数
+	UsageAPITexts  = "api_texts"  // 实际调用嵌入接口的文本数
+	UsageAPITokens = "api_tokens" // 实际调用嵌入接口的估算token数
+)
+
+type embeddingUserKey struct{}
+
+// WithEmbeddingUser 在ctx中记录发起嵌入请求的用户，用于按用户统计用量
+func WithEmbeddingUser(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, embeddingUserKey{}, userID)
+}
+
+func embeddingUser(ctx context.Context) uint {
+	userID, _ := ctx.Value(embeddingUserKey{}).(uint)
+	return userID
+}
+
+// CachedEmbedderConfig 嵌入缓存配置
+type CachedEmbedderConfig struct {
+	Model       string        // 模型名称，作为缓存键的一部分，换模型后不会命中旧缓存
+	Dimension   int           // 向量维度，作为缓存键的一部分
+	BatchSize   int           // 每次请求嵌入接口的文本数
+	Concurrency int           // 同时进行的嵌入接口请求数
+	CacheTTL    time.Duration // 缓存有效期，0表示不过期
+}
+
+// CachedEmbedder 带缓存的嵌入器：按内容哈希在Redis中缓存向量，相同文本的并发请求合并为一次，
+// 未命中的文本按批次并发请求嵌入接口，并按用户统计用量。client为nil时不缓存也不统计
+type CachedEmbedder struct {
+	base   embedding.Embedder
+	client *redis.Client
+	conf   CachedEmbedderConfig
+	sem    chan struct{}
+
+	mu       sync.Mutex
+	inflight map[string]*embedCall
+}
+
+// embedCall 一个正在请求嵌入接口的文本，其他请求相同文本的调用等待其结果
+type embedCall struct {
+	done chan struct{}
+	vec  []float64
+	err  error
+}
+
+func NewCachedEmbedder(base embedding.Embedder, client *redis.Client, conf CachedEmbedderConfig) *CachedEmbedder {
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 10
+	}
+	if conf.Concurrency <= 0 {
+		conf.Concurrency = 4
+	}
+	return &CachedEmbedder{
+		base:     base,
+		client:   client,
+		conf:     conf,
+		sem:      make(chan struct{}, conf.Concurrency),
+		inflight: make(map[string]*embedCall),
+	}
+}
+
+func (e *CachedEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	if len(texts) == 0 {
+		return [][]float64{}, nil
+	}
+
+	// 同一次请求中的重复文本只计算一次
+	hashes := make([]string, len(texts))
+	textOf := make(map[string]string, len(texts))
+	var unique []string
+	for i, text := range texts {
+		h := textHash(text)
+		hashes[i] = h
+		if _, ok := textOf[h]; !ok {
+			textOf[h] = text
+			unique = append(unique, h)
+		}
+	}
+
+	vectors := e.getCached(ctx, unique)
+	hits := len(vectors)
+
+	// 未命中缓存的文本：已有相同文本在请求中时等待其结果，否则由本次调用请求
+	var owned []string
+	waiting := make(map[string]*embedCall)
+	e.mu.Lock()
+	for _, h := range unique {
+		if _, ok := vectors[h]; ok {
+			continue
+		}
+		if call, ok := e.inflight[h]; ok {
+			waiting[h] = call
+			continue
+		}
+		call := &embedCall{done: make(chan struct{})}
+		e.inflight[h] = call
+		waiting[h] = call
+		owned = append(owned, h)
+	}
+	e.mu.Unlock()
+
+	e.embedOwned(ctx, owned, textOf, opts)
+
+	for h, call := range waiting {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		vectors[h] = call.vec
+	}
+
+	ownedTexts := make([]string, len(owned))
+	for i, h := range owned {
+		ownedTexts[i] = textOf[h]
+	}
+	e.recordUsage(ctx, len(texts), hits, ownedTexts)
+
+	result := make([][]float64, len(texts))
+	for i, h := range hashes {
+		result[i] = vectors[h]
+	}
+	return result, nil
+}
+
+// embedOwned 在后台按批次并发请求嵌入接口，完成后写入缓存并通知等待的调用。
+// 其他调用可能在等待这些文本，请求不随发起者的ctx取消，发起者取消后其他调用仍能拿到结果
+func (e *CachedEmbedder) embedOwned(ctx context.Context, owned []string, textOf map[string]string, opts []embedding.Option) {
+	if len(owned) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), embedCallTimeout)
+	var wg sync.WaitGroup
+	for start := 0; start < len(owned); start += e.conf.BatchSize {
+		batch := owned[start:min(start+e.conf.BatchSize, len(owned))]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vecs, err := e.embedBatch(ctx, batch, textOf, opts)
+			if err == nil {
+				e.setCached(ctx, batch, vecs)
+			}
+
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			for i, h := range batch {
+				call := e.inflight[h]
+				if err != nil {
+					call.err = err
+				} else {
+					call.vec = vecs[i]
+				}
+				delete(e.inflight, h)
+				close(call.done)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+}
+
+func (e *CachedEmbedder) embedBatch(ctx context.Context, batch []string, textOf map[string]string, opts []embedding.Option) ([][]float64, error) {
+	select {
+	case e.sem <- struct{}{}:
+		defer func() { <-e.sem }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	texts := make([]string, len(batch))
+	for i, h := range batch {
+		texts[i] = textOf[h]
+	}
+	vecs, err := e.base.EmbedStrings(ctx, texts, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) != len(texts) {
+		return nil, fmt.Errorf("embedding returned %d vectors for %d texts", len(vecs), len(texts))
+	}
+	return vecs, nil
+}
+
+func (e *CachedEmbedder) cacheKey(hash string) string {
+	return fmt.Sprintf(embeddingCacheKey, e.conf.Model, e.conf.Dimension, hash)
+}
+
+// getCached 批量读取缓存，读取失败时视为未命中
+func (e *CachedEmbedder) getCached(ctx context.Context, hashes []string) map[string][]float64 {
+	vectors := make(map[string][]float64, len(hashes))
+	if e.client == nil || len(hashes) == 0 {
+		return vectors
+	}
+	keys := make([]string, len(hashes))
+	for i, h := range hashes {
+		keys[i] = e.cacheKey(h)
+	}
+	values, err := e.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		logs.SugarLogger.Errorf("读取嵌入缓存失败: %v", err)
+		return vectors
+	}
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			if vec := bytesToVector([]byte(s)); len(vec) > 0 {
+				vectors[hashes[i]] = vec
+			}
+		}
+	}
+	return vectors
+}
+
+func (e *CachedEmbedder) setCached(ctx context.Context, hashes []string, vecs [][]float64) {
+	if e.client == nil {
+		return
+	}
+	pipe := e.client.Pipeline()
+	for i, h := range hashes {
+		pipe.Set(ctx, e.cacheKey(h), vectorToBytes(vecs[i]), e.conf.CacheTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logs.SugarLogger.Errorf("写入嵌入缓存失败: %v", err)
+	}
+}
+
+// recordUsage 按用户和日期累计嵌入用量
+func (e *CachedEmbedder) recordUsage(ctx context.Context, texts, hits int, apiTexts []string) {
+	if e.client == nil {
+		return
+	}
+	tokens := 0
+	for _, text := range apiTexts {
+		tokens += estimateTokens(text)
+	}
+
+	key := fmt.Sprintf(embeddingUsageKey, embeddingUser(ctx), time.Now().Format(time.DateOnly))
+	pipe := e.client.Pipeline()
+	pipe.HIncrBy(ctx, key, UsageTexts, int64(texts))
+	pipe.HIncrBy(ctx, key, UsageCacheHits, int64(hits))
+	pipe.HIncrBy(ctx, key, UsageAPITexts, int64(len(apiTexts)))
+	pipe.HIncrBy(ctx, key, UsageAPITokens, int64(tokens))
+	pipe.Expire(ctx, key, embeddingUsageTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logs.SugarLogger.Errorf("记录嵌入用量失败: %v", err)
+	}
+}
+
+// EmbeddingUsage 某天的嵌入用量
+type EmbeddingUsage struct {
+	Date      string `json:"date"`
+	Texts     int64  `json:"texts"`      // 请求的文本数
+	CacheHits int64  `json:"cache_hits"` // 命中缓存的文本数
+	APITexts  int64  `json:"api_texts"`  // 实际调用嵌入接口的文本数
+	APITokens int64  `json:"api_tokens"` // 实际调用嵌入接口的估算token数
+}
+
+// GetEmbeddingUsage 获取用户最近days天的嵌入用量，按日期倒序
+func GetEmbeddingUsage(ctx context.Context, client *redis.Client, userID uint, days int) ([]EmbeddingUsage, error) {
+	now := time.Now()
+	usages := make([]EmbeddingUsage, 0, days)
+	for i := 0; i < days; i++ {
+		date := now.AddDate(0, 0, -i).Format(time.DateOnly)
+		fields, err := client.HGetAll(ctx, fmt.Sprintf(embeddingUsageKey, userID, date)).Result()
+		if err != nil {
+			return nil, err
+		}
+		parse := func(field string) int64 {
+			n, _ := strconv.ParseInt(fields[field], 10, 64)
+			return n
+		}
+		usages = append(usages, EmbeddingUsage{
+			Date:      date,
+			Texts:     parse(UsageTexts),
+			CacheHits: parse(UsageCacheHits),
+			APITexts:  parse(UsageAPITexts),
+			APITokens: parse(UsageAPITokens),
+		})
+	}
+	return usages, nil
+}
+
+func textHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// vectorToBytes 把向量编码为FLOAT32小端字节，与索引的向量类型一致
+func vectorToBytes(vec []float64) []byte {
+	buf := make([]byte, 4*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(v)))
+	}
+	return buf
+}
+
+func bytesToVector(buf []byte) []float64 {
+	if len(buf)%4 != 0 {
+		return nil
+	}
+	vec := make([]float64, len(buf)/4)
+	for i := range vec {
+		vec[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:])))
+	}
+	return vec
+}
@@ -0,0 +1,146 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+// fakeEmbedder 向量为文本的字节长度，记录调用次数和每次的文本数
+type fakeEmbedder struct {
+	mu      sync.Mutex
+	batches []int
+	texts   atomic.Int64
+	delay   time.Duration
+	err     error
+}
+
+func (f *fakeEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	time.Sleep(f.delay)
+	f.mu.Lock()
+	f.batches = append(f.batches, len(texts))
+	f.mu.Unlock()
+	f.texts.Add(int64(len(texts)))
+	if f.err != nil {
+		return nil, f.err
+	}
+	vecs := make([][]float64, len(texts))
+	for i, text := range texts {
+		vecs[i] = []float64{float64(len(text))}
+	}
+	return vecs, nil
+}
+
+func TestCachedEmbedderBatchAndDedupe(t *testing.T) {
+	base := &fakeEmbedder{}
+	emb := NewCachedEmbedder(base, nil, CachedEmbedderConfig{BatchSize: 2, Concurrency: 2})
+
+	texts := []string{"a", "bb", "a", "ccc", "dddd", "bb", "eeeee"}
+	vecs, err := emb.EmbedStrings(context.Background(), texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, text := range texts {
+		if len(vecs[i]) != 1 || vecs[i][0] != float64(len(text)) {
+			t.Errorf("vector %d = %v, want [%d]", i, vecs[i], len(text))
+		}
+	}
+	// 5个不同文本，每批2个
+	if got := base.texts.Load(); got != 5 {
+		t.Errorf("embedded %d texts, want 5", got)
+	}
+	for _, n := range base.batches {
+		if n > 2 {
+			t.Errorf("batch size %d exceeds 2", n)
+		}
+	}
+	if len(base.batches) != 3 {
+		t.Errorf("got %d batches, want 3", len(base.batches))
+	}
+}
+
+func TestCachedEmbedderCoalesce(t *testing.T) {
+	base := &fakeEmbedder{delay: 50 * time.Millisecond}
+	emb := NewCachedEmbedder(base, nil, CachedEmbedderConfig{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vecs, err := emb.EmbedStrings(context.Background(), []string{"相同的问题"})
+			if err != nil || len(vecs) != 1 {
+				t.Errorf("unexpected result %v, %v", vecs, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := base.texts.Load(); got != 1 {
+		t.Errorf("concurrent identical texts embedded %d times, want 1", got)
+	}
+}
+
+// 发起请求的调用取消后，等待相同文本的调用仍能拿到结果
+func TestCachedEmbedderOwnerCancel(t *testing.T) {
+	base := &fakeEmbedder{delay: 50 * time.Millisecond}
+	emb := NewCachedEmbedder(base, nil, CachedEmbedderConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ownerErr := make(chan error, 1)
+	go func() {
+		_, err := emb.EmbedStrings(ctx, []string{"相同的问题"})
+		ownerErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	waiter := make(chan [][]float64, 1)
+	go func() {
+		vecs, err := emb.EmbedStrings(context.Background(), []string{"相同的问题"})
+		if err != nil {
+			t.Errorf("waiter got error %v after owner cancelled", err)
+		}
+		waiter <- vecs
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if err := <-ownerErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("owner error = %v, want context.Canceled", err)
+	}
+	if vecs := <-waiter; len(vecs) != 1 || vecs[0][0] != float64(len("相同的问题")) {
+		t.Errorf("waiter vectors = %v", vecs)
+	}
+	if got := base.texts.Load(); got != 1 {
+		t.Errorf("embedded %d times, want 1", got)
+	}
+}
+
+func TestCachedEmbedderError(t *testing.T) {
+	base := &fakeEmbedder{err: errors.New("quota exceeded")}
+	emb := NewCachedEmbedder(base, nil, CachedEmbedderConfig{})
+
+	if _, err := emb.EmbedStrings(context.Background(), []string{"x"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(emb.inflight) != 0 {
+		t.Errorf("inflight calls not cleaned up: %d", len(emb.inflight))
+	}
+}
+
+func TestVectorBytesRoundTrip(t *testing.T) {
+	vec := []float64{0.5, -1.25, 3}
+	got := bytesToVector(vectorToBytes(vec))
+	if len(got) != len(vec) {
+		t.Fatalf("got %v", got)
+	}
+	for i := range vec {
+		if got[i] != vec[i] {
+			t.Errorf("got %v, want %v", got, vec)
+		}
+	}
+}
@@ -2,10 +2,11 @@ package rag
 
 import (
 	"ai_jianli_go/component"
+	"ai_jianli_go/config"
 	"context"
 	"fmt"
 
-	"github.com/cloudwego/eino-ext/components/embedding/openai"
+	"github.com/cloudwego/eino/components/embedding"
 
 	ri "github.com/cloudwego/eino-ext/components/indexer/redis"
 	"github.com/cloudwego/eino/schema"
@@ -14,7 +15,7 @@ import (
 
 var indexer *ri.Indexer
 
-func initIndexer(ctx context.Context, emb *openai.Embedder) (err error) {
+func initIndexer(ctx context.Context, emb embedding.Embedder) (err error) {
 	// redis
 
 	client := component.GetRedisDB()
@@ -67,9 +68,9 @@ func createIndex(ctx context.Context, client *redis.Client) {
 				// FLAT index: https://redis.io/docs/latest/develop/interact/search-and-query/advanced-concepts/vectors/#flat-index
 				// Choose the FLAT index when you have small datasets (< 1M vectors) or when perfect search accuracy is more important than search latency.
 				FlatOptions: &redis.FTFlatOptions{
-					Type:           "FLOAT32", // BFLOAT16 / FLOAT16 / FLOAT32 / FLOAT64. BFLOAT16 and FLOAT16 require v2.10 or later.
-					Dim:            dimension, // keeps same with dimensions of Embedding
-					DistanceMetric: "COSINE",  // L2 / IP / COSINE
+					Type:           "FLOAT32",                             // BFLOAT16 / FLOAT16 / FLOAT32 / FLOAT64. BFLOAT16 and FLOAT16 require v2.10 or later.
+					Dim:            config.GetEmbeddingConfig().Dimension, // keeps same with dimensions of Embedding
+					DistanceMetric: "COSINE",                              // L2 / IP / COSINE
 				},
 				// HNSW index: https://redis.io/docs/latest/develop/interact/search-and-query/advanced-concepts/vectors/#hnsw-index
 				// HNSW, or hierarchical navigable small world, is an approximate nearest neighbors algorithm that uses a multi-layered graph to make vector search more scalable.
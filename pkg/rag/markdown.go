@@ -0,0 +1,327 @@
+package rag
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 分块大小的计量单位
+const (
+	sizeUnitRune  = "rune"  // 按字符数计算
+	sizeUnitToken = "token" // 按估算的token数计算
+)
+
+const (
+	defaultChunkSize    = 1000
+	defaultChunkOverlap = 100
+)
+
+// markdown内容块类型
+const (
+	mdBlockText    = "text"
+	mdBlockHeading = "heading"
+	mdBlockCode    = "code"  // 围栏代码块，不可拆分
+	mdBlockTable   = "table" // 表格，不可拆分
+)
+
+var (
+	mdHeadingRe        = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?\s*$`)
+	mdTableSeparatorRe = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+type mdBlock struct {
+	kind  string
+	level int    // 标题级别
+	title string // 标题文本
+	text  string // 原始markdown文本
+}
+
+// markdownChunk Markdown分块结果
+type markdownChunk struct {
+	HeadingPath []string
+	Content     string
+}
+
+// markdownSplitter 按章节层级切分Markdown，代码块和表格不会被拆开
+type markdownSplitter struct {
+	chunkSize int
+	overlap   int
+	sizeUnit  string
+}
+
+func newMarkdownSplitter(chunkSize, overlap int, sizeUnit string) *markdownSplitter {
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	if overlap < 0 || overlap >= chunkSize {
+		overlap = 0
+	}
+	if sizeUnit != sizeUnitToken {
+		sizeUnit = sizeUnitRune
+	}
+	return &markdownSplitter{chunkSize: chunkSize, overlap: overlap, sizeUnit: sizeUnit}
+}
+
+// size 按配置的计量单位计算文本大小
+func (s *markdownSplitter) size(text string) int {
+	if s.sizeUnit == sizeUnitToken {
+		return estimateTokens(text)
+	}
+	return utf8.RuneCountInString(text)
+}
+
+// Split 切分Markdown文本
+func (s *markdownSplitter) Split(content string) []markdownChunk {
+	var (
+		chunks   []markdownChunk
+		headings []mdBlock
+		section  []mdBlock
+	)
+
+	flush := func() {
+		// 只有标题没有正文的章节跳过，标题已包含在下级章节的标题路径中
+		if len(section) == 0 || (len(section) == 1 && section[0].kind == mdBlockHeading) {
+			section = nil
+			return
+		}
+		path := make([]string, len(headings))
+		for i, h := range headings {
+			path[i] = h.title
+		}
+		for _, c := range s.packBlocks(section) {
+			chunks = append(chunks, markdownChunk{HeadingPath: path, Content: c})
+		}
+		section = nil
+	}
+
+	for _, b := range parseMarkdownBlocks(content) {
+		if b.kind == mdBlockHeading {
+			flush()
+			for len(headings) > 0 && headings[len(headings)-1].level >= b.level {
+				headings = headings[:len(headings)-1]
+			}
+			headings = append(headings, b)
+		}
+		section = append(section, b)
+	}
+	flush()
+
+	return chunks
+}
+
+// packBlocks 把一个章节的内容块合并为不超过chunkSize的分块，相邻分块之间保留overlap大小的重叠
+func (s *markdownSplitter) packBlocks(blocks []mdBlock) []string {
+	// 超长的普通文本先按句子拆开，代码块和表格保持完整
+	var pieces []mdBlock
+	for _, b := range blocks {
+		if b.kind == mdBlockCode || b.kind == mdBlockTable || s.size(b.text) <= s.chunkSize {
+			pieces = append(pieces, b)
+			continue
+		}
+		for _, part := range splitSentences(b.text, s.chunkSize, s.size) {
+			pieces = append(pieces, mdBlock{kind: mdBlockText, text: part})
+		}
+	}
+
+	var (
+		chunks  []string
+		current []string
+		curSize int
+	)
+	emit := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, strings.Join(current, "\n\n"))
+		}
+		current = nil
+		curSize = 0
+	}
+
+	var last mdBlock
+	for _, p := range pieces {
+		size := s.size(p.text)
+		if len(current) > 0 && curSize+size > s.chunkSize {
+			emit()
+			// 上一块末尾是普通文本时，取其结尾作为重叠内容
+			if overlap := s.overlapText(last); overlap != "" && s.size(overlap)+size <= s.chunkSize {
+				current = append(current, overlap)
+				curSize = s.size(overlap)
+			}
+		}
+		current = append(current, p.text)
+		curSize += size
+		last = p
+	}
+	emit()
+
+	return chunks
+}
+
+// overlapText 取文本块结尾不超过overlap大小的内容，尽量从句子边界开始
+func (s *markdownSplitter) overlapText(b mdBlock) string {
+	if s.overlap == 0 || b.kind != mdBlockText {
+		return ""
+	}
+	runes := []rune(b.text)
+	start := len(runes)
+	for start > 0 && s.size(string(runes[start-1:])) <= s.overlap {
+		start--
+	}
+	for i := start; i < len(runes); i++ {
+		if isChunkBoundary(runes[i]) {
+			if tail := strings.TrimSpace(string(runes[i+1:])); tail != "" {
+				return tail
+			}
+			break
+		}
+	}
+	return strings.TrimSpace(string(runes[start:]))
+}
+
+// parseMarkdownBlocks 把Markdown解析为标题、段落、代码块和表格
+func parseMarkdownBlocks(content string) []mdBlock {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	var (
+		blocks    []mdBlock
+		paragraph []string
+	)
+	flushParagraph := func() {
+		if text := strings.TrimSpace(strings.Join(paragraph, "\n")); text != "" {
+			blocks = append(blocks, mdBlock{kind: mdBlockText, text: text})
+		}
+		paragraph = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		// 围栏代码块，未闭合时一直到文档结尾
+		if fence := fenceMarker(trimmed); fence != "" {
+			flushParagraph()
+			code := []string{line}
+			for i+1 < len(lines) {
+				i++
+				code = append(code, lines[i])
+				if isFenceClose(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+			}
+			blocks = append(blocks, mdBlock{kind: mdBlockCode, text: strings.Join(code, "\n")})
+			continue
+		}
+
+		if m := mdHeadingRe.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			title := strings.TrimSpace(strings.TrimRight(m[2], "#"))
+			blocks = append(blocks, mdBlock{kind: mdBlockHeading, level: len(m[1]), title: title, text: trimmed})
+			continue
+		}
+
+		// 表格：表头行加分隔行，之后连续的含|的行
+		if strings.Contains(line, "|") && i+1 < len(lines) && mdTableSeparatorRe.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-") {
+			flushParagraph()
+			table := []string{line, lines[i+1]}
+			i++
+			for i+1 < len(lines) && strings.Contains(lines[i+1], "|") && strings.TrimSpace(lines[i+1]) != "" {
+				i++
+				table = append(table, lines[i])
+			}
+			blocks = append(blocks, mdBlock{kind: mdBlockTable, text: strings.Join(table, "\n")})
+			continue
+		}
+
+		if trimmed == "" {
+			flushParagraph()
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flushParagraph()
+
+	return blocks
+}
+
+// fenceMarker 返回代码块起始行的围栏标记（```或~~~），不是起始行时返回空
+func fenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := 0
+		for n < len(line) && line[n:n+1] == c {
+			n++
+		}
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+func isFenceClose(line, fence string) bool {
+	return strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == ""
+}
+
+// splitSentences 按句子边界把文本拆成不超过maxSize的片段，单句超长时按字符硬切
+func splitSentences(text string, maxSize int, size func(string) int) []string {
+	var (
+		parts    []string
+		current  strings.Builder
+		sentence []rune
+	)
+	addSentence := func(sen string) {
+		if sen == "" {
+			return
+		}
+		if current.Len() > 0 && size(current.String()+sen) > maxSize {
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+		// 单句超长时按字符硬切，token数不会超过字符数
+		for size(sen) > maxSize {
+			runes := []rune(sen)
+			parts = append(parts, strings.TrimSpace(string(runes[:maxSize])))
+			sen = string(runes[maxSize:])
+		}
+		current.WriteString(sen)
+	}
+
+	for _, r := range text {
+		sentence = append(sentence, r)
+		if isChunkBoundary(r) {
+			addSentence(string(sentence))
+			sentence = sentence[:0]
+		}
+	}
+	addSentence(string(sentence))
+	if tail := strings.TrimSpace(current.String()); tail != "" {
+		parts = append(parts, tail)
+	}
+	return parts
+}
+
+// isChunkBoundary 是否为可以切分的句子边界
+func isChunkBoundary(r rune) bool {
+	switch r {
+	case '.', '!', '?', ';', '\n', ' ', '。', '！', '？', '；':
+		return true
+	}
+	return false
+}
+
+// estimateTokens 粗略估算token数：中文等非拉丁字符每个算1个，连续的字母数字每4个算1个
+func estimateTokens(text string) int {
+	tokens, word := 0, 0
+	for _, r := range text {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			word++
+			continue
+		}
+		tokens += (word + 3) / 4
+		word = 0
+		if !unicode.IsSpace(r) {
+			tokens++
+		}
+	}
+	return tokens + (word+3)/4
+}
@@ -0,0 +1,112 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const testMarkdown = "# Go语言\n\n简介段落。\n\n## 并发\n\ngoroutine是轻量级线程。channel用于通信。\n\n```go\nfunc main() {\n\n\tgo work()\n}\n```\n\n## 对比\n\n| 语言 | 并发模型 |\n| --- | --- |\n| Go | CSP |\n| Java | 线程 |\n\n### 细节\n\n#### 空章节\n\n##### 更深\n\n正文\n"
+
+func TestMarkdownSplitterSections(t *testing.T) {
+	chunks := newMarkdownSplitter(1000, 0, sizeUnitRune).Split(testMarkdown)
+
+	wantPaths := []string{
+		"Go语言",
+		"Go语言 > 并发",
+		"Go语言 > 对比",
+		"Go语言 > 对比 > 细节 > 空章节 > 更深",
+	}
+	if len(chunks) != len(wantPaths) {
+		t.Fatalf("got %d chunks, want %d: %+v", len(chunks), len(wantPaths), chunks)
+	}
+	for i, c := range chunks {
+		if got := strings.Join(c.HeadingPath, headingPathSep); got != wantPaths[i] {
+			t.Errorf("chunk %d heading path = %q, want %q", i, got, wantPaths[i])
+		}
+	}
+	if !strings.Contains(chunks[1].Content, "```go\nfunc main() {\n\n\tgo work()\n}\n```") {
+		t.Errorf("code block should be kept intact: %q", chunks[1].Content)
+	}
+	if !strings.Contains(chunks[2].Content, "| Go | CSP |\n| Java | 线程 |") {
+		t.Errorf("table should be kept intact: %q", chunks[2].Content)
+	}
+}
+
+func TestMarkdownSplitterKeepsCodeBlock(t *testing.T) {
+	code := "```\n" + strings.Repeat("x := 1\n", 30) + "```"
+	content := "## 代码\n\n" + strings.Repeat("说明文字。", 10) + "\n\n" + code + "\n\n" + strings.Repeat("后续文字。", 10)
+
+	chunks := newMarkdownSplitter(80, 10, sizeUnitRune).Split(content)
+	found := false
+	for _, c := range chunks {
+		if strings.Count(c.Content, "```") == 1 {
+			t.Fatalf("code fence split across chunks: %q", c.Content)
+		}
+		if strings.Contains(c.Content, code) {
+			found = true
+		}
+		if strings.Join(c.HeadingPath, headingPathSep) != "代码" {
+			t.Errorf("unexpected heading path %v", c.HeadingPath)
+		}
+	}
+	if !found {
+		t.Fatal("code block not found in any chunk")
+	}
+}
+
+func TestMarkdownSplitterSizeAndOverlap(t *testing.T) {
+	content := "# 标题\n\n" + strings.Repeat("这是一个测试句子。", 50)
+
+	chunks := newMarkdownSplitter(100, 20, sizeUnitRune).Split(content)
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if !utf8.ValidString(c.Content) {
+			t.Fatalf("chunk %d is not valid utf8", i)
+		}
+		if n := utf8.RuneCountInString(c.Content); n > 100 {
+			t.Errorf("chunk %d has %d runes, exceeds 100", i, n)
+		}
+	}
+	// 第二个分块以上一分块结尾的句子开头
+	if !strings.HasPrefix(chunks[1].Content, "这是一个测试句子。") {
+		t.Errorf("expected overlap at start of chunk 1: %q", chunks[1].Content)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	cases := map[string]int{
+		"":               0,
+		"你好":             2,
+		"hello":          2,
+		"hello world":    4,
+		"Go语言, 并发":       6,
+		"goroutine 调度器。": 7,
+	}
+	for text, want := range cases {
+		if got := estimateTokens(text); got != want {
+			t.Errorf("estimateTokens(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestChunkTextMultiByte(t *testing.T) {
+	text := strings.Repeat("中文内容没有标点", 50)
+	chunks := chunkText(text, 30)
+	total := 0
+	for i, c := range chunks {
+		if !utf8.ValidString(c) {
+			t.Fatalf("chunk %d is not valid utf8", i)
+		}
+		n := utf8.RuneCountInString(c)
+		if n > 30 {
+			t.Errorf("chunk %d has %d runes, exceeds 30", i, n)
+		}
+		total += n
+	}
+	if total != utf8.RuneCountInString(text) {
+		t.Errorf("chunks lost content: %d != %d", total, utf8.RuneCountInString(text))
+	}
+}
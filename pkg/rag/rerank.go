@@ -0,0 +1,203 @@
+package rag
+
+import (
+	"ai_jianli_go/component"
+	"ai_jianli_go/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	chatmodel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// 重排分数在文档元数据中的键
+const MetaRerankScore = "rerank_score"
+
+// 送入LLM重排的单个文档最大字符数
+const llmRerankDocRunes = 500
+
+// Reranker 对召回的文档重新打分，返回与docs一一对应的相关性分数(0-1)
+type Reranker interface {
+	Rerank(ctx context.Context, query string, docs []*schema.Document) ([]float64, error)
+}
+
+// GetReranker 根据配置获取重排器，未配置时返回nil
+func GetReranker() Reranker {
+	conf := config.GetRerankConfig()
+	switch conf.Provider {
+	case config.RerankProviderCrossEncoder:
+		return NewCrossEncoderReranker(conf.BaseURL, conf.APIKey, conf.Model)
+	case config.RerankProviderLLM:
+		return NewLLMReranker(component.GetAIComponent().GetFeatureModel(component.FeatureRerank))
+	default:
+		return nil
+	}
+}
+
+// RerankDocuments 使用重排器打分并按分数降序排序，分数写入MetaRerankScore和scoreKey
+func RerankDocuments(ctx context.Context, reranker Reranker, query string, docs []*schema.Document, scoreKey string) ([]*schema.Document, error) {
+	if reranker == nil || len(docs) == 0 {
+		return docs, nil
+	}
+	scores, err := reranker.Rerank(ctx, query, docs)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) != len(docs) {
+		return nil, fmt.Errorf("rerank returned %d scores for %d documents", len(scores), len(docs))
+	}
+
+	for i, doc := range docs {
+		if doc.MetaData == nil {
+			doc.MetaData = make(map[string]any)
+		}
+		doc.MetaData[MetaRerankScore] = scores[i]
+		doc.MetaData[scoreKey] = scores[i]
+	}
+	reranked := make([]*schema.Document, len(docs))
+	copy(reranked, docs)
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].MetaData[MetaRerankScore].(float64) > reranked[j].MetaData[MetaRerankScore].(float64)
+	})
+	return reranked, nil
+}
+
+// CrossEncoderReranker 调用交叉编码器重排服务，兼容 Jina/Cohere/TEI 风格的 /rerank 接口
+type CrossEncoderReranker struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+func NewCrossEncoderReranker(baseURL, apiKey, model string) *CrossEncoderReranker {
+	return &CrossEncoderReranker{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type crossEncoderRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+type crossEncoderResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+func (r *CrossEncoderReranker) Rerank(ctx context.Context, query string, docs []*schema.Document) ([]float64, error) {
+	contents := make([]string, len(docs))
+	for i, doc := range docs {
+		contents[i] = doc.Content
+	}
+	body, err := json.Marshal(crossEncoderRequest{
+		Model:     r.model,
+		Query:     query,
+		Documents: contents,
+		TopN:      len(docs),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+"/rerank", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("rerank request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("rerank request failed: status %d: %s", resp.StatusCode, msg)
+	}
+
+	var result crossEncoderResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode rerank response failed: %w", err)
+	}
+
+	scores := make([]float64, len(docs))
+	for _, item := range result.Results {
+		if item.Index < 0 || item.Index >= len(docs) {
+			return nil, fmt.Errorf("rerank response index %d out of range", item.Index)
+		}
+		scores[item.Index] = item.RelevanceScore
+	}
+	return scores, nil
+}
+
+// LLMReranker 使用大模型为每个文档的相关性打分
+type LLMReranker struct {
+	model chatmodel.BaseChatModel
+}
+
+func NewLLMReranker(model chatmodel.BaseChatModel) *LLMReranker {
+	return &LLMReranker{model: model}
+}
+
+func (r *LLMReranker) Rerank(ctx context.Context, query string, docs []*schema.Document) ([]float64, error) {
+	if r.model == nil {
+		return nil, fmt.Errorf("rerank model not configured")
+	}
+
+	var sb strings.Builder
+	for i, doc := range docs {
+		content := []rune(doc.Content)
+		if len(content) > llmRerankDocRunes {
+			content = content[:llmRerankDocRunes]
+		}
+		fmt.Fprintf(&sb, "[%d]\n%s\n\n", i+1, string(content))
+	}
+
+	messages := []*schema.Message{
+		schema.SystemMessage(
+			"你是检索结果相关性评估专家，需要判断每个文档片段对回答用户问题的帮助程度，给出0-10的分数，10表示完全相关。\n" +
+				"你必须只返回一个JSON数字数组，按文档编号顺序给出分数，数组长度与文档数量一致，不要返回任何其他内容。",
+		),
+		schema.UserMessage(fmt.Sprintf("用户问题：%s\n\n文档片段（共%d个）：\n%s", query, len(docs), sb.String())),
+	}
+	resp, err := r.model.Generate(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("llm rerank failed: %w", err)
+	}
+
+	content := strings.TrimSpace(resp.Content)
+	content, _ = strings.CutPrefix(content, "```json")
+	content, _ = strings.CutPrefix(content, "```")
+	content, _ = strings.CutSuffix(content, "```")
+
+	var scores []float64
+	if err = json.Unmarshal([]byte(strings.TrimSpace(content)), &scores); err != nil {
+		return nil, fmt.Errorf("parse llm rerank scores failed: %w", err)
+	}
+	if len(scores) != len(docs) {
+		return nil, fmt.Errorf("llm rerank returned %d scores for %d documents", len(scores), len(docs))
+	}
+	for i, score := range scores {
+		scores[i] = min(max(score/10, 0), 1)
+	}
+	return scores, nil
+}
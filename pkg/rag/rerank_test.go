@@ -0,0 +1,61 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ai_jianli_go/pkg/llm"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func rerankTestDocs() []*schema.Document {
+	return []*schema.Document{
+		{ID: "a", Content: "无关内容", MetaData: map[string]any{"score": 0.9}},
+		{ID: "b", Content: "goroutine调度"},
+	}
+}
+
+func TestCrossEncoderReranker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rerank" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body crossEncoderRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Query != "调度" || len(body.Documents) != 2 || body.Model != "bge" {
+			t.Errorf("unexpected body %+v", body)
+		}
+		w.Write([]byte(`{"results":[{"index":1,"relevance_score":0.95},{"index":0,"relevance_score":0.1}]}`))
+	}))
+	defer server.Close()
+
+	docs, err := RerankDocuments(context.Background(), NewCrossEncoderReranker(server.URL+"/", "key", "bge"), "调度", rerankTestDocs(), "score")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs[0].ID != "b" || docs[0].MetaData["score"] != 0.95 || docs[1].MetaData[MetaRerankScore] != 0.1 {
+		t.Fatalf("unexpected rerank result: %+v %+v", docs[0], docs[1])
+	}
+}
+
+func TestLLMReranker(t *testing.T) {
+	model := llm.NewFakeChatModel(map[string]string{"用户问题": "```json\n[2, 9]\n```"}, "")
+	docs, err := RerankDocuments(context.Background(), NewLLMReranker(model), "调度", rerankTestDocs(), "score")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs[0].ID != "b" || docs[0].MetaData["score"] != 0.9 || docs[1].MetaData["score"] != 0.2 {
+		t.Fatalf("unexpected rerank result: %+v %+v", docs[0], docs[1])
+	}
+
+	bad := llm.NewFakeChatModel(nil, "[1]")
+	if _, err = NewLLMReranker(bad).Rerank(context.Background(), "调度", rerankTestDocs()); err == nil {
+		t.Fatal("expected error when score count mismatches")
+	}
+}
@@ -6,15 +6,15 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/cloudwego/eino-ext/components/embedding/openai"
 	rr "github.com/cloudwego/eino-ext/components/retriever/redis"
+	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/schema"
 	"github.com/redis/go-redis/v9"
 )
 
 var retriever *rr.Retriever
 
-func initRetriever(ctx context.Context, emb *openai.Embedder) (err error) {
+func initRetriever(ctx context.Context, emb embedding.Embedder) (err error) {
 	// redis
 
 	client := component.GetRedisDB()
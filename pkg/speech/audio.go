@@ -0,0 +1,325 @@
+package speech
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 识别服务要求的音频格式：16k采样率、16bit、单声道PCM
+const (
+	TargetSampleRate = 16000
+	targetBytes      = 2
+)
+
+// 支持的输入音频范围，超出范围的参数多为伪造或损坏的文件头，重采样时会占用大量内存
+const (
+	minSampleRate = 8000
+	maxSampleRate = 192000
+	maxChannels   = 8
+)
+
+// 上传音频的限制。识别服务单次最长识别60秒，文件大小足够容纳60秒96k立体声24bit的WAV，
+// 限制大小避免转换时按采样解码占用过多内存
+const (
+	MaxAudioDuration = 60 * time.Second
+	MaxAudioSize     = 40 << 20
+)
+
+// ErrUnsupportedAudio 不支持的音频格式，需要客户端转为WAV或PCM后上传
+var ErrUnsupportedAudio = errors.New("不支持的音频格式")
+
+// ErrAudioTooLong 音频超过识别服务支持的最长时长
+var ErrAudioTooLong = fmt.Errorf("音频时长超过%d秒", int(MaxAudioDuration/time.Second))
+
+// WAV fmt块中的编码格式
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// PCMFormat PCM音频格式
+type PCMFormat struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int  // 8/16/24/32，8bit为无符号
+	Float         bool // 是否为IEEE浮点采样（32/64bit）
+}
+
+// 识别服务要求的格式
+var TargetFormat = PCMFormat{SampleRate: TargetSampleRate, Channels: 1, BitsPerSample: 16}
+
+// 常见压缩格式的文件头，用于给出明确的错误
+var compressedMagics = []struct {
+	name   string
+	offset int
+	magic  []byte
+}{
+	{"webm/mkv", 0, []byte{0x1A, 0x45, 0xDF, 0xA3}},
+	{"ogg/opus", 0, []byte("OggS")},
+	{"flac", 0, []byte("fLaC")},
+	{"mp3", 0, []byte("ID3")},
+	{"mp4/m4a", 4, []byte("ftyp")},
+	{"amr", 0, []byte("#!AMR")},
+}
+
+// NormalizeAudio 把上传的音频转换为识别服务要求的16k采样率16bit单声道PCM。
+// 支持PCM和IEEE浮点编码的WAV，没有文件头的数据按已是目标格式的PCM处理，压缩格式返回ErrUnsupportedAudio
+func NormalizeAudio(data []byte) ([]byte, error) {
+	if len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE" {
+		format, samples, err := parseWAV(data)
+		if err != nil {
+			return nil, err
+		}
+		if err = format.validate(); err != nil {
+			return nil, err
+		}
+		if format.duration(len(samples)) > MaxAudioDuration {
+			return nil, ErrAudioTooLong
+		}
+		return ConvertPCM(samples, format)
+	}
+	for _, m := range compressedMagics {
+		if len(data) >= m.offset+len(m.magic) && bytes.Equal(data[m.offset:m.offset+len(m.magic)], m.magic) {
+			return nil, fmt.Errorf("%w: %s，请上传WAV或16k采样率16bit单声道PCM音频", ErrUnsupportedAudio, m.name)
+		}
+	}
+	if len(data)%targetBytes != 0 {
+		return nil, fmt.Errorf("%w: 无法识别的音频数据", ErrUnsupportedAudio)
+	}
+	if TargetFormat.duration(len(data)) > MaxAudioDuration {
+		return nil, ErrAudioTooLong
+	}
+	return data, nil
+}
+
+// parseWAV 解析WAV文件头，返回音频格式和data块内容
+func parseWAV(data []byte) (PCMFormat, []byte, error) {
+	var format PCMFormat
+	var hasFormat bool
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := data[pos+8:]
+		// 录音中断的文件data块长度可能不正确，以实际长度为准
+		if size > len(body) || size < 0 {
+			size = len(body)
+		}
+		body = body[:size]
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return format, nil, fmt.Errorf("%w: WAV fmt块不完整", ErrUnsupportedAudio)
+			}
+			code := binary.LittleEndian.Uint16(body[0:2])
+			format.Channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			format.SampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+			format.BitsPerSample = int(binary.LittleEndian.Uint16(body[14:16]))
+			// WAVE_FORMAT_EXTENSIBLE的实际编码在子格式GUID的前两个字节
+			if code == wavFormatExtensible && size >= 26 {
+				code = binary.LittleEndian.Uint16(body[24:26])
+			}
+			switch code {
+			case wavFormatPCM:
+			case wavFormatFloat:
+				format.Float = true
+			default:
+				return format, nil, fmt.Errorf("%w: WAV编码格式0x%04X，仅支持PCM和IEEE浮点", ErrUnsupportedAudio, code)
+			}
+			hasFormat = true
+		case "data":
+			if !hasFormat {
+				return format, nil, fmt.Errorf("%w: WAV缺少fmt块", ErrUnsupportedAudio)
+			}
+			return format, body, nil
+		}
+		// 块按偶数字节对齐
+		pos += 8 + size + size%2
+	}
+	return format, nil, fmt.Errorf("%w: WAV缺少data块", ErrUnsupportedAudio)
+}
+
+// ConvertPCM 把PCM音频转换为16k采样率16bit单声道：先混为单声道，再重采样，最后量化为16bit
+func ConvertPCM(data []byte, format PCMFormat) ([]byte, error) {
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+	if format == TargetFormat {
+		return data[:len(data)-len(data)%targetBytes], nil
+	}
+
+	return quantize(resample(downmix(data, format), format.SampleRate, TargetSampleRate)), nil
+}
+
+// PCMConverter 流式转换PCM音频，保存跨消息的不完整采样帧和重采样的位置，
+// 分段转换的结果与整段转换一致，消息边界不会造成声道错位和采样不连续
+type PCMConverter struct {
+	format  PCMFormat
+	ratio   float64
+	pending []byte    // 不足一帧的剩余字节
+	input   []float64 // 还会被后续输出采样用到的输入采样
+	offset  int       // input[0]在整段输入中的序号
+	next    int       // 下一个输出采样的序号
+	total   int       // 已输入的采样帧数
+}
+
+// NewPCMConverter 创建把format格式的音频流转换为识别服务要求格式的转换器
+func NewPCMConverter(format PCMFormat) (*PCMConverter, error) {
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+	return &PCMConverter{format: format, ratio: float64(format.SampleRate) / TargetSampleRate}, nil
+}
+
+// Write 转换一段音频，返回已能确定的输出，未用完的输入留到下次
+func (c *PCMConverter) Write(data []byte) []byte {
+	frame := c.format.BitsPerSample / 8 * c.format.Channels
+	data = append(c.pending, data...)
+	n := len(data) - len(data)%frame
+	c.pending = append([]byte(nil), data[n:]...)
+	c.total += n / frame
+	if c.format == TargetFormat {
+		return data[:n]
+	}
+
+	c.input = append(c.input, downmix(data[:n], c.format)...)
+	end := c.offset + len(c.input)
+	var out []float64
+	for {
+		pos := float64(c.next) * c.ratio
+		j := int(pos)
+		if c.ratio > 1 {
+			// 降采样需要输出采样覆盖的整个输入区间
+			stop := int(pos + c.ratio)
+			if stop > end {
+				break
+			}
+			var sum float64
+			for _, v := range c.input[j-c.offset : stop-c.offset] {
+				sum += v
+			}
+			out = append(out, sum/float64(max(stop-j, 1)))
+		} else {
+			// 升采样插值需要后一个采样，采样率不变时不需要
+			need := j + 1
+			if c.ratio == 1 {
+				need = j
+			}
+			if need >= end {
+				break
+			}
+			frac := pos - float64(j)
+			v := c.input[j-c.offset]
+			if frac > 0 {
+				v = v*(1-frac) + c.input[j+1-c.offset]*frac
+			}
+			out = append(out, v)
+		}
+		c.next++
+	}
+	// 丢弃之后不再用到的输入采样
+	if used := min(int(float64(c.next)*c.ratio), end) - c.offset; used > 0 {
+		c.input = append(c.input[:0], c.input[used:]...)
+		c.offset += used
+	}
+	return quantize(out)
+}
+
+// Duration 已输入音频的时长
+func (c *PCMConverter) Duration() time.Duration {
+	return time.Duration(c.total) * time.Second / time.Duration(c.format.SampleRate)
+}
+
+// quantize 把[-1, 1]范围的采样量化为16bit PCM
+func quantize(samples []float64) []byte {
+	out := make([]byte, len(samples)*targetBytes)
+	for i, v := range samples {
+		v = math.Max(-1, math.Min(1, v))
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(int16(math.Round(v*math.MaxInt16))))
+	}
+	return out
+}
+
+func (f PCMFormat) validate() error {
+	if f.SampleRate < minSampleRate || f.SampleRate > maxSampleRate || f.Channels <= 0 || f.Channels > maxChannels {
+		return fmt.Errorf("%w: 采样率%d，声道数%d", ErrUnsupportedAudio, f.SampleRate, f.Channels)
+	}
+	switch {
+	case f.Float && (f.BitsPerSample == 32 || f.BitsPerSample == 64):
+	case !f.Float && (f.BitsPerSample == 8 || f.BitsPerSample == 16 || f.BitsPerSample == 24 || f.BitsPerSample == 32):
+	default:
+		return fmt.Errorf("%w: %dbit采样", ErrUnsupportedAudio, f.BitsPerSample)
+	}
+	return nil
+}
+
+// duration n字节音频的时长
+func (f PCMFormat) duration(n int) time.Duration {
+	frame := f.BitsPerSample / 8 * f.Channels
+	return time.Duration(n/frame) * time.Second / time.Duration(f.SampleRate)
+}
+
+// downmix 解码采样并把各声道取平均混为单声道，采样值范围[-1, 1]
+func downmix(data []byte, format PCMFormat) []float64 {
+	width := format.BitsPerSample / 8
+	frame := width * format.Channels
+	samples := make([]float64, len(data)/frame)
+	for i := range samples {
+		var sum float64
+		for c := 0; c < format.Channels; c++ {
+			sum += decodeSample(data[i*frame+c*width:], width, format.Float)
+		}
+		samples[i] = sum / float64(format.Channels)
+	}
+	return samples
+}
+
+func decodeSample(b []byte, width int, float bool) float64 {
+	switch {
+	case float && width == 4:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	case float:
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	case width == 1:
+		// 8bit PCM为无符号
+		return (float64(b[0]) - 128) / 128
+	case width == 2:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	case width == 3:
+		v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+		return float64(v) / (1 << 23)
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+	}
+}
+
+// resample 重采样。降采样时对每个输出采样覆盖的输入区间取平均，减少混叠；升采样时线性插值
+func resample(samples []float64, from, to int) []float64 {
+	if from == to || len(samples) == 0 {
+		return samples
+	}
+	ratio := float64(from) / float64(to)
+	out := make([]float64, int(float64(len(samples))/ratio))
+	for i := range out {
+		pos := float64(i) * ratio
+		if ratio > 1 {
+			start, end := int(pos), min(int(pos+ratio), len(samples))
+			var sum float64
+			for _, v := range samples[start:end] {
+				sum += v
+			}
+			out[i] = sum / float64(max(end-start, 1))
+			continue
+		}
+		j := int(pos)
+		frac := pos - float64(j)
+		next := samples[min(j+1, len(samples)-1)]
+		out[i] = samples[j]*(1-frac) + next*frac
+	}
+	return out
+}
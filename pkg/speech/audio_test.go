@@ -0,0 +1,153 @@
+package speech
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// buildWAV 构造WAV文件，samples为交错排列的各声道采样
+func buildWAV(code uint16, rate, channels, bits int, data []byte) []byte {
+	buf := []byte("RIFF\x00\x00\x00\x00WAVE")
+	fmtChunk := make([]byte, 16)
+	binary.LittleEndian.PutUint16(fmtChunk[0:], code)
+	binary.LittleEndian.PutUint16(fmtChunk[2:], uint16(channels))
+	binary.LittleEndian.PutUint32(fmtChunk[4:], uint32(rate))
+	binary.LittleEndian.PutUint32(fmtChunk[8:], uint32(rate*channels*bits/8))
+	binary.LittleEndian.PutUint16(fmtChunk[12:], uint16(channels*bits/8))
+	binary.LittleEndian.PutUint16(fmtChunk[14:], uint16(bits))
+	buf = appendChunk(buf, "fmt ", fmtChunk)
+	// 非音频块应被跳过，奇数长度需要补齐
+	buf = appendChunk(buf, "LIST", []byte("abc"))
+	return appendChunk(buf, "data", data)
+}
+
+func appendChunk(buf []byte, id string, body []byte) []byte {
+	buf = append(buf, id...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(body)))
+	buf = append(buf, body...)
+	if len(body)%2 == 1 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+func pcm16(samples []int16) []byte {
+	out := make([]byte, 0, len(samples)*2)
+	for _, v := range samples {
+		out = binary.LittleEndian.AppendUint16(out, uint16(v))
+	}
+	return out
+}
+
+func TestNormalizeStereo48k(t *testing.T) {
+	// 48k立体声混为单声道（取平均）并降采样到16k
+	var samples []int16
+	for i := 0; i < 4800; i++ {
+		samples = append(samples, 1000, 3000)
+	}
+	out, err := NormalizeAudio(buildWAV(wavFormatPCM, 48000, 2, 16, pcm16(samples)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out) != 1600*2 {
+		t.Fatalf("expected 1600 samples at 16k, got %d", len(out)/2)
+	}
+	if v := int16(binary.LittleEndian.Uint16(out[100:])); v < 1995 || v > 2005 {
+		t.Fatalf("downmix mismatch: %d", v)
+	}
+}
+
+func TestNormalizeFloatUpsample(t *testing.T) {
+	var data []byte
+	for i := 0; i < 800; i++ {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(0.5))
+	}
+	out, err := NormalizeAudio(buildWAV(wavFormatFloat, 8000, 1, 32, data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out) != 1600*2 {
+		t.Fatalf("expected 1600 samples at 16k, got %d", len(out)/2)
+	}
+	if v := int16(binary.LittleEndian.Uint16(out[10:])); v != 16384 {
+		t.Fatalf("float conversion mismatch: %d", v)
+	}
+}
+
+func TestNormalizePassThrough(t *testing.T) {
+	raw := pcm16([]int16{1, 2, 3})
+	out, err := NormalizeAudio(raw)
+	if err != nil || len(out) != len(raw) {
+		t.Fatalf("raw pcm should pass through: %v", err)
+	}
+	out, err = NormalizeAudio(buildWAV(wavFormatPCM, 16000, 1, 16, raw))
+	if err != nil || string(out) != string(raw) {
+		t.Fatalf("16k mono wav should only strip header: %v", err)
+	}
+}
+
+func TestNormalizeUnsupported(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"webm":   {0x1A, 0x45, 0xDF, 0xA3, 0x01, 0x02},
+		"ogg":    []byte("OggS\x00\x02"),
+		"alaw":   buildWAV(6, 8000, 1, 8, []byte{1, 2}),
+		"12bit":  buildWAV(wavFormatPCM, 8000, 1, 12, []byte{1, 2}),
+		"4k":     buildWAV(wavFormatPCM, 4000, 1, 16, []byte{1, 2}),
+		"384k":   buildWAV(wavFormatPCM, 384000, 1, 16, []byte{1, 2}),
+		"16ch":   buildWAV(wavFormatPCM, 16000, 16, 16, make([]byte, 32)),
+		"nodata": []byte("RIFF\x00\x00\x00\x00WAVE"),
+	} {
+		if _, err := NormalizeAudio(data); !errors.Is(err, ErrUnsupportedAudio) {
+			t.Fatalf("%s: expected ErrUnsupportedAudio, got %v", name, err)
+		}
+	}
+}
+
+func TestNormalizeTooLong(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"wav": buildWAV(wavFormatPCM, 8000, 1, 8, make([]byte, 61*8000)),
+		"pcm": make([]byte, 61*TargetSampleRate*targetBytes),
+	} {
+		if _, err := NormalizeAudio(data); !errors.Is(err, ErrAudioTooLong) {
+			t.Fatalf("%s: expected ErrAudioTooLong, got %v", name, err)
+		}
+	}
+	if _, err := NormalizeAudio(make([]byte, 60*TargetSampleRate*targetBytes)); err != nil {
+		t.Fatalf("60s audio should be accepted: %v", err)
+	}
+}
+
+func TestPCMConverterChunks(t *testing.T) {
+	// 立体声24bit，按不对齐采样帧的长度分段发送，结果应与整段转换一致
+	for _, rate := range []int{44100, 16000, 8000} {
+		format := PCMFormat{SampleRate: rate, Channels: 2, BitsPerSample: 24}
+		data := make([]byte, rate/10*6)
+		for i := 0; i+3 <= len(data); i += 3 {
+			v := int32(math.Sin(float64(i)/50) * (1 << 22))
+			data[i], data[i+1], data[i+2] = byte(v), byte(v>>8), byte(v>>16)
+		}
+		want, err := ConvertPCM(data, format)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		conv, err := NewPCMConverter(format)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got []byte
+		for pos, size := 0, 1; pos < len(data); pos, size = pos+size, size%997+7 {
+			got = append(got, conv.Write(data[pos:min(pos+size, len(data))])...)
+		}
+		// 流式转换末尾等待后续输入的采样还没有输出，8k升采样时最多2个
+		if len(want)-len(got) > 2*targetBytes || string(got) != string(want[:len(got)]) {
+			t.Fatalf("%d: chunked conversion mismatch, got %d bytes, want %d", rate, len(got), len(want))
+		}
+		if conv.Duration() != 100*time.Millisecond {
+			t.Fatalf("%d: duration mismatch: %v", rate, conv.Duration())
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package speech
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// FakeStep 模拟服务脚本中的一步，收到客户端第AfterFrame帧（从1开始）后执行，
+// AfterFrame为0时在收到最后一帧后执行
+type FakeStep struct {
+	AfterFrame int
+	Response   *RespData // 返回给客户端的识别结果
+	Drop       bool      // 不返回结果，直接断开连接
+}
+
+// After 设置在收到第frame帧后执行
+func (s FakeStep) After(frame int) FakeStep {
+	s.AfterFrame = frame
+	return s
+}
+
+// FakeResult 返回第sn段识别结果，final为true时为最终结果
+func FakeResult(sn int, text string, final bool) FakeStep {
+	return FakeStep{Response: fakeResp(Result{Sn: sn, Ws: fakeWords(text), Ls: final}, final)}
+}
+
+// FakeReplace 返回第sn段识别结果，并替换第from到to段之前的结果（pgs=rpl）
+func FakeReplace(sn, from, to int, text string, final bool) FakeStep {
+	return FakeStep{Response: fakeResp(Result{Sn: sn, Pgs: "rpl", Rg: []int{from, to}, Ws: fakeWords(text), Ls: final}, final)}
+}
+
+// FakeWord 带时间和置信度的词，Bg为起始帧（1帧为10ms），Sc为置信度分数
+type FakeWord struct {
+	Text string
+	Bg   int
+	Sc   float64
+}
+
+// FakeTimedResult 返回第sn段带词级时间和置信度的识别结果，ed为本段结束帧
+func FakeTimedResult(sn, ed int, words []FakeWord, final bool) FakeStep {
+	ws := make([]Ws, 0, len(words))
+	for _, w := range words {
+		ws = append(ws, Ws{Bg: w.Bg, Cw: []Cw{{W: w.Text, Sc: w.Sc}}})
+	}
+	return FakeStep{Response: fakeResp(Result{Sn: sn, Ed: ed, Ws: ws, Ls: final}, final)}
+}
+
+// FakeError 返回错误码
+func FakeError(code int, message string) FakeStep {
+	return FakeStep{Response: &RespData{Sid: "fake", Code: code, Message: message}}
+}
+
+// FakeDrop 断开连接
+func FakeDrop() FakeStep {
+	return FakeStep{Drop: true}
+}
+
+func fakeResp(result Result, final bool) *RespData {
+	status := STATUS_CONTINUE_FRAME
+	if final {
+		status = STATUS_LAST_FRAME
+	}
+	return &RespData{Sid: "fake", Message: "success", Data: Data{Result: result, Status: status}}
+}
+
+func fakeWords(text string) []Ws {
+	return []Ws{{Cw: []Cw{{W: text}}}}
+}
+
+// FakeFrame 模拟服务收到的音频帧
+type FakeFrame struct {
+	AppID  string // 仅第一帧有
+	Status int
+	Audio  []byte
+}
+
+// FakeServer 本地模拟的讯飞语音听写WebSocket服务，按脚本回放识别结果，用于离线测试。
+// 每个连接独立执行一遍脚本，请求缺少鉴权参数时返回401
+type FakeServer struct {
+	server *httptest.Server
+	steps  []FakeStep
+
+	mu     sync.Mutex
+	frames []FakeFrame
+}
+
+func NewFakeServer(steps ...FakeStep) *FakeServer {
+	s := &FakeServer{steps: steps}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Endpoint 模拟服务的接口地址，用作Config.Endpoint
+func (s *FakeServer) Endpoint() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http") + "/v2/iat"
+}
+
+// Frames 收到的所有音频帧
+func (s *FakeServer) Frames() []FakeFrame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]FakeFrame(nil), s.frames...)
+}
+
+// Audio 收到的全部音频
+func (s *FakeServer) Audio() []byte {
+	var audio []byte
+	for _, frame := range s.Frames() {
+		audio = append(audio, frame.Audio...)
+	}
+	return audio
+}
+
+func (s *FakeServer) Close() {
+	s.server.Close()
+}
+
+var fakeUpgrader = websocket.Upgrader{}
+
+func (s *FakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("authorization") == "" || q.Get("date") == "" || q.Get("host") == "" {
+		http.Error(w, `{"message":"HMAC signature cannot be verified"}`, http.StatusUnauthorized)
+		return
+	}
+	conn, err := fakeUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	next, received := 0, 0
+	for next < len(s.steps) {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req struct {
+			Common struct {
+				AppID string `json:"app_id"`
+			} `json:"common"`
+			Data struct {
+				Status int    `json:"status"`
+				Audio  string `json:"audio"`
+			} `json:"data"`
+		}
+		if err = json.Unmarshal(msg, &req); err != nil {
+			return
+		}
+		audio, _ := base64.StdEncoding.DecodeString(req.Data.Audio)
+		s.mu.Lock()
+		s.frames = append(s.frames, FakeFrame{AppID: req.Common.AppID, Status: req.Data.Status, Audio: audio})
+		s.mu.Unlock()
+		received++
+
+		last := req.Data.Status == STATUS_LAST_FRAME
+		for ; next < len(s.steps); next++ {
+			step := s.steps[next]
+			if !last && (step.AfterFrame == 0 || step.AfterFrame > received) {
+				break
+			}
+			if step.Drop {
+				return
+			}
+			if err = conn.WriteJSON(step.Response); err != nil {
+				return
+			}
+		}
+	}
+}
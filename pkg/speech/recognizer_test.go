@@ -0,0 +1,142 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func newFakeRecognizer(server *FakeServer) *XfyunRecognizer {
+	return NewXfyunRecognizer(Config{APIKey: "key", APISecret: "secret", AppID: "app", Endpoint: server.Endpoint()})
+}
+
+func TestDecoderReplace(t *testing.T) {
+	var d Decoder
+	d.Decode(&Result{Sn: 1, Ws: fakeWords("今天")})
+	d.Decode(&Result{Sn: 2, Ws: fakeWords("天汽")})
+	d.Decode(&Result{Sn: 3, Pgs: "rpl", Rg: []int{2, 2}, Ws: fakeWords("天气")})
+	if got := d.String(); got != "今天天气" {
+		t.Fatalf("decode mismatch: %q", got)
+	}
+	// 替换范围超出已有结果时不应越界
+	d.Decode(&Result{Sn: 4, Pgs: "rpl", Rg: []int{3, 10}, Ws: fakeWords("很好")})
+	if got := d.String(); got != "今天很好" {
+		t.Fatalf("decode mismatch: %q", got)
+	}
+}
+
+func TestRecognize(t *testing.T) {
+	server := NewFakeServer(
+		FakeResult(1, "你好", false).After(1),
+		FakeResult(2, "世姐", false).After(2),
+		FakeReplace(3, 2, 2, "世界", true),
+	)
+	defer server.Close()
+
+	audio := bytes.Repeat([]byte{1, 2}, frameSize*2)
+	res, err := newFakeRecognizer(server).Recognize(context.Background(), bytes.NewReader(audio))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Text != "你好世界" {
+		t.Fatalf("text mismatch: %q", res.Text)
+	}
+
+	frames := server.Frames()
+	if frames[0].AppID != "app" || frames[0].Status != STATUS_FIRST_FRAME || frames[len(frames)-1].Status != STATUS_LAST_FRAME {
+		t.Fatalf("unexpected frames: %+v", frames)
+	}
+	if !bytes.Equal(server.Audio(), audio) {
+		t.Fatalf("audio mismatch: got %d bytes", len(server.Audio()))
+	}
+}
+
+func TestRecognizeErrors(t *testing.T) {
+	for name, steps := range map[string][]FakeStep{
+		"error code": {FakeError(10165, "invalid handle")},
+		"dropped":    {FakeResult(1, "你好", false).After(1), FakeDrop().After(1)},
+		"no final":   {FakeResult(1, "你好", false)},
+	} {
+		server := NewFakeServer(steps...)
+		_, err := newFakeRecognizer(server).Recognize(context.Background(), bytes.NewReader(make([]byte, frameSize)))
+		server.Close()
+		if err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+		if name == "error code" && !strings.Contains(err.Error(), "10165") {
+			t.Fatalf("%s: error should contain code: %v", name, err)
+		}
+	}
+}
+
+func TestSessionPartialResults(t *testing.T) {
+	server := NewFakeServer(
+		FakeResult(1, "你好", false).After(1),
+		FakeResult(2, "世界", true),
+	)
+	defer server.Close()
+
+	session, err := newFakeRecognizer(server).NewSession(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer session.Close()
+
+	if err = session.Write(make([]byte, 100)); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	select {
+	case res := <-session.Results():
+		if res.Text != "你好" || res.Final {
+			t.Fatalf("partial mismatch: %+v", res)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("partial result timeout")
+	}
+
+	if err = session.CloseSend(); err != nil {
+		t.Fatalf("close send failed: %v", err)
+	}
+	res := <-session.Results()
+	if res.Text != "你好世界" || !res.Final {
+		t.Fatalf("final mismatch: %+v", res)
+	}
+	if _, ok := <-session.Results(); ok || session.Err() != nil {
+		t.Fatalf("session should end without error: %v", session.Err())
+	}
+}
+
+func TestSessionCanceled(t *testing.T) {
+	// 脚本在最后一帧后才返回结果，取消前收不到任何结果
+	server := NewFakeServer(FakeResult(1, "你好", true))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session, err := newFakeRecognizer(server).NewSession(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer session.Close()
+
+	cancel()
+	for range session.Results() {
+	}
+	if session.Err() == nil {
+		t.Fatal("canceled session should fail")
+	}
+}
+
+func TestFakeServerRequiresAuth(t *testing.T) {
+	server := NewFakeServer(FakeResult(1, "你好", true))
+	defer server.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial(server.Endpoint(), nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without auth params, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package speech
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 每帧发送的音频大小，16k采样率16bit单声道PCM约40ms
+const frameSize = 1280
+
+// Session 讯飞流式识别会话，音频到达即发送给识别服务，识别结果通过Results返回
+type Session struct {
+	conn    *websocket.Conn
+	appID   string
+	results chan Transcript
+	done    chan struct{}
+	// Close后关闭，避免调用方不再读取结果时readLoop阻塞
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	writeMu sync.Mutex
+	started bool // 是否已发送第一帧
+	sent    bool // 是否已发送最后一帧
+
+	errMu sync.Mutex
+	err   error
+}
+
+var _ RecognizeSession = (*Session)(nil)
+
+// NewSession 建立识别会话，ctx取消时关闭连接
+func (r *XfyunRecognizer) NewSession(ctx context.Context) (RecognizeSession, error) {
+	return r.newSession(ctx)
+}
+
+func (r *XfyunRecognizer) newSession(ctx context.Context) (*Session, error) {
+	authUrl, err := assembleAuthUrl(r.config.Endpoint, r.config.APIKey, r.config.APISecret)
+	if err != nil {
+		return nil, err
+	}
+	d := websocket.Dialer{
+		HandshakeTimeout: 5 * time.Second,
+	}
+	//握手并建立websocket 连接
+	conn, resp, err := d.DialContext(ctx, authUrl, nil)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("连接失败: %v, %s", err, readResp(resp))
+		}
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+
+	s := &Session{
+		conn:    conn,
+		appID:   r.config.AppID,
+		results: make(chan Transcript, 16),
+		done:    make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	go s.readLoop()
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.fail(ctx.Err())
+			conn.Close()
+		case <-s.done:
+		}
+	}()
+	return s, nil
+}
+
+// Write 发送一段音频（16k采样率16bit单声道PCM），按帧大小拆分
+func (s *Session) Write(audio []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if s.sent {
+		return errors.New("音频已发送完毕")
+	}
+	for len(audio) > 0 {
+		n := min(len(audio), frameSize)
+		status := STATUS_CONTINUE_FRAME
+		if !s.started {
+			status = STATUS_FIRST_FRAME
+		}
+		if err := s.conn.WriteJSON(s.frame(status, audio[:n])); err != nil {
+			return fmt.Errorf("发送音频失败: %w", err)
+		}
+		s.started = true
+		audio = audio[n:]
+	}
+	return nil
+}
+
+// CloseSend 发送最后一帧，识别服务返回最终结果后结束会话
+func (s *Session) CloseSend() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if s.sent {
+		return nil
+	}
+	s.sent = true
+	if err := s.conn.WriteJSON(s.frame(STATUS_LAST_FRAME, nil)); err != nil {
+		return fmt.Errorf("发送音频失败: %w", err)
+	}
+	return nil
+}
+
+// Results 识别结果，每收到一次识别服务的返回输出一次，会话结束时关闭
+func (s *Session) Results() <-chan Transcript {
+	return s.results
+}
+
+// Err 会话失败的原因，正常结束时为nil，需在Results关闭后调用
+func (s *Session) Err() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.err
+}
+
+// Close 关闭连接，未收到最终结果的会话以失败结束
+func (s *Session) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return s.conn.Close()
+}
+
+// fail 记录会话失败的原因，只保留第一个
+func (s *Session) fail(err error) {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// frame 构造音频帧，第一帧带上common和business参数
+func (s *Session) frame(status int, audio []byte) map[string]any {
+	frame := map[string]any{
+		"data": map[string]any{
+			"status":   status,
+			"format":   "audio/L16;rate=16000",
+			"audio":    base64.StdEncoding.EncodeToString(audio),
+			"encoding": "raw",
+		},
+	}
+	if !s.started {
+		frame["common"] = map[string]any{
+			"app_id": s.appID, //appid 必须带上，只需第一帧发送
+		}
+		frame["business"] = map[string]any{ //business 参数，只需一帧发送
+			"language": "zh_cn",
+			"domain":   "iat",
+			"accent":   "mandarin",
+			"dwa":      "wpgs", // 开启动态修正，中间结果通过pgs=rpl替换之前的结果
+		}
+	}
+	return frame
+}
+
+// readLoop 读取识别结果，收到最终结果、识别服务返回错误或连接断开时结束
+func (s *Session) readLoop() {
+	defer close(s.results)
+	defer close(s.done)
+	defer s.conn.Close()
+
+	var decoder Decoder
+	for {
+		_, msg, err := s.conn.ReadMessage()
+		if err != nil {
+			s.fail(fmt.Errorf("连接中断: %w", err))
+			return
+		}
+		var resp RespData
+		if err = json.Unmarshal(msg, &resp); err != nil {
+			s.fail(fmt.Errorf("解析识别结果失败: %w", err))
+			return
+		}
+		if resp.Code != 0 {
+			s.fail(fmt.Errorf("识别失败: code=%d, message=%s, sid=%s", resp.Code, resp.Message, resp.Sid))
+			return
+		}
+		decoder.Decode(&resp.Data.Result)
+		final := resp.Data.Status == STATUS_LAST_FRAME
+		select {
+		case s.results <- decoder.Transcript(final):
+		case <-s.closed:
+			s.fail(errors.New("会话已关闭"))
+			return
+		}
+		if final {
+			return
+		}
+	}
+}
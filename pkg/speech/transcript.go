@@ -0,0 +1,145 @@
+package speech
+
+import "unicode"
+
+// 识别服务时间偏移的单位，1帧为10ms
+const frameMillis = 10
+
+// 置信度低于该值的词标记为低置信度，便于界面提示用户核对
+const LowConfidenceThreshold = 0.6
+
+// Transcript 识别结果，Text为到目前为止的完整文本（已按动态修正替换），Final表示识别结束
+type Transcript struct {
+	Text          string    `json:"text"`
+	Final         bool      `json:"final"`
+	Segments      []Segment `json:"segments"`       // 按时间排序的分段
+	LowConfidence bool      `json:"low_confidence"` // 是否有低置信度的词
+}
+
+// Segment 识别结果的一段，对应识别服务返回的一次结果
+type Segment struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"` // 开始时间，毫秒
+	End   int    `json:"end"`   // 结束时间，毫秒
+	Words []Word `json:"words"`
+}
+
+// Word 识别出的词
+type Word struct {
+	Text          string  `json:"text"`
+	Start         int     `json:"start"`          // 开始时间，毫秒
+	End           int     `json:"end"`            // 结束时间，毫秒
+	Confidence    float64 `json:"confidence"`     // 置信度(0-1)，识别服务未返回分数时为-1
+	LowConfidence bool    `json:"low_confidence"` // 置信度低于LowConfidenceThreshold
+}
+
+// Transcript 当前的识别结果。每段最后一个词的结束时间取段的结束时间，其余的词和未返回段结束时间时
+// 取后一个词的开始时间，都没有时与开始时间相同；标点等没有时间的词（bg为0）取前一个词的结束时间
+func (d *Decoder) Transcript(final bool) Transcript {
+	t := Transcript{Text: d.String(), Final: final, Segments: make([]Segment, 0, len(d.results))}
+	// 各词是否有时间，与Segments中的词一一对应
+	var timed [][]bool
+	var ends []int
+	first := true
+	for _, result := range d.results {
+		if result == nil {
+			continue
+		}
+		segment := Segment{Text: result.String(), Words: make([]Word, 0, len(result.Ws))}
+		flags := make([]bool, 0, len(result.Ws))
+		for _, ws := range result.Ws {
+			word := Word{Text: ws.String(), Start: ws.Bg * frameMillis, Confidence: confidence(ws.Cw)}
+			word.LowConfidence = word.Confidence >= 0 && word.Confidence < LowConfidenceThreshold
+			t.LowConfidence = t.LowConfidence || word.LowConfidence
+			segment.Words = append(segment.Words, word)
+			flags = append(flags, ws.Bg > 0 || first)
+			first = false
+		}
+		t.Segments = append(t.Segments, segment)
+		timed = append(timed, flags)
+		ends = append(ends, result.Ed*frameMillis)
+	}
+
+	// 倒序计算有时间的词的结束时间
+	next := -1
+	for i := len(t.Segments) - 1; i >= 0; i-- {
+		words := t.Segments[i].Words
+		if ends[i] > 0 {
+			next = ends[i]
+		}
+		for j := len(words) - 1; j >= 0; j-- {
+			if !timed[i][j] {
+				continue
+			}
+			words[j].End = max(next, words[j].Start)
+			next = words[j].Start
+		}
+	}
+	// 顺序补全没有时间的词，并计算段的起止时间
+	last := 0
+	for i := range t.Segments {
+		segment := &t.Segments[i]
+		for j := range segment.Words {
+			if timed[i][j] {
+				last = segment.Words[j].End
+			} else {
+				segment.Words[j].Start, segment.Words[j].End = last, last
+			}
+		}
+		if len(segment.Words) > 0 {
+			segment.Start = segment.Words[0].Start
+			segment.End = segment.Words[len(segment.Words)-1].End
+		}
+		segment.End = max(segment.End, ends[i])
+	}
+	return t
+}
+
+// confidence 取候选词中的最高分并换算到0-1，没有分数时返回-1
+func confidence(cws []Cw) float64 {
+	score := 0.0
+	for _, cw := range cws {
+		score = max(score, cw.Sc)
+	}
+	switch {
+	case score <= 0:
+		return -1
+	case score > 1:
+		return min(score/100, 1)
+	default:
+		return score
+	}
+}
+
+// EditDistance 回答文本与识别文本的编辑距离（按字计），忽略空白、标点和英文大小写，
+// 用于记录用户对识别结果的修改程度
+func (t *Transcript) EditDistance(text string) int {
+	a, b := normalizeText(t.Text), normalizeText(text)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func normalizeText(text string) []rune {
+	var runes []rune
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, unicode.ToLower(r))
+		}
+	}
+	return runes
+}
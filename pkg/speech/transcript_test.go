@@ -0,0 +1,83 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestDecoderTranscript(t *testing.T) {
+	var d Decoder
+	d.Decode(&Result{Sn: 1, Ed: 120, Ws: []Ws{
+		{Bg: 10, Cw: []Cw{{W: "今天", Sc: 0.95}}},
+		{Bg: 50, Cw: []Cw{{W: "天气", Sc: 0.4}}},
+		{Cw: []Cw{{W: "，"}}},
+	}})
+	d.Decode(&Result{Sn: 2, Ws: []Ws{
+		{Bg: 150, Cw: []Cw{{W: "很好", Sc: 80}}},
+	}})
+
+	res := d.Transcript(true)
+	if res.Text != "今天天气，很好" || !res.Final || !res.LowConfidence || len(res.Segments) != 2 {
+		t.Fatalf("transcript mismatch: %+v", res)
+	}
+	want := []Word{
+		{Text: "今天", Start: 100, End: 500, Confidence: 0.95},
+		{Text: "天气", Start: 500, End: 1200, Confidence: 0.4, LowConfidence: true},
+		{Text: "，", Start: 1200, End: 1200, Confidence: -1},
+		{Text: "很好", Start: 1500, End: 1500, Confidence: 0.8},
+	}
+	var got []Word
+	for _, segment := range res.Segments {
+		got = append(got, segment.Words...)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("word count mismatch: %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("word %d mismatch: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if s := res.Segments[0]; s.Start != 100 || s.End != 1200 {
+		t.Fatalf("segment time mismatch: %+v", s)
+	}
+}
+
+func TestRecognizeTimedResult(t *testing.T) {
+	server := NewFakeServer(FakeTimedResult(1, 80, []FakeWord{
+		{Text: "你好", Bg: 20, Sc: 0.9},
+		{Text: "世界", Bg: 45, Sc: 0.3},
+	}, true))
+	defer server.Close()
+
+	res, err := newFakeRecognizer(server).Recognize(context.Background(), bytes.NewReader(make([]byte, frameSize)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Text != "你好世界" || !res.LowConfidence || len(res.Segments) != 1 {
+		t.Fatalf("transcript mismatch: %+v", res)
+	}
+	words := res.Segments[0].Words
+	if words[0].Start != 200 || words[0].End != 450 || words[1].End != 800 || !words[1].LowConfidence || words[0].LowConfidence {
+		t.Fatalf("words mismatch: %+v", words)
+	}
+}
+
+func TestTranscriptEditDistance(t *testing.T) {
+	transcript := Transcript{Text: "嗯，我们使用Redis做缓存。"}
+	cases := []struct {
+		answer string
+		want   int
+	}{
+		{"嗯，我们使用Redis做缓存。", 0},
+		{"嗯 我们使用 redis 做缓存", 0},
+		{"我们使用Redis做缓存", 1},
+		{"嗯，我们使用Redis作缓存。", 1},
+	}
+	for _, c := range cases {
+		if got := transcript.EditDistance(c.answer); got != c.want {
+			t.Errorf("EditDistance(%q) = %d, want %d", c.answer, got, c.want)
+		}
+	}
+}
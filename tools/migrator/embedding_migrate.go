@@ -0,0 +1,168 @@
+package main
+
+import (
+	"ai_jianli_go/component"
+	"ai_jianli_go/config"
+	"ai_jianli_go/internal/dao"
+	"ai_jianli_go/logs"
+	"ai_jianli_go/pkg/rag"
+	"ai_jianli_go/types/model"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// 更换嵌入模型后迁移已有知识库：用config.yaml中新的嵌入模型重新计算所有分块的向量，
+// 写入新版本索引后切换索引别名。需在项目根目录执行，执行前应停止服务
+//
+//	go run tools/migrator/embedding_migrate.go [-user 1] [-root 2] [-keep-old]
+//
+// -backfill-filters 不重新计算向量，为加入检索过滤条件前导入的分块补写文章ID、文件夹等过滤字段，
+// 并为旧索引补充过滤字段的定义。补写前按过滤条件检索不到这些分块
+//
+//	go run tools/migrator/embedding_migrate.go -backfill-filters [-user 1] [-root 2]
+//
+// -cleanup-old 不迁移，删除之前以-keep-old迁移时保留的旧版本分块
+//
+//	go run tools/migrator/embedding_migrate.go -cleanup-old [-user 1] [-root 2]
+func main() {
+	userID := flag.Uint("user", 0, "只迁移该用户的知识库，0表示所有用户")
+	rootID := flag.Uint("root", 0, "只迁移该知识库，0表示所有知识库")
+	keepOld := flag.Bool("keep-old", false, "迁移后保留旧分块")
+	backfill := flag.Bool("backfill-filters", false, "只为已有分块补写过滤字段，不重新计算向量")
+	cleanup := flag.Bool("cleanup-old", false, "只删除迁移时保留的旧分块")
+	flag.Parse()
+
+	logs.Init()
+	config.Init()
+	component.Init()
+	rag.Init()
+
+	wikiDAO := dao.NewWikiDAO(component.GetMySQLDB())
+	wikis, err := wikiDAO.ListKnowledgeBases(*userID)
+	if err != nil {
+		fmt.Printf("获取知识库失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *backfill {
+		if failed := backfillFilters(wikiDAO, wikis, *rootID); failed > 0 {
+			fmt.Printf("%d个知识库补写失败，可重新执行\n", failed)
+			os.Exit(1)
+		}
+		return
+	}
+	if *cleanup {
+		if failed := cleanupOld(wikis, *rootID); failed > 0 {
+			fmt.Printf("%d个知识库清理失败，可重新执行\n", failed)
+			os.Exit(1)
+		}
+		return
+	}
+
+	conf := config.GetEmbeddingConfig()
+	fmt.Printf("嵌入模型: %s, 向量维度: %d\n", conf.Model, conf.Dimension)
+
+	ctx := context.Background()
+	failed := 0
+	for _, wiki := range wikis {
+		if *rootID != 0 && wiki.ID != *rootID {
+			continue
+		}
+		wiki.RootId = wiki.ID
+
+		fmt.Printf("迁移知识库 %d(%s)...\n", wiki.ID, wiki.Title)
+		result, err := wiki.MigrateIndex(rag.WithEmbeddingUser(ctx, wiki.UserId), component.GetRedisDB(), rag.GetEmbedding(), *keepOld,
+			func(done, total int) {
+				fmt.Printf("\r  已迁移 %d/%d 个分块", done, total)
+			})
+		if err != nil {
+			failed++
+			fmt.Printf("\n  迁移失败: %v\n", err)
+			continue
+		}
+		fmt.Printf("\n  完成: 索引版本 v%d -> v%d, 迁移%d个分块, 删除%d个旧分块\n",
+			result.FromVersion, result.ToVersion, result.Chunks, result.Removed)
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d个知识库迁移失败，可重新执行继续迁移\n", failed)
+		os.Exit(1)
+	}
+}
+
+// backfillFilters 为各知识库的旧索引补充过滤字段定义，并按当前目录结构为每篇文章的分块补写过滤字段，返回失败的知识库数
+func backfillFilters(wikiDAO *dao.WikiDAO, wikis []*model.Wiki, rootID uint) int {
+	ctx := context.Background()
+	client := component.GetRedisDB()
+	failed := 0
+	for _, wiki := range wikis {
+		if rootID != 0 && wiki.ID != rootID {
+			continue
+		}
+		wiki.RootId = wiki.ID
+
+		fmt.Printf("补写知识库 %d(%s)...\n", wiki.ID, wiki.Title)
+		added, err := wiki.AddFilterFields(ctx, client)
+		if err != nil {
+			failed++
+			fmt.Printf("  补充索引字段失败: %v\n", err)
+			continue
+		}
+		items, err := wikiDAO.ListDescendants(wiki.UserId, wiki.ID)
+		if err != nil {
+			failed++
+			fmt.Printf("  获取文章失败: %v\n", err)
+			continue
+		}
+		itemMap := make(map[uint]*model.Wiki, len(items))
+		for _, item := range items {
+			itemMap[item.ID] = item
+		}
+
+		articles, chunks, ok := 0, 0, true
+		for _, item := range items {
+			if item.Type != model.WikiTypeArticle {
+				continue
+			}
+			item.RootId = wiki.ID
+			item.SetFolderIDs(model.AncestorFolderIDs(item, itemMap))
+			n, err := item.BackfillFilterFields(ctx, client)
+			if err != nil {
+				fmt.Printf("  文章 %d 补写失败: %v\n", item.ID, err)
+				ok = false
+				break
+			}
+			articles++
+			chunks += n
+		}
+		if !ok {
+			failed++
+			continue
+		}
+		fmt.Printf("  完成: 补充%d个索引字段, %d篇文章, %d个分块\n", added, articles, chunks)
+	}
+	return failed
+}
+
+// cleanupOld 删除各知识库迁移时保留的旧版本分块，返回失败的知识库数
+func cleanupOld(wikis []*model.Wiki, rootID uint) int {
+	ctx := context.Background()
+	failed := 0
+	for _, wiki := range wikis {
+		if rootID != 0 && wiki.ID != rootID {
+			continue
+		}
+		wiki.RootId = wiki.ID
+
+		removed, err := wiki.DeleteKeptVersions(ctx, component.GetRedisDB())
+		if err != nil {
+			failed++
+			fmt.Printf("清理知识库 %d(%s) 失败: %v\n", wiki.ID, wiki.Title, err)
+			continue
+		}
+		fmt.Printf("清理知识库 %d(%s): 删除%d个旧分块\n", wiki.ID, wiki.Title, removed)
+	}
+	return failed
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// 难度变化方式
+const (
+	DifficultyRampFlat   = "flat"   // 保持起始难度
+	DifficultyRampLinear = "linear" // 从起始难度线性增长到最高难度
+	DifficultyRampStep   = "step"   // 每隔RampInterval轮提升一级
+)
+
+// 问题来源
+const (
+	QuestionSourceResume = "resume"
+	QuestionSourceWiki   = "wiki"
+)
+
+const (
+	minDifficulty = 1
+	maxDifficulty = 5
+)
+
+// InterviewPlan 面试计划，零值字段使用默认值
+type InterviewPlan struct {
+	TotalRounds     int      `json:"total_rounds"`     // 总轮数
+	MaxFollowUps    int      `json:"max_follow_ups"`   // 单个知识点最多追问轮数
+	ResumeRatio     *float64 `json:"resume_ratio"`     // 新问题中简历问题的占比(0-1)，其余为知识库问题
+	StartDifficulty int      `json:"start_difficulty"` // 起始难度(1-5)
+	MaxDifficulty   int      `json:"max_difficulty"`   // 最高难度(1-5)
+	DifficultyRamp  string   `json:"difficulty_ramp"`  // 难度变化方式: flat/linear/step
+	RampInterval    int      `json:"ramp_interval"`    // step方式下每隔多少轮提升一级
+}
+
+// DefaultInterviewPlan 默认面试计划
+func DefaultInterviewPlan() InterviewPlan {
+	plan := InterviewPlan{}
+	plan.fillDefault()
+	return plan
+}
+
+func (p *InterviewPlan) fillDefault() {
+	if p.TotalRounds == 0 {
+		p.TotalRounds = 20
+	}
+	if p.MaxFollowUps == 0 {
+		p.MaxFollowUps = 4
+	}
+	if p.ResumeRatio == nil {
+		ratio := 0.5
+		p.ResumeRatio = &ratio
+	}
+	if p.StartDifficulty == 0 {
+		p.StartDifficulty = 2
+	}
+	if p.MaxDifficulty == 0 {
+		p.MaxDifficulty = maxDifficulty
+	}
+	if p.DifficultyRamp == "" {
+		p.DifficultyRamp = DifficultyRampLinear
+	}
+	if p.RampInterval == 0 {
+		p.RampInterval = 4
+	}
+}
+
+// Validate 补全默认值并校验取值范围
+func (p *InterviewPlan) Validate() error {
+	p.fillDefault()
+	if p.TotalRounds < 1 || p.TotalRounds > 100 {
+		return fmt.Errorf("total_rounds must be in [1, 100]")
+	}
+	if p.MaxFollowUps < 1 || p.MaxFollowUps > 10 {
+		return fmt.Errorf("max_follow_ups must be in [1, 10]")
+	}
+	if *p.ResumeRatio < 0 || *p.ResumeRatio > 1 {
+		return fmt.Errorf("resume_ratio must be in [0, 1]")
+	}
+	if p.StartDifficulty < minDifficulty || p.StartDifficulty > maxDifficulty ||
+		p.MaxDifficulty < minDifficulty || p.MaxDifficulty > maxDifficulty {
+		return fmt.Errorf("difficulty must be in [%d, %d]", minDifficulty, maxDifficulty)
+	}
+	if p.MaxDifficulty < p.StartDifficulty {
+		return fmt.Errorf("max_difficulty must not be less than start_difficulty")
+	}
+	switch p.DifficultyRamp {
+	case DifficultyRampFlat, DifficultyRampLinear, DifficultyRampStep:
+	default:
+		return fmt.Errorf("unsupported difficulty_ramp: %s", p.DifficultyRamp)
+	}
+	if p.RampInterval < 1 {
+		return fmt.Errorf("ramp_interval must be positive")
+	}
+	return nil
+}
+
+// DifficultyAt 第round轮(从1开始)的题目难度
+func (p *InterviewPlan) DifficultyAt(round int) int {
+	if round < 1 {
+		round = 1
+	}
+	switch p.DifficultyRamp {
+	case DifficultyRampLinear:
+		if p.TotalRounds <= 1 {
+			return p.StartDifficulty
+		}
+		progress := float64(round-1) / float64(p.TotalRounds-1)
+		d := p.StartDifficulty + int(math.Round(progress*float64(p.MaxDifficulty-p.StartDifficulty)))
+		return min(d, p.MaxDifficulty)
+	case DifficultyRampStep:
+		return min(p.StartDifficulty+(round-1)/p.RampInterval, p.MaxDifficulty)
+	default:
+		return p.StartDifficulty
+	}
+}
+
+// QuestionSourceAt 第topic个新问题(从1开始，追问不计)应来自简历还是知识库
+// 按比例均匀分布并从简历问题开始，例如0.5时简历和知识库交替出现
+func (p *InterviewPlan) QuestionSourceAt(topic int) string {
+	ratio := *p.ResumeRatio
+	if math.Ceil(float64(topic)*ratio) > math.Ceil(float64(topic-1)*ratio) {
+		return QuestionSourceResume
+	}
+	return QuestionSourceWiki
+}
@@ -0,0 +1,58 @@
+package model
+
+import "testing"
+
+func TestInterviewPlanDifficultyAt(t *testing.T) {
+	plan := InterviewPlan{TotalRounds: 9, StartDifficulty: 1, MaxDifficulty: 5, DifficultyRamp: DifficultyRampLinear}
+	if err := plan.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for round, want := range map[int]int{1: 1, 5: 3, 9: 5, 20: 5} {
+		if got := plan.DifficultyAt(round); got != want {
+			t.Fatalf("linear round %d: want %d, got %d", round, want, got)
+		}
+	}
+
+	plan.DifficultyRamp = DifficultyRampStep
+	plan.RampInterval = 3
+	for round, want := range map[int]int{1: 1, 3: 1, 4: 2, 7: 3, 30: 5} {
+		if got := plan.DifficultyAt(round); got != want {
+			t.Fatalf("step round %d: want %d, got %d", round, want, got)
+		}
+	}
+}
+
+func TestInterviewPlanQuestionSourceAt(t *testing.T) {
+	ratio := 0.25
+	plan := InterviewPlan{ResumeRatio: &ratio}
+	resume := 0
+	for topic := 1; topic <= 8; topic++ {
+		if plan.QuestionSourceAt(topic) == QuestionSourceResume {
+			resume++
+		}
+	}
+	if plan.QuestionSourceAt(1) != QuestionSourceResume {
+		t.Fatal("first new question should come from the resume")
+	}
+	if resume != 2 {
+		t.Fatalf("expected 2 resume questions in 8 new questions, got %d", resume)
+	}
+
+	zero := 0.0
+	plan.ResumeRatio = &zero
+	if plan.QuestionSourceAt(1) != QuestionSourceWiki {
+		t.Fatal("ratio 0 should always pick wiki questions")
+	}
+}
+
+func TestInterviewPlanValidate(t *testing.T) {
+	plan := DefaultInterviewPlan()
+	if plan.TotalRounds != 20 || plan.MaxFollowUps != 4 {
+		t.Fatalf("unexpected default plan: %+v", plan)
+	}
+
+	invalid := InterviewPlan{StartDifficulty: 4, MaxDifficulty: 2}
+	if err := invalid.Validate(); err == nil {
+		t.Fatal("expected error when max_difficulty < start_difficulty")
+	}
+}
@@ -1,7 +1,13 @@
 package model
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
 )
@@ -12,16 +18,325 @@ type Meeting struct {
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	UserID           uint           `json:"user_id" gorm:"index"` // 用户ID
-	Candidate        string         `json:"candidate"`            // 候选人
-	Position         string         `json:"position"`             // 职位
-	JobDescription   string         `json:"job_description"`      // 职位描述
-	Time             int64          `json:"time"`                 // 面试时间
-	Status           string         `json:"status"`               // 面试状态
-	Remark           string         `json:"remark"`               // 备注
-	Resume           string         `json:"resume"`               // 简历内容
-	InterviewRecord  string         `json:"interview_record"`     // 面试记录
-	InterviewSummary string         `json:"interview_summary"`    // 面试总结
-	InterviewNumber  int            `json:"interview_number"`     // 面试对话次数
-	WikiID           uint           `json:"wiki_id"`              // 知识库ID
+	UserID           uint           `json:"user_id" gorm:"index"`                  // 用户ID
+	Candidate        string         `json:"candidate"`                             // 候选人
+	Position         string         `json:"position"`                              // 职位
+	JobDescription   string         `json:"job_description"`                       // 职位描述
+	Time             int64          `json:"time"`                                  // 面试时间
+	Status           string         `json:"status"`                                // 面试状态
+	Remark           string         `json:"remark"`                                // 备注
+	Resume           string         `json:"resume"`                                // 简历内容
+	InterviewRecord  string         `json:"interview_record"`                      // 面试记录
+	InterviewSummary string         `json:"interview_summary"`                     // 面试总结
+	InterviewNumber  int            `json:"interview_number"`                      // 面试对话次数
+	WikiID           uint           `json:"wiki_id"`                               // 知识库ID
+	WikiFolderID     uint           `json:"wiki_folder_id"`                        // 知识库文件夹ID，不为0时只从该文件夹检索
+	Plan             InterviewPlan  `json:"plan" gorm:"serializer:json;type:text"` // 面试计划
+	StageIndex       int            `json:"stage_index"`                           // 当前阶段序号，多阶段面试时有效
+	Stages           []MeetingStage `json:"stages,omitempty" gorm:"-"`             // 面试阶段，按Seq排序
+}
+
+// GetPlan 获取补全默认值后的面试计划
+func (m *Meeting) GetPlan() InterviewPlan {
+	plan := m.Plan
+	plan.fillDefault()
+	return plan
+}
+
+// 面试轮次表，保存每一轮AI面试官的结构化输出
+type MeetingRound struct {
+	ID            uint            `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt  `json:"deleted_at" gorm:"index"`
+	MeetingID     uint            `json:"meeting_id" gorm:"index"`                   // 面试ID
+	StageID       uint            `json:"stage_id" gorm:"index"`                     // 阶段ID，单阶段面试为0
+	Round         int             `json:"round"`                                     // 轮次，从1开始
+	Answer        string          `json:"answer"`                                    // 应聘者回答
+	QuestionID    uint            `json:"question_id" gorm:"index"`                  // 本轮提出的题库题目ID，未使用题库时为0
+	Delivery      *SpeechDelivery `json:"delivery" gorm:"serializer:json;type:text"` // 语音回答的表达情况，文字回答为nil
+	InterviewTurn `gorm:"embedded"`
+}
+
+// InterviewTurn AI面试官单轮输出
+type InterviewTurn struct {
+	Evaluation      string      `json:"evaluation"`                                        // 评价总结
+	Strengths       []string    `json:"strengths" gorm:"serializer:json;type:text"`        // 优点
+	Weaknesses      []string    `json:"weaknesses" gorm:"serializer:json;type:text"`       // 不足
+	KnowledgePoints []string    `json:"knowledge_points" gorm:"serializer:json;type:text"` // 可追问的知识点
+	NextQuestion    string      `json:"next_question"`                                     // 下一个问题
+	FollowUpDepth   int         `json:"follow_up_depth"`                                   // 当前追问深度，0表示新的知识点
+	Score           AnswerScore `json:"score" gorm:"embedded;embeddedPrefix:score_"`       // 本轮回答的评分
+}
+
+// ParseInterviewTurn 解析模型返回的JSON并校验
+func ParseInterviewTurn(content string, maxFollowUp int) (*InterviewTurn, error) {
+	content = strings.TrimSpace(content)
+	content, _ = strings.CutPrefix(content, "```json")
+	content, _ = strings.CutPrefix(content, "```")
+	content, _ = strings.CutSuffix(content, "```")
+
+	turn := &InterviewTurn{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), turn); err != nil {
+		return nil, fmt.Errorf("unmarshal interview turn failed: %w", err)
+	}
+	if err := turn.Validate(maxFollowUp); err != nil {
+		return nil, err
+	}
+	return turn, nil
+}
+
+// TurnField 流式输出中的顶层字段。字符串字段生成过程中Delta为新生成的文本，
+// 字段完整生成后Value为字段的完整JSON值
+type TurnField struct {
+	Name  string          `json:"name"`
+	Delta string          `json:"delta,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Completed 字段是否已完整生成
+func (f TurnField) Completed() bool {
+	return f.Value != nil
+}
+
+// TurnStream 的解析状态
+const (
+	turnBegin  = iota // 等待对象开始
+	turnKey           // 等待字段名
+	turnKeyStr        // 字段名中
+	turnColon         // 等待冒号
+	turnValue         // 等待字段值
+	turnString        // 字符串值中
+	turnNested        // 数组或对象值中
+	turnScalar        // 数字、布尔或null值中
+	turnDone          // 对象已结束
+)
+
+// TurnStream 逐字节解析模型流式输出的单轮JSON：字符串字段边生成边返回新增的文本，
+// 各字段的值完整生成后返回完整值，客户端不需要自己拼接不完整的JSON
+type TurnStream struct {
+	state   int
+	name    string
+	key     []byte // 字段名的原始字节
+	value   []byte // 当前字段值的原始字节
+	escaped bool   // 上一个字节是字符串中的转义符
+	inStr   bool   // 数组或对象值中的字符串内
+	depth   int    // 数组或对象值的嵌套深度
+	sent    int    // 当前字符串值已返回的文本字节数
+}
+
+// Write 追加一段模型输出，返回字符串字段新增的文本和本次新完成的字段
+func (s *TurnStream) Write(delta string) []TurnField {
+	var fields []TurnField
+	for i := 0; i < len(delta); i++ {
+		b := delta[i]
+		switch s.state {
+		case turnBegin:
+			// 跳过```json等前缀
+			if b == '{' {
+				s.state = turnKey
+			}
+		case turnKey:
+			switch b {
+			case '"':
+				s.key = s.key[:0]
+				s.state = turnKeyStr
+			case '}':
+				s.state = turnDone
+			}
+		case turnKeyStr:
+			if s.scanString(b) {
+				_ = json.Unmarshal(append(append([]byte{'"'}, s.key...), '"'), &s.name)
+				s.state = turnColon
+			} else {
+				s.key = append(s.key, b)
+			}
+		case turnColon:
+			if b == ':' {
+				s.state = turnValue
+			}
+		case turnValue:
+			if isJSONSpace(b) {
+				continue
+			}
+			s.value = append(s.value[:0], b)
+			switch b {
+			case '"':
+				s.sent = 0
+				s.state = turnString
+			case '{', '[':
+				s.depth = 1
+				s.state = turnNested
+			default:
+				s.state = turnScalar
+			}
+		case turnString:
+			s.value = append(s.value, b)
+			if s.scanString(b) {
+				fields = s.appendDelta(fields, true)
+				fields = s.complete(fields, turnKey)
+			}
+		case turnNested:
+			s.value = append(s.value, b)
+			if s.inStr {
+				s.inStr = !s.scanString(b)
+				continue
+			}
+			switch b {
+			case '"':
+				s.inStr = true
+			case '{', '[':
+				s.depth++
+			case '}', ']':
+				if s.depth--; s.depth == 0 {
+					fields = s.complete(fields, turnKey)
+				}
+			}
+		case turnScalar:
+			// 数字在输出末尾时可能还没生成完，出现分隔符才算完整
+			switch {
+			case b == '}':
+				fields = s.complete(fields, turnDone)
+			case b == ',' || isJSONSpace(b):
+				fields = s.complete(fields, turnKey)
+			default:
+				s.value = append(s.value, b)
+			}
+		}
+	}
+	if s.state == turnString {
+		fields = s.appendDelta(fields, false)
+	}
+	return fields
+}
+
+// scanString 处理字符串中的一个字节，遇到未转义的引号表示字符串结束
+func (s *TurnStream) scanString(b byte) bool {
+	switch {
+	case s.escaped:
+		s.escaped = false
+	case b == '\\':
+		s.escaped = true
+	case b == '"':
+		return true
+	}
+	return false
+}
+
+// complete 返回当前字段的完整值并进入下一状态
+func (s *TurnStream) complete(fields []TurnField, next int) []TurnField {
+	s.state = next
+	if !json.Valid(s.value) {
+		return fields
+	}
+	value := make(json.RawMessage, len(s.value))
+	copy(value, s.value)
+	return append(fields, TurnField{Name: s.name, Value: value})
+}
+
+// appendDelta 返回当前字符串值新增的文本。未结束时不解码末尾不完整的转义和字符
+func (s *TurnStream) appendDelta(fields []TurnField, closed bool) []TurnField {
+	raw := s.value[1:]
+	if closed {
+		raw = raw[:len(raw)-1]
+	} else {
+		raw = raw[:completePrefix(raw)]
+	}
+	var text string
+	if err := json.Unmarshal(append(append([]byte{'"'}, raw...), '"'), &text); err != nil || len(text) <= s.sent {
+		return fields
+	}
+	fields = append(fields, TurnField{Name: s.name, Delta: text[s.sent:]})
+	s.sent = len(text)
+	return fields
+}
+
+// completePrefix 字符串原始内容中可以完整解码的前缀长度
+func completePrefix(raw []byte) int {
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			continue
+		}
+		n := 2
+		if i+1 < len(raw) && raw[i+1] == 'u' {
+			n = 6
+			// 代理对的前半部分需要和后半部分一起解码
+			if i+n <= len(raw) && isHighSurrogate(raw[i+2:i+6]) {
+				n = 12
+			}
+		}
+		if i+n > len(raw) {
+			return i
+		}
+		i += n - 1
+	}
+	n := len(raw)
+	for n > 0 && !utf8.Valid(raw[:n]) && len(raw)-n < utf8.UTFMax {
+		n--
+	}
+	return n
+}
+
+func isHighSurrogate(hex []byte) bool {
+	v, err := strconv.ParseUint(string(hex), 16, 16)
+	return err == nil && utf16.IsSurrogate(rune(v)) && v < 0xdc00
+}
+
+func isJSONSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
+
+// Validate 校验单轮输出，去除空白项
+func (t *InterviewTurn) Validate(maxFollowUp int) error {
+	t.Evaluation = strings.TrimSpace(t.Evaluation)
+	t.NextQuestion = strings.TrimSpace(t.NextQuestion)
+	t.Strengths = compactStrings(t.Strengths)
+	t.Weaknesses = compactStrings(t.Weaknesses)
+	t.KnowledgePoints = compactStrings(t.KnowledgePoints)
+	t.Score.normalize()
+
+	if t.NextQuestion == "" {
+		return fmt.Errorf("next_question is empty")
+	}
+	if t.FollowUpDepth < 0 || t.FollowUpDepth > maxFollowUp {
+		return fmt.Errorf("follow_up_depth %d out of range [0, %d]", t.FollowUpDepth, maxFollowUp)
+	}
+	return nil
+}
+
+// KnowledgeQuery 拼接知识点，用于下一轮知识库检索
+func (t *InterviewTurn) KnowledgeQuery() string {
+	return strings.Join(t.KnowledgePoints, " ")
+}
+
+// String 渲染为文本，写入对话记录
+func (t *InterviewTurn) String() string {
+	var sb strings.Builder
+	if t.Evaluation != "" {
+		sb.WriteString("评价：" + t.Evaluation + "\n")
+	}
+	for _, v := range t.Strengths {
+		sb.WriteString("✅ " + v + "\n")
+	}
+	for _, v := range t.Weaknesses {
+		sb.WriteString("❌ " + v + "\n")
+	}
+	if t.Score.Scored() {
+		fmt.Fprintf(&sb, "评分：正确性%d 深度%d 表达清晰度%d\n", t.Score.Correctness, t.Score.Depth, t.Score.Clarity)
+	}
+	if len(t.KnowledgePoints) > 0 {
+		sb.WriteString("可追问的知识点：" + strings.Join(t.KnowledgePoints, "、") + "\n")
+	}
+	sb.WriteString("问题：" + t.NextQuestion)
+	return sb.String()
+}
+
+func compactStrings(items []string) []string {
+	res := make([]string, 0, len(items))
+	for _, v := range items {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
 }
@@ -0,0 +1,78 @@
+package model
+
+import "sort"
+
+// CandidateComparison 候选人对比表中的一行，数据来自面试的最新评价报告
+type CandidateComparison struct {
+	Rank            int                `json:"rank"` // 排名，得分相同的候选人排名相同
+	MeetingID       uint               `json:"meeting_id"`
+	Candidate       string             `json:"candidate"`
+	ReportVersion   int                `json:"report_version"`   // 使用的报告版本
+	Score           float64            `json:"score"`            // 百分制总体得分
+	Rating          string             `json:"rating"`           // 评级
+	Dimensions      map[string]float64 `json:"dimensions"`       // 各胜任力维度的百分制得分
+	MatchPercentage float64            `json:"match_percentage"` // 岗位匹配度(0-100)
+	MissingKeywords []string           `json:"missing_keywords"` // 欠缺的岗位要求
+}
+
+// SkippedMeeting 未参与对比的面试及原因
+type SkippedMeeting struct {
+	MeetingID uint   `json:"meeting_id"`
+	Candidate string `json:"candidate"`
+	Reason    string `json:"reason"`
+}
+
+// CandidateRanking 同一岗位候选人的对比排名
+type CandidateRanking struct {
+	Position   string                `json:"position"`
+	Candidates []CandidateComparison `json:"candidates"` // 按排名排序
+	Skipped    []SkippedMeeting      `json:"skipped"`
+	Summary    string                `json:"summary"` // 排名靠前候选人的差异总结，未要求时为空
+}
+
+// NewCandidateComparison 根据面试评价报告构建对比行，维度得分统一换算为百分制
+func NewCandidateComparison(meeting *Meeting, report *MeetingReport) CandidateComparison {
+	overall := report.Report.OverallEvaluation
+	item := CandidateComparison{
+		MeetingID:       meeting.ID,
+		Candidate:       meeting.Candidate,
+		ReportVersion:   report.Version,
+		Score:           overall.Score,
+		Rating:          overall.Rating,
+		Dimensions:      make(map[string]float64, len(report.Report.CompetencyDimensions.Dimensions)),
+		MatchPercentage: report.Report.JDMatch.MatchPercentage,
+		MissingKeywords: report.Report.JDMatch.MissingKeywords,
+	}
+	if overall.MaxScore > 0 && overall.MaxScore != reportFullMark {
+		item.Score = round1(overall.Score / overall.MaxScore * reportFullMark)
+	}
+	for _, d := range report.Report.CompetencyDimensions.Dimensions {
+		score := d.Score
+		if d.FullMark > 0 && d.FullMark != reportFullMark {
+			score = round1(d.Score / d.FullMark * reportFullMark)
+		}
+		item.Dimensions[d.Name] = score
+	}
+	return item
+}
+
+// RankCandidates 按总体得分从高到低排名，得分相同时岗位匹配度高的在前，
+// 两者都相同的候选人排名相同
+func RankCandidates(items []CandidateComparison) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		if items[i].MatchPercentage != items[j].MatchPercentage {
+			return items[i].MatchPercentage > items[j].MatchPercentage
+		}
+		return items[i].MeetingID < items[j].MeetingID
+	})
+	for i := range items {
+		if i > 0 && items[i].Score == items[i-1].Score && items[i].MatchPercentage == items[i-1].MatchPercentage {
+			items[i].Rank = items[i-1].Rank
+			continue
+		}
+		items[i].Rank = i + 1
+	}
+}
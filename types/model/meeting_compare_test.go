@@ -0,0 +1,39 @@
+package model
+
+import "testing"
+
+func TestRankCandidates(t *testing.T) {
+	items := []CandidateComparison{
+		{MeetingID: 1, Score: 70, MatchPercentage: 60},
+		{MeetingID: 2, Score: 85, MatchPercentage: 50},
+		{MeetingID: 3, Score: 70, MatchPercentage: 80},
+		{MeetingID: 4, Score: 70, MatchPercentage: 60},
+	}
+	RankCandidates(items)
+
+	wantIDs := []uint{2, 3, 1, 4}
+	wantRanks := []int{1, 2, 3, 3}
+	for i := range items {
+		if items[i].MeetingID != wantIDs[i] || items[i].Rank != wantRanks[i] {
+			t.Fatalf("position %d mismatch: %+v", i, items[i])
+		}
+	}
+}
+
+func TestNewCandidateComparison(t *testing.T) {
+	report := &MeetingReport{Version: 2, Report: InterviewReport{
+		OverallEvaluation: OverallEvaluation{Score: 8, MaxScore: 10, Rating: "良好"},
+		CompetencyDimensions: CompetencyDimensions{Dimensions: []Dimension{
+			{Name: DimensionKnowledge, Score: 45, FullMark: 50},
+			{Name: DimensionDepth, Score: 70, FullMark: 100},
+		}},
+		JDMatch: JDMatch{MatchPercentage: 66, MissingKeywords: []string{"Redis"}},
+	}}
+	item := NewCandidateComparison(&Meeting{ID: 5, Candidate: "张三"}, report)
+	if item.Score != 80 || item.ReportVersion != 2 || item.MatchPercentage != 66 {
+		t.Fatalf("comparison mismatch: %+v", item)
+	}
+	if item.Dimensions[DimensionKnowledge] != 90 || item.Dimensions[DimensionDepth] != 70 {
+		t.Fatalf("dimensions not normalized: %+v", item.Dimensions)
+	}
+}
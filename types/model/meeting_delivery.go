@@ -0,0 +1,170 @@
+package model
+
+import (
+	"ai_jianli_go/pkg/speech"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 统计的口头禅，按识别出的词完整匹配，避免把“那个项目”“这就是”中的词算作口头禅
+var fillerWords = []string{"嗯", "那个", "就是"}
+
+const (
+	// 词的结束时间由后一个词推断时，按每字最长的发音时间估算实际结束时间，超出部分算作停顿
+	maxCharMillis = 400
+	// 没有结束时间的最后一个词按正常语速每字的发音时间估算
+	typicalCharMillis = 250
+	// 超过该时长的间隔计为一次停顿，毫秒
+	pauseThreshold = 800
+
+	// 正常表达的语速范围，字/分钟
+	minSpeakingRate = 160
+	maxSpeakingRate = 280
+	// 停顿时长占回答时长的比例超过该值时扣分
+	maxPauseRatio = 0.2
+	// 每分钟口头禅超过该次数时扣分
+	maxFillerRate = 2
+)
+
+// SpeechDelivery 语音回答的表达情况，由语音识别返回的词级时间计算
+type SpeechDelivery struct {
+	Duration      int            `json:"duration"`       // 回答总时长，毫秒
+	Characters    int            `json:"characters"`     // 字数，不含标点，英文单词和数字按一个字计
+	SpeakingRate  float64        `json:"speaking_rate"`  // 语速，字/分钟
+	PauseCount    int            `json:"pause_count"`    // 停顿次数
+	PauseDuration int            `json:"pause_duration"` // 停顿总时长，毫秒
+	LongestPause  int            `json:"longest_pause"`  // 最长停顿，毫秒
+	FillerCount   int            `json:"filler_count"`   // 口头禅次数
+	FillerRate    float64        `json:"filler_rate"`    // 口头禅频率，次/分钟
+	Fillers       map[string]int `json:"fillers"`        // 各口头禅的次数
+	Score         float64        `json:"score"`          // 表达流畅度(1-10)，作为沟通表达的评分
+}
+
+// spokenWord 有发音的词（不含标点）
+type spokenWord struct {
+	speech.Word
+	chars int
+}
+
+// AnalyzeDelivery 根据识别结果的词级时间计算语速、停顿和口头禅，识别结果没有时间信息时返回nil
+func AnalyzeDelivery(transcript *speech.Transcript) *SpeechDelivery {
+	if transcript == nil {
+		return nil
+	}
+	var words []spokenWord
+	for _, segment := range transcript.Segments {
+		for _, word := range segment.Words {
+			if chars := countChars(word.Text); chars > 0 {
+				words = append(words, spokenWord{Word: word, chars: chars})
+			}
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+
+	d := &SpeechDelivery{Fillers: make(map[string]int)}
+	for i, word := range words {
+		d.Characters += word.chars
+		if filler := strings.TrimSpace(word.Text); isFiller(filler) {
+			d.Fillers[filler]++
+			d.FillerCount++
+		}
+		if i == 0 {
+			continue
+		}
+		prev := words[i-1]
+		pause := word.Start - spokenEnd(prev)
+		if pause >= pauseThreshold {
+			d.PauseCount++
+			d.PauseDuration += pause
+			d.LongestPause = max(d.LongestPause, pause)
+		}
+	}
+
+	last := words[len(words)-1]
+	end := last.End
+	if end <= last.Start {
+		end = last.Start + last.chars*typicalCharMillis
+	}
+	d.Duration = end - words[0].Start
+	if d.Duration <= 0 {
+		return nil
+	}
+	minutes := float64(d.Duration) / 60000
+	d.SpeakingRate = round1(float64(d.Characters) / minutes)
+	d.FillerRate = round1(float64(d.FillerCount) / minutes)
+	d.Score = d.score()
+	return d
+}
+
+// score 按语速、停顿占比和口头禅频率扣分，满分10分，最低1分
+func (d *SpeechDelivery) score() float64 {
+	score := float64(maxAnswerScore)
+	switch {
+	case d.SpeakingRate < minSpeakingRate:
+		score -= min((minSpeakingRate-d.SpeakingRate)/20, 4)
+	case d.SpeakingRate > maxSpeakingRate:
+		score -= min((d.SpeakingRate-maxSpeakingRate)/20, 4)
+	}
+	if ratio := float64(d.PauseDuration) / float64(d.Duration); ratio > maxPauseRatio {
+		score -= min((ratio-maxPauseRatio)*10, 3)
+	}
+	if d.FillerRate > maxFillerRate {
+		score -= min((d.FillerRate-maxFillerRate)*0.5, 3)
+	}
+	return round1(max(score, 1))
+}
+
+// String 表达情况的文字描述，用于评价输入和导出报告
+func (d *SpeechDelivery) String() string {
+	fillers := make([]string, 0, len(fillerWords))
+	for _, w := range fillerWords {
+		if n := d.Fillers[w]; n > 0 {
+			fillers = append(fillers, fmt.Sprintf("%s%d次", w, n))
+		}
+	}
+	s := fmt.Sprintf("时长%.1f秒，语速%.0f字/分钟，停顿%d次（共%.1f秒，最长%.1f秒），口头禅%d次",
+		float64(d.Duration)/1000, d.SpeakingRate, d.PauseCount, float64(d.PauseDuration)/1000, float64(d.LongestPause)/1000, d.FillerCount)
+	if len(fillers) > 0 {
+		s += "（" + strings.Join(fillers, "、") + "）"
+	}
+	return s + fmt.Sprintf("，表达流畅度%.1f/10", d.Score)
+}
+
+// spokenEnd 词的实际结束时间。识别服务只返回词的开始时间，段内词的结束时间取后一个词的开始时间，
+// 其中包含了词后的停顿，因此最多按每字maxCharMillis计算发音时间
+func spokenEnd(word spokenWord) int {
+	return word.Start + min(word.End-word.Start, word.chars*maxCharMillis)
+}
+
+// countChars 统计字数：汉字每个计一字，连续的字母和数字计一字
+func countChars(text string) int {
+	var n int
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			n++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				n++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	return n
+}
+
+func isFiller(word string) bool {
+	for _, w := range fillerWords {
+		if word == w {
+			return true
+		}
+	}
+	return false
+}
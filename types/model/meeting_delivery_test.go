@@ -0,0 +1,86 @@
+package model
+
+import (
+	"ai_jianli_go/pkg/speech"
+	"testing"
+)
+
+func TestAnalyzeDelivery(t *testing.T) {
+	transcript := &speech.Transcript{Segments: []speech.Segment{
+		{Words: []speech.Word{
+			{Text: "嗯", Start: 0, End: 300},
+			{Text: "我们", Start: 300, End: 800},
+			{Text: "使用", Start: 800, End: 1200},
+		}},
+		{Words: []speech.Word{
+			{Text: "那个", Start: 3000, End: 3500},
+			{Text: "Redis", Start: 3500, End: 4000},
+			{Text: "。", Start: 4000, End: 4000},
+		}},
+	}}
+	d := AnalyzeDelivery(transcript)
+	if d == nil {
+		t.Fatal("delivery should not be nil")
+	}
+	if d.Duration != 4000 || d.Characters != 8 || d.SpeakingRate != 120 {
+		t.Fatalf("rate mismatch: %+v", d)
+	}
+	if d.PauseCount != 1 || d.PauseDuration != 1800 || d.LongestPause != 1800 {
+		t.Fatalf("pause mismatch: %+v", d)
+	}
+	if d.FillerCount != 2 || d.Fillers["嗯"] != 1 || d.Fillers["那个"] != 1 || d.FillerRate != 30 {
+		t.Fatalf("filler mismatch: %+v", d)
+	}
+	// 语速扣2分，停顿占比45%扣2.5分，口头禅扣3分
+	if d.Score != 2.5 {
+		t.Fatalf("score mismatch: %v", d.Score)
+	}
+
+	if AnalyzeDelivery(&speech.Transcript{Text: "你好"}) != nil {
+		t.Fatal("transcript without words should have no delivery")
+	}
+}
+
+func TestAnalyzeDeliveryInferredEnd(t *testing.T) {
+	// 词的结束时间取后一个词的开始时间，按每字最长400ms估算停顿；最后一个词没有结束时间时按每字250ms估算
+	transcript := &speech.Transcript{Segments: []speech.Segment{{Words: []speech.Word{
+		{Text: "就是", Start: 0, End: 2000},
+		{Text: "缓存", Start: 2000, End: 2000},
+	}}}}
+	d := AnalyzeDelivery(transcript)
+	if d.PauseCount != 1 || d.PauseDuration != 1200 || d.Duration != 2500 || d.Fillers["就是"] != 1 {
+		t.Fatalf("delivery mismatch: %+v", d)
+	}
+}
+
+func TestSummarizeDelivery(t *testing.T) {
+	rounds := []MeetingRound{
+		{InterviewTurn: InterviewTurn{Score: AnswerScore{Correctness: 8, Depth: 6, Clarity: 9}}, Delivery: &SpeechDelivery{Score: 6}},
+		{InterviewTurn: InterviewTurn{Score: AnswerScore{Correctness: 6, Depth: 6, Clarity: 9}}},
+		{Delivery: &SpeechDelivery{Score: 8}}, // 开场，未评分
+	}
+	summary := SummarizeScores(rounds)
+	if summary.SpokenRounds != 2 || summary.Delivery != 7 || summary.Communication() != 7 || summary.Clarity != 9 {
+		t.Fatalf("summary mismatch: %+v", summary)
+	}
+
+	var report InterviewReport
+	report.ApplyScores(summary, rounds)
+	dimensions := report.CompetencyDimensions.Dimensions
+	if len(dimensions) != 3 || dimensions[2].Name != DimensionCommunication || dimensions[2].Score != 70 {
+		t.Fatalf("communication should use measured delivery: %+v", dimensions)
+	}
+	if report.AnswerAnalysis.Answers[0].Delivery == nil {
+		t.Fatal("answer item should carry delivery")
+	}
+
+	// 没有评分记录时只覆盖沟通表达
+	report = InterviewReport{CompetencyDimensions: CompetencyDimensions{Dimensions: []Dimension{
+		{Name: DimensionKnowledge, Score: 80, FullMark: 100},
+		{Name: DimensionCommunication, Score: 90, FullMark: 100},
+	}}}
+	report.ApplyScores(SummarizeScores(rounds[2:]), rounds[2:])
+	if dimensions = report.CompetencyDimensions.Dimensions; dimensions[0].Score != 80 || dimensions[1].Score != 80 {
+		t.Fatalf("unexpected dimensions: %+v", dimensions)
+	}
+}
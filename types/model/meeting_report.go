@@ -0,0 +1,278 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 报告各板块的前端图表类型
+const (
+	ChartGauge     = "gauge"
+	ChartRadar     = "radar"
+	ChartWordCloud = "wordcloud"
+	ChartDoughnut  = "doughnut"
+)
+
+// 报告分数满分
+const reportFullMark = 100
+
+// 由各轮评分汇总得出的胜任力维度，分别对应正确性、深度和表达清晰度
+const (
+	DimensionKnowledge     = "专业知识"
+	DimensionDepth         = "思考深度"
+	DimensionCommunication = "沟通表达"
+)
+
+// 面试评价报告表，同一场面试（或阶段）每次重新生成报告保存为新版本
+type MeetingReport struct {
+	ID        uint            `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	DeletedAt gorm.DeletedAt  `json:"deleted_at" gorm:"index"`
+	MeetingID uint            `json:"meeting_id" gorm:"uniqueIndex:idx_meeting_report_version"` // 面试ID
+	StageID   uint            `json:"stage_id" gorm:"uniqueIndex:idx_meeting_report_version"`   // 阶段ID，整场面试的报告为0
+	Version   int             `json:"version" gorm:"uniqueIndex:idx_meeting_report_version"`    // 版本，从1开始
+	Scores    ScoreSummary    `json:"scores" gorm:"serializer:json;type:text"`                  // 生成报告时的评分汇总
+	Report    InterviewReport `json:"report" gorm:"serializer:json;type:text"`                  // 报告内容
+}
+
+// InterviewReport 面试评价报告，模型生成的内容经过解析和校验，分数以各轮回答的评分汇总为准
+type InterviewReport struct {
+	OverallEvaluation    OverallEvaluation    `json:"overallEvaluation"`    // 总体评价
+	CompetencyDimensions CompetencyDimensions `json:"competencyDimensions"` // 胜任力维度得分
+	AnswerAnalysis       AnswerAnalysis       `json:"answerAnalysis"`       // 答题内容分析
+	JDMatch              JDMatch              `json:"jdMatch"`              // 岗位匹配度
+	ImprovablePoints     []string             `json:"improvablePoints"`     // 可改进点
+}
+
+type OverallEvaluation struct {
+	Score     float64 `json:"score"`     // 总体得分
+	MaxScore  float64 `json:"maxScore"`  // 满分，默认100
+	Rating    string  `json:"rating"`    // 评级: 优秀/良好/合格/待提高
+	Comment   string  `json:"comment"`   // 面试文字评价
+	ChartType string  `json:"chartType"` // 默认gauge
+}
+
+type CompetencyDimensions struct {
+	ChartType  string      `json:"chartType"` // 默认radar
+	Dimensions []Dimension `json:"dimensions"`
+}
+
+type Dimension struct {
+	Name     string  `json:"name"`
+	Score    float64 `json:"score"`
+	FullMark float64 `json:"fullMark"` // 默认100
+}
+
+type AnswerAnalysis struct {
+	KeywordCloud KeywordCloud `json:"keywordCloud"` // 回答关键词
+	Answers      []AnswerItem `json:"answers"`      // 逐题评分，由服务端根据面试轮次填写
+}
+
+type KeywordCloud struct {
+	ChartType string    `json:"chartType"` // 默认wordcloud
+	Keywords  []Keyword `json:"keywords"`
+}
+
+type Keyword struct {
+	Text  string  `json:"text"`
+	Value float64 `json:"value"` // 权重
+}
+
+// AnswerItem 单个回答的评分
+type AnswerItem struct {
+	StageID  uint            `json:"stageId"`
+	Round    int             `json:"round"`
+	Question string          `json:"question"` // 回答的问题，阶段的第一轮为空
+	Answer   string          `json:"answer"`
+	Scored   bool            `json:"scored"` // 是否有评分，开场问候等不评分
+	Score    float64         `json:"score"`  // 百分制得分
+	Comment  string          `json:"comment"`
+	Delivery *SpeechDelivery `json:"delivery,omitempty"` // 语音回答的表达情况
+}
+
+type JDMatch struct {
+	ChartType       string   `json:"chartType"`       // 默认doughnut
+	MatchPercentage float64  `json:"matchPercentage"` // 匹配度(0-100)
+	MatchedKeywords []string `json:"matchedKeywords"` // 匹配的岗位要求
+	MissingKeywords []string `json:"missingKeywords"` // 欠缺的岗位要求
+}
+
+// ParseInterviewReport 解析模型返回的报告JSON并校验。兼容```json代码块，
+// JSON前后有多余文字时截取第一个{到最后一个}之间的内容再解析
+func ParseInterviewReport(content string) (*InterviewReport, error) {
+	content = strings.TrimSpace(content)
+	content, _ = strings.CutPrefix(content, "```json")
+	content, _ = strings.CutPrefix(content, "```")
+	content, _ = strings.CutSuffix(content, "```")
+	content = strings.TrimSpace(content)
+
+	report := &InterviewReport{}
+	if err := json.Unmarshal([]byte(content), report); err != nil {
+		start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+		if start < 0 || end <= start {
+			return nil, fmt.Errorf("unmarshal interview report failed: %w", err)
+		}
+		report = &InterviewReport{}
+		if err = json.Unmarshal([]byte(content[start:end+1]), report); err != nil {
+			return nil, fmt.Errorf("unmarshal interview report failed: %w", err)
+		}
+	}
+	if err := report.Validate(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Validate 校验报告并补全默认值，去除空白项
+func (r *InterviewReport) Validate() error {
+	overall := &r.OverallEvaluation
+	overall.Comment = strings.TrimSpace(overall.Comment)
+	overall.Rating = strings.TrimSpace(overall.Rating)
+	if overall.MaxScore <= 0 {
+		overall.MaxScore = reportFullMark
+	}
+	if overall.Score < 0 || overall.Score > overall.MaxScore {
+		return fmt.Errorf("overallEvaluation.score %v out of range [0, %v]", overall.Score, overall.MaxScore)
+	}
+	if overall.Comment == "" {
+		return fmt.Errorf("overallEvaluation.comment is empty")
+	}
+	if overall.Rating == "" {
+		overall.Rating = ratingOf(overall.Score / overall.MaxScore * reportFullMark)
+	}
+	overall.ChartType = defaultString(overall.ChartType, ChartGauge)
+
+	r.CompetencyDimensions.ChartType = defaultString(r.CompetencyDimensions.ChartType, ChartRadar)
+	if len(r.CompetencyDimensions.Dimensions) == 0 {
+		return fmt.Errorf("competencyDimensions.dimensions is empty")
+	}
+	for i := range r.CompetencyDimensions.Dimensions {
+		d := &r.CompetencyDimensions.Dimensions[i]
+		d.Name = strings.TrimSpace(d.Name)
+		if d.FullMark <= 0 {
+			d.FullMark = reportFullMark
+		}
+		if d.Name == "" {
+			return fmt.Errorf("competencyDimensions.dimensions[%d].name is empty", i)
+		}
+		if d.Score < 0 || d.Score > d.FullMark {
+			return fmt.Errorf("dimension %s score %v out of range [0, %v]", d.Name, d.Score, d.FullMark)
+		}
+	}
+
+	cloud := &r.AnswerAnalysis.KeywordCloud
+	cloud.ChartType = defaultString(cloud.ChartType, ChartWordCloud)
+	keywords := make([]Keyword, 0, len(cloud.Keywords))
+	for _, k := range cloud.Keywords {
+		if k.Text = strings.TrimSpace(k.Text); k.Text != "" && k.Value > 0 {
+			keywords = append(keywords, k)
+		}
+	}
+	cloud.Keywords = keywords
+
+	match := &r.JDMatch
+	match.ChartType = defaultString(match.ChartType, ChartDoughnut)
+	if match.MatchPercentage < 0 || match.MatchPercentage > 100 {
+		return fmt.Errorf("jdMatch.matchPercentage %v out of range [0, 100]", match.MatchPercentage)
+	}
+	match.MatchedKeywords = compactStrings(match.MatchedKeywords)
+	match.MissingKeywords = compactStrings(match.MissingKeywords)
+
+	r.ImprovablePoints = compactStrings(r.ImprovablePoints)
+	return nil
+}
+
+// ApplyScores 用各轮回答的评分覆盖报告中的分数：有评分时总分和胜任力维度取评分汇总，
+// 有语音回答时沟通表达取实测的表达流畅度，并按面试轮次填写逐题评分
+func (r *InterviewReport) ApplyScores(summary ScoreSummary, rounds []MeetingRound) {
+	if summary.ScoredRounds > 0 {
+		r.OverallEvaluation.Score = summary.Total
+		r.OverallEvaluation.MaxScore = reportFullMark
+		r.OverallEvaluation.Rating = ratingOf(summary.Total)
+		// 维度得分由各项平均分(1-10)换算为百分制
+		r.CompetencyDimensions.Dimensions = []Dimension{
+			{Name: DimensionKnowledge, Score: round1(summary.Correctness * 10), FullMark: reportFullMark},
+			{Name: DimensionDepth, Score: round1(summary.Depth * 10), FullMark: reportFullMark},
+			{Name: DimensionCommunication, Score: round1(summary.Communication() * 10), FullMark: reportFullMark},
+		}
+	} else if summary.SpokenRounds > 0 {
+		r.setDimension(DimensionCommunication, round1(summary.Delivery*10))
+	}
+
+	r.AnswerAnalysis.Answers = make([]AnswerItem, 0, len(rounds))
+	for i, round := range rounds {
+		item := AnswerItem{
+			StageID:  round.StageID,
+			Round:    round.Round,
+			Answer:   round.Answer,
+			Scored:   round.Score.Scored(),
+			Comment:  round.Score.Comment,
+			Delivery: round.Delivery,
+		}
+		// 每轮回答的是同一阶段上一轮提出的问题
+		if i > 0 && rounds[i-1].StageID == round.StageID {
+			item.Question = rounds[i-1].NextQuestion
+		}
+		if item.Scored {
+			item.Score = round.Score.Total()
+		}
+		r.AnswerAnalysis.Answers = append(r.AnswerAnalysis.Answers, item)
+	}
+}
+
+// setDimension 设置胜任力维度得分，没有该维度时添加
+func (r *InterviewReport) setDimension(name string, score float64) {
+	for i := range r.CompetencyDimensions.Dimensions {
+		if r.CompetencyDimensions.Dimensions[i].Name == name {
+			r.CompetencyDimensions.Dimensions[i].Score = score
+			r.CompetencyDimensions.Dimensions[i].FullMark = reportFullMark
+			return
+		}
+	}
+	r.CompetencyDimensions.Dimensions = append(r.CompetencyDimensions.Dimensions, Dimension{Name: name, Score: score, FullMark: reportFullMark})
+}
+
+// Brief 报告摘要，用于生成跨阶段综合评价
+func (r *InterviewReport) Brief() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "总体得分：%.1f/%.0f（%s）\n", r.OverallEvaluation.Score, r.OverallEvaluation.MaxScore, r.OverallEvaluation.Rating)
+	for _, d := range r.CompetencyDimensions.Dimensions {
+		fmt.Fprintf(&sb, "%s：%.1f/%.0f\n", d.Name, d.Score, d.FullMark)
+	}
+	sb.WriteString("评价：" + r.OverallEvaluation.Comment + "\n")
+	fmt.Fprintf(&sb, "岗位匹配度：%.0f%%", r.JDMatch.MatchPercentage)
+	if len(r.JDMatch.MissingKeywords) > 0 {
+		sb.WriteString("，欠缺：" + strings.Join(r.JDMatch.MissingKeywords, "、"))
+	}
+	sb.WriteString("\n")
+	for _, p := range r.ImprovablePoints {
+		sb.WriteString("可改进：" + p + "\n")
+	}
+	return sb.String()
+}
+
+// ratingOf 百分制得分对应的评级
+func ratingOf(score float64) string {
+	switch {
+	case score >= 90:
+		return "优秀"
+	case score >= 75:
+		return "良好"
+	case score >= 60:
+		return "合格"
+	default:
+		return "待提高"
+	}
+}
+
+func defaultString(v, def string) string {
+	if v = strings.TrimSpace(v); v == "" {
+		return def
+	}
+	return v
+}
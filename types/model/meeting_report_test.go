@@ -0,0 +1,77 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+const reportJSON = `{
+  "overallEvaluation": {"score": 80, "comment": " 表现良好 "},
+  "competencyDimensions": {"dimensions": [{"name": "专业知识", "score": 85}]},
+  "answerAnalysis": {"keywordCloud": {"keywords": [{"text": "Redis", "value": 3}, {"text": " ", "value": 1}]}},
+  "jdMatch": {"matchPercentage": 70, "matchedKeywords": ["Go", ""]},
+  "improvablePoints": ["加强分布式知识", " "]
+}`
+
+func TestParseInterviewReport(t *testing.T) {
+	for name, content := range map[string]string{
+		"plain":  reportJSON,
+		"fenced": "```json\n" + reportJSON + "\n```",
+		"extra":  "以下是评价报告：\n" + reportJSON + "\n以上。",
+	} {
+		report, err := ParseInterviewReport(content)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if report.OverallEvaluation.Comment != "表现良好" || report.OverallEvaluation.Rating != "良好" {
+			t.Fatalf("%s: overall mismatch: %+v", name, report.OverallEvaluation)
+		}
+		if report.OverallEvaluation.MaxScore != 100 || report.OverallEvaluation.ChartType != ChartGauge {
+			t.Fatalf("%s: defaults not filled: %+v", name, report.OverallEvaluation)
+		}
+		if report.CompetencyDimensions.ChartType != ChartRadar || report.CompetencyDimensions.Dimensions[0].FullMark != 100 {
+			t.Fatalf("%s: dimension defaults not filled: %+v", name, report.CompetencyDimensions)
+		}
+		if len(report.AnswerAnalysis.KeywordCloud.Keywords) != 1 || len(report.JDMatch.MatchedKeywords) != 1 || len(report.ImprovablePoints) != 1 {
+			t.Fatalf("%s: blank items not removed: %+v", name, report)
+		}
+	}
+}
+
+func TestParseInterviewReportInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"not json":        "无法生成评价",
+		"score range":     strings.Replace(reportJSON, `"score": 80`, `"score": 120`, 1),
+		"empty comment":   strings.Replace(reportJSON, `" 表现良好 "`, `""`, 1),
+		"no dimensions":   strings.Replace(reportJSON, `[{"name": "专业知识", "score": 85}]`, `[]`, 1),
+		"match out range": strings.Replace(reportJSON, `"matchPercentage": 70`, `"matchPercentage": -5`, 1),
+	} {
+		if _, err := ParseInterviewReport(content); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestApplyScores(t *testing.T) {
+	report, err := ParseInterviewReport(reportJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rounds := []MeetingRound{
+		{StageID: 1, Round: 1, Answer: "你好", InterviewTurn: InterviewTurn{NextQuestion: "介绍一下Redis"}},
+		{StageID: 1, Round: 2, Answer: "内存数据库", InterviewTurn: InterviewTurn{Score: AnswerScore{Correctness: 8, Depth: 6, Clarity: 7}}},
+	}
+	report.ApplyScores(SummarizeScores(rounds), rounds)
+
+	if report.OverallEvaluation.Score != 72 || report.OverallEvaluation.Rating != "合格" {
+		t.Fatalf("overall not overwritten: %+v", report.OverallEvaluation)
+	}
+	dimensions := report.CompetencyDimensions.Dimensions
+	if len(dimensions) != 3 || dimensions[0].Name != DimensionKnowledge || dimensions[0].Score != 80 || dimensions[2].Score != 70 {
+		t.Fatalf("dimensions mismatch: %+v", dimensions)
+	}
+	answers := report.AnswerAnalysis.Answers
+	if len(answers) != 2 || answers[0].Scored || answers[1].Question != "介绍一下Redis" || answers[1].Score != 72 {
+		t.Fatalf("answers mismatch: %+v", answers)
+	}
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"math"
+	"strings"
+)
+
+// 评分依据
+const (
+	ScoreBasisReference = "reference" // 题库题目的参考答案
+	ScoreBasisWiki      = "wiki"      // 知识库检索内容
+	ScoreBasisResume    = "resume"    // 简历内容
+)
+
+// 单个回答的评分维度权重，总分为各项加权平均换算的百分制
+const (
+	weightCorrectness = 0.5
+	weightDepth       = 0.3
+	weightClarity     = 0.2
+
+	maxAnswerScore = 10
+)
+
+// AnswerScore 按评分标准对单个回答的评分，各项1-10分。
+// 各项均为0表示本轮回答不是对面试问题的作答（如开场问候），不参与汇总
+type AnswerScore struct {
+	Correctness int    `json:"correctness"` // 正确性，有参考答案时以参考答案为准
+	Depth       int    `json:"depth"`       // 深度
+	Clarity     int    `json:"clarity"`     // 表达清晰度
+	Comment     string `json:"comment"`     // 评分理由
+	Basis       string `json:"basis"`       // 评分依据: reference/wiki/resume，由服务端根据本轮上下文填写
+}
+
+// Scored 本轮回答是否有评分
+func (s AnswerScore) Scored() bool {
+	return s.Correctness > 0 || s.Depth > 0 || s.Clarity > 0
+}
+
+// Total 百分制总分
+func (s AnswerScore) Total() float64 {
+	total := float64(s.Correctness)*weightCorrectness + float64(s.Depth)*weightDepth + float64(s.Clarity)*weightClarity
+	return round1(total * 100 / maxAnswerScore)
+}
+
+// normalize 各项分数限制在0-10
+func (s *AnswerScore) normalize() {
+	s.Correctness = min(max(s.Correctness, 0), maxAnswerScore)
+	s.Depth = min(max(s.Depth, 0), maxAnswerScore)
+	s.Clarity = min(max(s.Clarity, 0), maxAnswerScore)
+	s.Comment = strings.TrimSpace(s.Comment)
+}
+
+// ScoreSummary 各轮回答评分的汇总
+type ScoreSummary struct {
+	Rounds       int     `json:"rounds"`        // 面试轮数
+	ScoredRounds int     `json:"scored_rounds"` // 有评分的轮数
+	Correctness  float64 `json:"correctness"`   // 正确性平均分(1-10)
+	Depth        float64 `json:"depth"`         // 深度平均分(1-10)
+	Clarity      float64 `json:"clarity"`       // 表达清晰度平均分(1-10)
+	Total        float64 `json:"total"`         // 百分制平均总分
+	SpokenRounds int     `json:"spoken_rounds"` // 有语音表达数据的轮数
+	Delivery     float64 `json:"delivery"`      // 语音回答表达流畅度平均分(1-10)
+}
+
+// Communication 沟通表达得分(1-10)：有语音回答时取实测的表达流畅度，否则取表达清晰度评分
+func (s ScoreSummary) Communication() float64 {
+	if s.SpokenRounds > 0 {
+		return s.Delivery
+	}
+	return s.Clarity
+}
+
+// SummarizeScores 汇总各轮回答的评分，未评分的轮次不计入平均分；
+// 表达流畅度按所有语音回答的轮次平均
+func SummarizeScores(rounds []MeetingRound) ScoreSummary {
+	summary := ScoreSummary{Rounds: len(rounds)}
+	var correctness, depth, clarity, total, delivery float64
+	for _, round := range rounds {
+		if round.Delivery != nil {
+			summary.SpokenRounds++
+			delivery += round.Delivery.Score
+		}
+		if !round.Score.Scored() {
+			continue
+		}
+		summary.ScoredRounds++
+		correctness += float64(round.Score.Correctness)
+		depth += float64(round.Score.Depth)
+		clarity += float64(round.Score.Clarity)
+		total += round.Score.Total()
+	}
+	if summary.SpokenRounds > 0 {
+		summary.Delivery = round1(delivery / float64(summary.SpokenRounds))
+	}
+	if summary.ScoredRounds == 0 {
+		return summary
+	}
+	n := float64(summary.ScoredRounds)
+	summary.Correctness = round1(correctness / n)
+	summary.Depth = round1(depth / n)
+	summary.Clarity = round1(clarity / n)
+	summary.Total = round1(total / n)
+	return summary
+}
+
+// round1 保留一位小数
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
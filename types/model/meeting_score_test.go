@@ -0,0 +1,48 @@
+package model
+
+import "testing"
+
+func TestAnswerScoreTotal(t *testing.T) {
+	score := AnswerScore{Correctness: 8, Depth: 6, Clarity: 7}
+	// (8*0.5 + 6*0.3 + 7*0.2) * 10
+	if got := score.Total(); got != 72 {
+		t.Fatalf("total mismatch: %v", got)
+	}
+	if (AnswerScore{}).Scored() {
+		t.Fatalf("zero score should not be scored")
+	}
+}
+
+func TestParseInterviewTurnScore(t *testing.T) {
+	content := `{"next_question":"q","score":{"correctness":12,"depth":-1,"clarity":7,"comment":" 基本正确 "}}`
+	turn, err := ParseInterviewTurn(content, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if turn.Score.Correctness != 10 || turn.Score.Depth != 0 || turn.Score.Comment != "基本正确" {
+		t.Fatalf("score not normalized: %+v", turn.Score)
+	}
+}
+
+func TestSummarizeScores(t *testing.T) {
+	rounds := []MeetingRound{
+		{InterviewTurn: InterviewTurn{}}, // 开场，未评分
+		{InterviewTurn: InterviewTurn{Score: AnswerScore{Correctness: 8, Depth: 6, Clarity: 7}}},
+		{InterviewTurn: InterviewTurn{Score: AnswerScore{Correctness: 5, Depth: 3, Clarity: 8}}},
+	}
+	summary := SummarizeScores(rounds)
+	if summary.Rounds != 3 || summary.ScoredRounds != 2 {
+		t.Fatalf("round count mismatch: %+v", summary)
+	}
+	if summary.Correctness != 6.5 || summary.Depth != 4.5 || summary.Clarity != 7.5 {
+		t.Fatalf("average mismatch: %+v", summary)
+	}
+	// (72 + 50) / 2
+	if summary.Total != 61 {
+		t.Fatalf("total mismatch: %v", summary.Total)
+	}
+
+	if empty := SummarizeScores(rounds[:1]); empty.ScoredRounds != 0 || empty.Total != 0 {
+		t.Fatalf("expected empty summary, got %+v", empty)
+	}
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 面试阶段类型
+const (
+	StageTypeHR         = "hr"         // HR初筛
+	StageTypeTechnical  = "technical"  // 技术面
+	StageTypeBehavioral = "behavioral" // 行为面
+)
+
+// 各阶段类型的默认面试官人设
+var defaultStagePersonas = map[string]string{
+	StageTypeHR:         "你是一名资深HR面试官，负责候选人初筛，重点考察求职动机、职业规划、沟通表达与稳定性",
+	StageTypeTechnical:  "你是一个专业技术面试官，重点考察候选人的专业知识深度、技术广度和解决问题的能力",
+	StageTypeBehavioral: "你是一名行为面试官，使用STAR法则考察候选人的团队协作、抗压能力、冲突处理和过往经历",
+}
+
+// 面试阶段表，一场面试按Seq顺序依次进行各阶段，每个阶段有独立的对话、状态和评价
+type MeetingStage struct {
+	ID              uint           `json:"id" gorm:"primarykey"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	MeetingID       uint           `json:"meeting_id" gorm:"index"`               // 面试ID
+	Seq             int            `json:"seq"`                                   // 阶段顺序，从0开始
+	Name            string         `json:"name"`                                  // 阶段名称
+	Type            string         `json:"type"`                                  // 阶段类型: hr/technical/behavioral
+	Persona         string         `json:"persona"`                               // 面试官人设，为空时使用阶段类型的默认人设
+	WikiID          uint           `json:"wiki_id"`                               // 知识库ID
+	WikiFolderID    uint           `json:"wiki_folder_id"`                        // 知识库文件夹ID，不为0时只从该文件夹检索
+	Rubric          string         `json:"rubric"`                                // 评分标准
+	Plan            InterviewPlan  `json:"plan" gorm:"serializer:json;type:text"` // 阶段面试计划
+	Status          string         `json:"status"`                                // 阶段状态
+	InterviewRecord string         `json:"interview_record"`                      // 阶段面试记录
+	InterviewNumber int            `json:"interview_number"`                      // 阶段对话次数
+}
+
+// GetPlan 获取补全默认值后的阶段面试计划
+func (s *MeetingStage) GetPlan() InterviewPlan {
+	plan := s.Plan
+	plan.fillDefault()
+	return plan
+}
+
+// GetPersona 获取阶段面试官人设
+func (s *MeetingStage) GetPersona() string {
+	if s.Persona != "" {
+		return s.Persona
+	}
+	if persona, ok := defaultStagePersonas[s.Type]; ok {
+		return persona
+	}
+	return defaultStagePersonas[StageTypeTechnical]
+}
+
+// StageReport 单个阶段的评价
+type StageReport struct {
+	StageID uint             `json:"stage_id"`
+	Seq     int              `json:"seq"`
+	Name    string           `json:"name"`
+	Type    string           `json:"type"`
+	Status  string           `json:"status"`
+	Report  *InterviewReport `json:"report"`  // 阶段评价报告，未进行的阶段为空
+	Version int              `json:"version"` // 阶段评价报告版本
+}
+
+// CombinedReport 多阶段面试综合报告
+type CombinedReport struct {
+	MeetingID uint          `json:"meeting_id"`
+	Candidate string        `json:"candidate"`
+	Position  string        `json:"position"`
+	Stages    []StageReport `json:"stages"`
+	Summary   string        `json:"summary"` // 跨阶段综合评价
+}
@@ -0,0 +1,31 @@
+package model
+
+import "testing"
+
+func TestMeetingStageGetPersona(t *testing.T) {
+	stage := &MeetingStage{Type: StageTypeHR}
+	if stage.GetPersona() != defaultStagePersonas[StageTypeHR] {
+		t.Fatalf("hr stage should use default hr persona, got %q", stage.GetPersona())
+	}
+
+	stage.Persona = "你是一名产品总监"
+	if stage.GetPersona() != "你是一名产品总监" {
+		t.Fatalf("custom persona should win, got %q", stage.GetPersona())
+	}
+
+	unknown := &MeetingStage{Type: "unknown"}
+	if unknown.GetPersona() != defaultStagePersonas[StageTypeTechnical] {
+		t.Fatalf("unknown stage type should fall back to technical persona, got %q", unknown.GetPersona())
+	}
+}
+
+func TestMeetingStageGetPlan(t *testing.T) {
+	stage := &MeetingStage{Plan: InterviewPlan{TotalRounds: 5}}
+	plan := stage.GetPlan()
+	if plan.TotalRounds != 5 || plan.MaxFollowUps != 4 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if stage.Plan.MaxFollowUps != 0 {
+		t.Fatalf("GetPlan should not modify stored plan")
+	}
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInterviewTurn(t *testing.T) {
+	content := "```json\n" + `{
+  "evaluation": "回答基本正确",
+  "strengths": ["概念清晰", " "],
+  "weaknesses": ["缺少实际案例"],
+  "knowledge_points": ["Redis持久化", "AOF"],
+  "next_question": "  AOF重写的触发条件是什么？ ",
+  "follow_up_depth": 1
+}` + "\n```"
+
+	turn, err := ParseInterviewTurn(content, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if turn.NextQuestion != "AOF重写的触发条件是什么？" {
+		t.Fatalf("next_question not trimmed: %q", turn.NextQuestion)
+	}
+	if len(turn.Strengths) != 1 {
+		t.Fatalf("blank strengths should be dropped, got %v", turn.Strengths)
+	}
+	if turn.KnowledgeQuery() != "Redis持久化 AOF" {
+		t.Fatalf("knowledge query mismatch: %q", turn.KnowledgeQuery())
+	}
+}
+
+func TestParseInterviewTurnInvalid(t *testing.T) {
+	cases := map[string]string{
+		"not json":        "可追问的知识点：Redis",
+		"empty question":  `{"evaluation":"ok","next_question":""}`,
+		"depth too large": `{"next_question":"q","follow_up_depth":5}`,
+	}
+	for name, content := range cases {
+		if _, err := ParseInterviewTurn(content, 4); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestTurnStream(t *testing.T) {
+	content := "```json\n" + `{"evaluation": "回答\"基本\"正确\n", "strengths": ["思路清晰", "[]"], "follow_up_depth": 12, "next_question": "什么是MVCC\uff1f"}` + "\n```"
+	var s TurnStream
+	var names, values []string
+	deltas := make(map[string]string)
+	var deltaCount int
+	// 逐字节输出，模拟模型的流式返回
+	for i := 0; i < len(content); i++ {
+		for _, f := range s.Write(content[i : i+1]) {
+			if !f.Completed() {
+				deltas[f.Name] += f.Delta
+				deltaCount++
+				continue
+			}
+			names = append(names, f.Name)
+			values = append(values, string(f.Value))
+		}
+	}
+	if strings.Join(names, ",") != "evaluation,strengths,follow_up_depth,next_question" {
+		t.Fatalf("fields mismatch: %v", names)
+	}
+	if values[0] != `"回答\"基本\"正确\n"` || values[1] != `["思路清晰", "[]"]` || values[2] != "12" || values[3] != `"什么是MVCC\uff1f"` {
+		t.Fatalf("values mismatch: %v", values)
+	}
+	if deltas["evaluation"] != "回答\"基本\"正确\n" || deltas["next_question"] != "什么是MVCC？" || len(deltas) != 2 {
+		t.Fatalf("deltas mismatch: %q", deltas)
+	}
+	// 字符串字段逐字返回，而不是生成完整后一次返回
+	if deltaCount < 10 {
+		t.Fatalf("expected incremental deltas, got %d", deltaCount)
+	}
+}
+
+func TestTurnStreamSurrogate(t *testing.T) {
+	var s TurnStream
+	var text string
+	for _, chunk := range []string{`{"evaluation": "好\ud83d`, `\ude00", "follow_up_depth": 1}`} {
+		for _, f := range s.Write(chunk) {
+			text += f.Delta
+		}
+	}
+	if text != "好😀" {
+		t.Fatalf("delta mismatch: %q", text)
+	}
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 题目审核状态
+const (
+	QuestionPending  = "pending"  // 待审核，生成后的状态
+	QuestionApproved = "approved" // 已通过，AI面试时可以抽取
+	QuestionRejected = "rejected" // 已驳回
+)
+
+// 题目难度范围，与面试计划的难度一致
+const (
+	minQuestionDifficulty = 1
+	maxQuestionDifficulty = 5
+)
+
+// Question 题库题目，由知识库文章的分块生成，审核通过后供AI面试抽取
+type Question struct {
+	ID              uint           `json:"id" gorm:"primarykey"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	UserID          uint           `json:"user_id" gorm:"index"`                  // 用户ID
+	RootID          uint           `json:"root_id" gorm:"index"`                  // 知识库ID
+	WikiID          uint           `json:"wiki_id" gorm:"index"`                  // 文章ID
+	ChunkID         int            `json:"chunk_id"`                              // 分块序号，与检索引用的chunk_id一致
+	ChunkKey        string         `json:"chunk_key"`                             // 分块在索引中的文档ID
+	Content         string         `json:"content" gorm:"type:text"`              // 题目
+	ReferenceAnswer string         `json:"reference_answer" gorm:"type:text"`     // 参考答案
+	Difficulty      int            `json:"difficulty"`                            // 难度1-5
+	Tags            []string       `json:"tags" gorm:"serializer:json;type:text"` // 标签
+	Status          string         `json:"status" gorm:"index"`                   // 审核状态
+	UsedCount       int            `json:"used_count"`                            // 被AI面试抽取的次数
+}
+
+func (q *Question) TableName() string {
+	return "question"
+}
+
+// Validate 校验题目，去除空白标签
+func (q *Question) Validate() error {
+	q.Content = strings.TrimSpace(q.Content)
+	q.ReferenceAnswer = strings.TrimSpace(q.ReferenceAnswer)
+	q.Tags = compactStrings(q.Tags)
+	if q.Content == "" {
+		return fmt.Errorf("question content is empty")
+	}
+	if q.ReferenceAnswer == "" {
+		return fmt.Errorf("reference answer is empty")
+	}
+	if q.Difficulty < minQuestionDifficulty || q.Difficulty > maxQuestionDifficulty {
+		return fmt.Errorf("difficulty %d out of range [%d, %d]", q.Difficulty, minQuestionDifficulty, maxQuestionDifficulty)
+	}
+	return nil
+}
+
+// ValidQuestionStatus 是否为合法的审核状态
+func ValidQuestionStatus(status string) bool {
+	switch status {
+	case QuestionPending, QuestionApproved, QuestionRejected:
+		return true
+	}
+	return false
+}
+
+// GeneratedQuestion 模型根据分块生成的题目
+type GeneratedQuestion struct {
+	Question   string   `json:"question"`   // 题目
+	Answer     string   `json:"answer"`     // 参考答案
+	Difficulty int      `json:"difficulty"` // 难度1-5
+	Tags       []string `json:"tags"`       // 标签
+}
+
+// ParseGeneratedQuestions 解析模型生成的题目列表，兼容```json代码块。
+// 不合法的题目直接丢弃，难度超出范围时取最近的合法值，没有合法题目时返回错误
+func ParseGeneratedQuestions(content string) ([]*Question, error) {
+	content = strings.TrimSpace(content)
+	content, _ = strings.CutPrefix(content, "```json")
+	content, _ = strings.CutPrefix(content, "```")
+	content, _ = strings.CutSuffix(content, "```")
+
+	var generated []GeneratedQuestion
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &generated); err != nil {
+		return nil, fmt.Errorf("unmarshal generated questions failed: %w", err)
+	}
+
+	questions := make([]*Question, 0, len(generated))
+	for _, g := range generated {
+		q := &Question{
+			Content:         g.Question,
+			ReferenceAnswer: g.Answer,
+			Difficulty:      min(max(g.Difficulty, minQuestionDifficulty), maxQuestionDifficulty),
+			Tags:            g.Tags,
+			Status:          QuestionPending,
+		}
+		if err := q.Validate(); err != nil {
+			continue
+		}
+		questions = append(questions, q)
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("no valid question generated")
+	}
+	return questions, nil
+}
+
+// PickQuestion 从候选题目中选出最接近目标难度的题目，难度相同时优先抽取次数少、创建早的题目，
+// 没有候选题目时返回nil
+func PickQuestion(candidates []*Question, difficulty int) *Question {
+	var best *Question
+	for _, q := range candidates {
+		if best == nil || questionLess(q, best, difficulty) {
+			best = q
+		}
+	}
+	return best
+}
+
+func questionLess(a, b *Question, difficulty int) bool {
+	da, db := abs(a.Difficulty-difficulty), abs(b.Difficulty-difficulty)
+	if da != db {
+		return da < db
+	}
+	if a.UsedCount != b.UsedCount {
+		return a.UsedCount < b.UsedCount
+	}
+	return a.ID < b.ID
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,61 @@
+package model
+
+import "testing"
+
+func TestParseGeneratedQuestions(t *testing.T) {
+	content := "```json\n" + `[
+  {"question": " 什么是AOF重写？ ", "answer": "压缩AOF文件", "difficulty": 3, "tags": ["Redis", " "]},
+  {"question": "RDB的优点", "answer": "恢复快", "difficulty": 9},
+  {"question": "", "answer": "缺少题目"},
+  {"question": "缺少答案", "answer": ""}
+]` + "\n```"
+
+	questions, err := ParseGeneratedQuestions(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(questions) != 2 {
+		t.Fatalf("invalid questions should be dropped, got %d", len(questions))
+	}
+	if questions[0].Content != "什么是AOF重写？" || len(questions[0].Tags) != 1 {
+		t.Fatalf("question not normalized: %+v", questions[0])
+	}
+	if questions[1].Difficulty != 5 {
+		t.Fatalf("difficulty should be clamped to 5, got %d", questions[1].Difficulty)
+	}
+	for _, q := range questions {
+		if q.Status != QuestionPending {
+			t.Fatalf("generated question should be pending, got %s", q.Status)
+		}
+	}
+
+	if _, err = ParseGeneratedQuestions(`[{"question":"","answer":""}]`); err == nil {
+		t.Fatalf("expected error when no valid question")
+	}
+	if _, err = ParseGeneratedQuestions("可以考察Redis持久化"); err == nil {
+		t.Fatalf("expected error for non-json content")
+	}
+}
+
+func TestPickQuestion(t *testing.T) {
+	if PickQuestion(nil, 3) != nil {
+		t.Fatalf("expected nil without candidates")
+	}
+
+	candidates := []*Question{
+		{ID: 1, Difficulty: 1},
+		{ID: 2, Difficulty: 4, UsedCount: 2},
+		{ID: 3, Difficulty: 4, UsedCount: 1},
+		{ID: 4, Difficulty: 2, UsedCount: 1},
+		{ID: 5, Difficulty: 2, UsedCount: 1},
+	}
+	if q := PickQuestion(candidates, 4); q.ID != 3 {
+		t.Fatalf("expected least used question with same difficulty, got %d", q.ID)
+	}
+	if q := PickQuestion(candidates, 2); q.ID != 4 {
+		t.Fatalf("expected earliest question on tie, got %d", q.ID)
+	}
+	if q := PickQuestion(candidates[:1], 5); q.ID != 1 {
+		t.Fatalf("expected closest difficulty, got %d", q.ID)
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"ai_jianli_go/logs"
 	"context"
 
-	"github.com/cloudwego/eino-ext/components/model/openai"
+	chatmodel "github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/schema"
 	"gorm.io/gorm"
@@ -56,7 +56,7 @@ func NewTemplate(name string, content string) *Template {
 }
 
 // SetShowContent 设置前端展示内容
-func (r *Template) SetShowContent(model *openai.ChatModel) {
+func (r *Template) SetShowContent(model chatmodel.BaseChatModel) {
 	if r.Content == "" {
 		return
 	}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"ai_jianli_go/pkg/llm"
+	"testing"
+)
+
+func TestTemplateSetShowContent(t *testing.T) {
+	fake := llm.NewFakeChatModel(map[string]string{
+		"{{ name }}": "<h1>张三</h1>",
+	}, "")
+
+	template := NewTemplate("默认模板", "<h1>{{ name }}</h1>")
+	template.SetShowContent(fake)
+	if template.ShowContent != "<h1>张三</h1>" {
+		t.Fatalf("show content mismatch: %q", template.ShowContent)
+	}
+
+	// 模板内容为空时不调用模型
+	empty := NewTemplate("空模板", "")
+	empty.SetShowContent(fake)
+	if len(fake.Calls()) != 1 {
+		t.Fatalf("expected 1 call, got %d", len(fake.Calls()))
+	}
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"ai_jianli_go/pkg/speech"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	speechRecognitionKey = "speech_recognition:%d:%s" // 用户的语音识别结果，提交回答时按识别ID引用
+	// 识别结果的保留时间，覆盖一轮回答从录音到提交的时间
+	speechRecognitionTTL = 30 * time.Minute
+)
+
+// ErrRecognitionNotFound 识别结果不存在或已过期
+var ErrRecognitionNotFound = errors.New("speech recognition not found")
+
+// SaveRecognition 在服务端保存用户的识别结果，返回识别ID。提交回答时按ID引用，
+// 避免客户端伪造词级时间影响表达评分
+func SaveRecognition(ctx context.Context, client *redis.Client, userID uint, transcript *speech.Transcript) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate recognition id failed: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+	data, err := json.Marshal(transcript)
+	if err != nil {
+		return "", fmt.Errorf("marshal transcript failed: %w", err)
+	}
+	if err = client.Set(ctx, fmt.Sprintf(speechRecognitionKey, userID, id), data, speechRecognitionTTL).Err(); err != nil {
+		return "", fmt.Errorf("save recognition failed: %w", err)
+	}
+	return id, nil
+}
+
+// LoadRecognition 读取用户保存的识别结果，不存在时返回ErrRecognitionNotFound
+func LoadRecognition(ctx context.Context, client *redis.Client, userID uint, id string) (*speech.Transcript, error) {
+	data, err := client.Get(ctx, fmt.Sprintf(speechRecognitionKey, userID, id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrRecognitionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get recognition failed: %w", err)
+	}
+	var transcript speech.Transcript
+	if err = json.Unmarshal(data, &transcript); err != nil {
+		return nil, fmt.Errorf("invalid recognition: %w", err)
+	}
+	return &transcript, nil
+}
@@ -1,15 +1,15 @@
 package model
 
 import (
+	"ai_jianli_go/config"
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/cloudwego/eino-ext/components/embedding/openai"
-	ri "github.com/cloudwego/eino-ext/components/indexer/redis"
 	rr "github.com/cloudwego/eino-ext/components/retriever/redis"
-	"github.com/cloudwego/eino/components/indexer"
+	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/schema"
 	"github.com/redis/go-redis/v9"
@@ -32,8 +32,13 @@ type Wiki struct {
 	UserId   uint   `gorm:"column:user_id" json:"user_id"`     // 用户ID
 	RootId   uint   `gorm:"column:root_id" json:"root_id"`     // 根文件夹ID
 
-	indexer   *ri.Indexer   `gorm:"-"`
-	retriever *rr.Retriever `gorm:"-"`
+	retriever   *rr.Retriever      `gorm:"-"`
+	client      *redis.Client      `gorm:"-"`
+	embedder    embedding.Embedder `gorm:"-"`
+	folderIDs   []uint             `gorm:"-"` // 文章所在的各级文件夹ID
+	version     int                `gorm:"-"` // 知识库当前使用的索引版本，迁移嵌入模型后递增
+	indexConfig WikiIndexConfig    `gorm:"-"` // 知识库的向量索引配置
+	loadedAt    int64              `gorm:"-"` // 导入时间，同一批导入的文档使用相同的导入时间
 }
 
 func (w *Wiki) TableName() string {
@@ -45,24 +50,41 @@ func NewWiki() *Wiki {
 }
 
 const (
-	wikiKeyPrefix                = "wiki_%d_%d:"      // keyPrefix: wiki_userId_rootId:docId
-	wikiIndexName                = "index_wiki_%d_%d" // indexName: index_wiki_userId_rootId
+	wikiKeyPrefix                = "wiki_%d_%d:"              // keyPrefix: wiki_userId_rootId:docId
+	wikiIndexName                = "index_wiki_%d_%d"         // indexName: index_wiki_userId_rootId，迁移后为指向当前版本索引的别名
+	wikiVersionKeyPrefix         = "wiki_%d_%d_v%d:"          // 迁移后的keyPrefix: wiki_userId_rootId_v版本:docId
+	wikiVersionIndexName         = "index_wiki_%d_%d_v%d"     // 迁移后的索引名: index_wiki_userId_rootId_v版本
+	wikiIndexVersionKey          = "wiki_index_version:%d:%d" // 知识库当前使用的索引版本，不存在时为0
 	customContentFieldName       = "content"
 	customTitleFieldName         = "title"
 	customContentVectorFieldName = "vector"
 	customTitleVectorFieldName   = "title_vector"
 	customExtraFieldName         = "extra_field_number"
-	dimension                    = 2560
+	customFileTypeFieldName      = "file_type"
+	customSourceFieldName        = "source"
+	customWikiIDFieldName        = "wiki_id"
+	customParentIDFieldName      = "parent_id"
+	customFolderIDsFieldName     = "folder_ids"
+	customLoadedAtFieldName      = "loaded_at"
+	customChunkIDFieldName       = "chunk_id"
+	customPageFieldName          = "page"
 )
 
+// SetFolderIDs 设置文章所在的各级文件夹ID，存储文档时写入folder_ids用于按文件夹过滤
+func (w *Wiki) SetFolderIDs(ids []uint) {
+	w.folderIDs = ids
+}
+
 // CreateIndex 创建Redis搜索索引
 func (w *Wiki) CreateIndex(ctx context.Context, client *redis.Client) error {
 	if w.UserId == 0 {
 		return fmt.Errorf("invalid user_id")
 	}
+	if err := w.loadIndexState(ctx, client); err != nil {
+		return err
+	}
 
-	keyPrefix := fmt.Sprintf(wikiKeyPrefix, w.UserId, w.RootId)
-	indexName := fmt.Sprintf(wikiIndexName, w.UserId, w.RootId)
+	indexName := indexNameOf(w.UserId, w.RootId, w.version)
 
 	// 检查索引是否已存在
 	exists, err := client.Exists(ctx, indexName).Result()
@@ -77,7 +99,12 @@ func (w *Wiki) CreateIndex(ctx context.Context, client *redis.Client) error {
 		}
 	}
 
-	// 创建新的搜索索引
+	return createIndex(ctx, client, indexName, w.keyPrefix(), w.indexConfig)
+}
+
+// createIndex 按索引配置创建知识库索引，向量维度取自嵌入模型配置
+func createIndex(ctx context.Context, client *redis.Client, indexName, keyPrefix string, conf WikiIndexConfig) error {
+	dimension := config.GetEmbeddingConfig().Dimension
 	schemas := []*redis.FieldSchema{
 		{
 			FieldName: customContentFieldName,
@@ -88,35 +115,25 @@ func (w *Wiki) CreateIndex(ctx context.Context, client *redis.Client) error {
 			FieldType: redis.SearchFieldTypeText,
 		},
 		{
-			FieldName: customContentVectorFieldName,
-			FieldType: redis.SearchFieldTypeVector,
-			VectorArgs: &redis.FTVectorArgs{
-				FlatOptions: &redis.FTFlatOptions{
-					Type:           "FLOAT32",
-					Dim:            dimension,
-					DistanceMetric: "COSINE",
-				},
-			},
+			FieldName:  customContentVectorFieldName,
+			FieldType:  redis.SearchFieldTypeVector,
+			VectorArgs: conf.vectorArgs(dimension),
 		},
 		{
-			FieldName: customTitleVectorFieldName,
-			FieldType: redis.SearchFieldTypeVector,
-			VectorArgs: &redis.FTVectorArgs{
-				FlatOptions: &redis.FTFlatOptions{
-					Type:           "FLOAT32",
-					Dim:            dimension,
-					DistanceMetric: "COSINE",
-				},
-			},
+			FieldName:  customTitleVectorFieldName,
+			FieldType:  redis.SearchFieldTypeVector,
+			VectorArgs: conf.vectorArgs(dimension),
 		},
 	}
+	schemas = append(schemas, filterFieldSchemas()...)
 
 	options := &redis.FTCreateOptions{
-		OnHash: true,
-		Prefix: []any{keyPrefix},
+		OnHash:          true,
+		Prefix:          []any{keyPrefix},
+		DefaultLanguage: searchLanguage,
 	}
 
-	_, err = client.FTCreate(ctx, indexName, options, schemas...).Result()
+	_, err := client.FTCreate(ctx, indexName, options, schemas...).Result()
 	if err != nil {
 		return fmt.Errorf("create index failed: %w", err)
 	}
@@ -124,49 +141,23 @@ func (w *Wiki) CreateIndex(ctx context.Context, client *redis.Client) error {
 	return nil
 }
 
-// Init 初始化索引器和检索器
-func (w *Wiki) Init(ctx context.Context, client *redis.Client, emb *openai.Embedder) error {
+// Init 读取知识库的索引状态并初始化检索器
+func (w *Wiki) Init(ctx context.Context, client *redis.Client, emb embedding.Embedder) error {
 	if w.UserId == 0 || w.RootId == 0 {
 		return fmt.Errorf("invalid user_id or root_id")
 	}
 
-	keyPrefix := fmt.Sprintf(wikiKeyPrefix, w.UserId, w.RootId)
-	indexName := fmt.Sprintf(wikiIndexName, w.UserId, w.RootId)
-
-	// 创建索引器
-	var err error
-	w.indexer, err = ri.NewIndexer(ctx, &ri.IndexerConfig{
-		Client:    client,
-		KeyPrefix: keyPrefix,
-		DocumentToHashes: func(ctx context.Context, doc *schema.Document) (*ri.Hashes, error) {
-			// 生成唯一的文档ID
-			docID := fmt.Sprintf("doc_%d_%d", w.ID, time.Now().UnixNano())
-
-			f2v := map[string]ri.FieldValue{
-				customContentFieldName: {
-					Value:     doc.Content,
-					EmbedKey:  customContentVectorFieldName,
-					Stringify: nil,
-				},
-				customTitleFieldName: {
-					Value:     doc.MetaData["title"].(string),
-					EmbedKey:  customTitleVectorFieldName,
-					Stringify: nil,
-				},
-			}
-
-			return &ri.Hashes{
-				Key:         docID,
-				Field2Value: f2v,
-			}, nil
-		},
-		BatchSize: 10, // 增加批处理大小
-		Embedding: emb,
-	})
-	if err != nil {
-		return fmt.Errorf("create indexer failed: %w", err)
+	if err := w.loadIndexState(ctx, client); err != nil {
+		return err
 	}
+	keyPrefix := w.keyPrefix()
+	indexName := w.indexName()
+
+	w.client = client
+	w.embedder = emb
+	w.loadedAt = time.Now().Unix()
 
+	var err error
 	// 创建检索器
 	w.retriever, err = rr.NewRetriever(ctx, &rr.RetrieverConfig{
 		Client:      client,
@@ -178,6 +169,12 @@ func (w *Wiki) Init(ctx context.Context, client *redis.Client, emb *openai.Embed
 			customTitleFieldName,
 			customContentVectorFieldName,
 			customTitleVectorFieldName,
+			customFileTypeFieldName,
+			customSourceFieldName,
+			customWikiIDFieldName,
+			customParentIDFieldName,
+			customChunkIDFieldName,
+			customPageFieldName,
 		},
 		DocumentConverter: func(ctx context.Context, doc redis.Document) (*schema.Document, error) {
 			resp := &schema.Document{
@@ -214,16 +211,26 @@ func (w *Wiki) Init(ctx context.Context, client *redis.Client, emb *openai.Embed
 }
 
 // Store 存储文档到索引
-func (w *Wiki) Store(ctx context.Context, docs []*schema.Document, opts ...indexer.Option) error {
-	if w.indexer == nil {
-		return fmt.Errorf("indexer not initialized, call Init() first")
+func (w *Wiki) Store(ctx context.Context, docs []*schema.Document) error {
+	if w.client == nil || w.embedder == nil {
+		return fmt.Errorf("wiki not initialized, call Init() first")
 	}
 
 	if len(docs) == 0 {
 		return fmt.Errorf("no documents to store")
 	}
 
-	// 为每个文档添加必要的元数据
+	prepareDocuments(docs)
+
+	if err := w.storeChunks(ctx, docs); err != nil {
+		return fmt.Errorf("store documents failed: %w", err)
+	}
+
+	return nil
+}
+
+// prepareDocuments 为每个文档添加必要的元数据
+func prepareDocuments(docs []*schema.Document) {
 	for _, doc := range docs {
 		if doc.MetaData == nil {
 			doc.MetaData = make(map[string]any)
@@ -231,19 +238,12 @@ func (w *Wiki) Store(ctx context.Context, docs []*schema.Document, opts ...index
 		// 如果没有标题，使用内容的前50个字符作为标题
 		if _, exists := doc.MetaData["title"]; !exists {
 			title := doc.Content
-			if len(title) > 50 {
-				title = title[:50] + "..."
+			if runes := []rune(title); len(runes) > 50 {
+				title = string(runes[:50]) + "..."
 			}
 			doc.MetaData["title"] = title
 		}
 	}
-
-	_, err := w.indexer.Store(ctx, docs, opts...)
-	if err != nil {
-		return fmt.Errorf("store documents failed: %w", err)
-	}
-
-	return nil
 }
 
 // StoreSingle 存储单个文档
@@ -262,6 +262,9 @@ func (w *Wiki) Search(ctx context.Context, query string, opts ...retriever.Optio
 	if w.retriever == nil {
 		return nil, fmt.Errorf("retriever not initialized, call Init() first")
 	}
+	if w.indexConfig.VectorType != VectorTypeFloat32 {
+		return nil, errUnsupportedVectorType
+	}
 
 	if strings.TrimSpace(query) == "" {
 		return nil, fmt.Errorf("query cannot be empty")
@@ -275,37 +278,94 @@ func (w *Wiki) Search(ctx context.Context, query string, opts ...retriever.Optio
 	return docs, nil
 }
 
-// SearchWithFilter 带过滤条件的搜索
+// SearchWithFilter 带过滤条件的搜索，过滤条件见BuildFilterQuery，作为KNN检索的预过滤
 func (w *Wiki) SearchWithFilter(ctx context.Context, query string, filter map[string]interface{}, opts ...retriever.Option) ([]*schema.Document, error) {
-	// 注意：eino框架可能不支持WithFilter，这里暂时跳过过滤条件
-	// 可以根据实际需要实现自定义过滤逻辑
-
+	filterQuery, err := BuildFilterQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	if filterQuery != "" {
+		opts = append(opts, rr.WithFilterQuery(filterQuery))
+	}
 	return w.Search(ctx, query, opts...)
 }
 
-// DeleteIndex 删除索引
+func joinIDs(ids []uint) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// DeleteIndex 删除索引，保留索引下的文档
 func (w *Wiki) DeleteIndex(ctx context.Context, client *redis.Client) error {
+	return w.deleteIndex(ctx, client, false)
+}
+
+// DeleteIndexAndDocuments 删除索引及索引下的所有文档
+func (w *Wiki) DeleteIndexAndDocuments(ctx context.Context, client *redis.Client) error {
+	return w.deleteIndex(ctx, client, true)
+}
+
+// deleteIndex 删除知识库当前版本的索引，同时删除别名、版本和索引配置
+func (w *Wiki) deleteIndex(ctx context.Context, client *redis.Client, deleteDocs bool) error {
 	if w.UserId == 0 || w.RootId == 0 {
 		return fmt.Errorf("invalid user_id or root_id")
 	}
+	if err := w.loadIndexState(ctx, client); err != nil {
+		return err
+	}
 
-	indexName := fmt.Sprintf(wikiIndexName, w.UserId, w.RootId)
-	_, err := client.FTDropIndex(ctx, indexName).Result()
-	if err != nil {
+	if err := dropIndex(ctx, client, indexNameOf(w.UserId, w.RootId, w.version), deleteDocs); err != nil {
 		return fmt.Errorf("delete index failed: %w", err)
 	}
+	// 迁移时保留的旧分块不在当前索引中，需单独删除
+	if deleteDocs {
+		if _, err := w.DeleteKeptVersions(ctx, client); err != nil {
+			return fmt.Errorf("delete kept chunks failed: %w", err)
+		}
+	}
+	if w.version > 0 {
+		if err := client.FTAliasDel(ctx, w.indexName()).Err(); err != nil && !isUnknownIndex(err) {
+			return fmt.Errorf("delete index alias failed: %w", err)
+		}
+	}
+	err := client.Del(ctx,
+		fmt.Sprintf(wikiIndexVersionKey, w.UserId, w.RootId),
+		fmt.Sprintf(wikiIndexConfigKey, w.UserId, w.RootId),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("delete index state failed: %w", err)
+	}
+	return nil
+}
 
+// dropIndex 删除索引，索引不存在时视为已删除
+func dropIndex(ctx context.Context, client *redis.Client, indexName string, deleteDocs bool) error {
+	err := client.FTDropIndexWithArgs(ctx, indexName, &redis.FTDropIndexOptions{DeleteDocs: deleteDocs}).Err()
+	if err != nil && !isUnknownIndex(err) {
+		return err
+	}
 	return nil
 }
 
+func isUnknownIndex(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unknown index") || strings.Contains(msg, "alias does not exist")
+}
+
 // GetIndexInfo 获取索引信息
 func (w *Wiki) GetIndexInfo(ctx context.Context, client *redis.Client) (redis.FTInfoResult, error) {
 	if w.UserId == 0 || w.RootId == 0 {
 		return redis.FTInfoResult{}, fmt.Errorf("invalid user_id or root_id")
 	}
 
-	indexName := fmt.Sprintf(wikiIndexName, w.UserId, w.RootId)
-	info, err := client.FTInfo(ctx, indexName).Result()
+	if err := w.loadIndexState(ctx, client); err != nil {
+		return redis.FTInfoResult{}, err
+	}
+
+	info, err := client.FTInfo(ctx, indexNameOf(w.UserId, w.RootId, w.version)).Result()
 	if err != nil {
 		return redis.FTInfoResult{}, fmt.Errorf("get index info failed: %w", err)
 	}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// filterFieldSchemas 过滤字段的索引定义
+func filterFieldSchemas() []*redis.FieldSchema {
+	return []*redis.FieldSchema{
+		{FieldName: customFileTypeFieldName, FieldType: redis.SearchFieldTypeTag},
+		{FieldName: customSourceFieldName, FieldType: redis.SearchFieldTypeTag},
+		{FieldName: customFolderIDsFieldName, FieldType: redis.SearchFieldTypeTag},
+		{FieldName: customWikiIDFieldName, FieldType: redis.SearchFieldTypeNumeric},
+		{FieldName: customParentIDFieldName, FieldType: redis.SearchFieldTypeNumeric},
+		{FieldName: customLoadedAtFieldName, FieldType: redis.SearchFieldTypeNumeric},
+	}
+}
+
+// AddFilterFields 为加入过滤字段前创建的索引补充过滤字段的定义，已有的字段跳过，返回补充的字段数。
+// 不扫描已有分块，分块由BackfillFilterFields重写后重新索引
+func (w *Wiki) AddFilterFields(ctx context.Context, client *redis.Client) (int, error) {
+	if w.UserId == 0 || w.RootId == 0 {
+		return 0, fmt.Errorf("invalid user_id or root_id")
+	}
+	if err := w.loadIndexState(ctx, client); err != nil {
+		return 0, err
+	}
+	indexName := indexNameOf(w.UserId, w.RootId, w.version)
+	info, err := client.FTInfo(ctx, indexName).Result()
+	if err != nil {
+		return 0, fmt.Errorf("get index info failed: %w", err)
+	}
+	existing := make(map[string]bool, len(info.Attributes))
+	for _, attr := range info.Attributes {
+		existing[attr.Attribute] = true
+	}
+
+	added := 0
+	for _, field := range filterFieldSchemas() {
+		if existing[field.FieldName] {
+			continue
+		}
+		if err = client.FTAlter(ctx, indexName, true, []any{field.FieldName, field.FieldType.String()}).Err(); err != nil {
+			return added, fmt.Errorf("add field %s to index failed: %w", field.FieldName, err)
+		}
+		added++
+	}
+	return added, nil
+}
+
+// BackfillFilterFields 为加入过滤字段前导入的分块补写过滤字段：文章ID、父文件夹和各级文件夹按当前的目录结构重写，
+// 缺少导入时间时取文章的更新时间，缺少来源和文件类型时按文章地址补充。
+// 调用前需通过SetFolderIDs设置文章所在的各级文件夹，返回更新的分块数
+func (w *Wiki) BackfillFilterFields(ctx context.Context, client *redis.Client) (int, error) {
+	if w.UserId == 0 || w.RootId == 0 {
+		return 0, fmt.Errorf("invalid user_id or root_id")
+	}
+	if err := w.loadIndexState(ctx, client); err != nil {
+		return 0, err
+	}
+	w.client = client
+	keys, err := w.documentKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	optional := []string{customLoadedAtFieldName, customSourceFieldName, customFileTypeFieldName}
+	updated := 0
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		batch := keys[start:min(start+deleteBatchSize, len(keys))]
+		pipe := client.Pipeline()
+		cmds := make([]*redis.SliceCmd, len(batch))
+		for i, key := range batch {
+			cmds[i] = pipe.HMGet(ctx, key, optional...)
+		}
+		if _, err = pipe.Exec(ctx); err != nil {
+			return updated, fmt.Errorf("read chunks failed: %w", err)
+		}
+
+		pipe = client.Pipeline()
+		for i, key := range batch {
+			values := w.backfillValues()
+			for j, field := range optional {
+				// 已有的值保留
+				if v, ok := cmds[i].Val()[j].(string); ok && v != "" {
+					delete(values, field)
+				}
+			}
+			pipe.HSet(ctx, key, values)
+		}
+		if _, err = pipe.Exec(ctx); err != nil {
+			return updated, fmt.Errorf("write filter fields failed: %w", err)
+		}
+		updated += len(batch)
+	}
+	return updated, nil
+}
+
+// backfillValues 补写的过滤字段，文章地址没有扩展名时不补文件类型
+func (w *Wiki) backfillValues() map[string]any {
+	values := map[string]any{
+		customWikiIDFieldName:   strconv.FormatUint(uint64(w.ID), 10),
+		customParentIDFieldName: strconv.FormatUint(uint64(w.ParentID), 10),
+		// 不在任何文件夹中的文章写入空值，覆盖移动前的旧值
+		customFolderIDsFieldName: joinIDs(w.folderIDs),
+		customLoadedAtFieldName:  strconv.FormatInt(w.UpdatedAt.Unix(), 10),
+	}
+	if w.Url != "" {
+		values[customSourceFieldName] = w.Url
+		// 链接只看路径部分，避免把域名后缀当作文件类型
+		path := w.Url
+		if u, err := url.Parse(w.Url); err == nil && u.Scheme != "" {
+			path = u.Path
+		}
+		if ext := strings.ToLower(filepath.Ext(path)); ext != "" {
+			values[customFileTypeFieldName] = ext
+		}
+	}
+	return values
+}
+
+// AncestorFolderIDs 按上级关系计算文章所在的各级文件夹ID，从直接父文件夹到最上层文件夹。
+// items为知识库下的所有条目，不在items中或不是文件夹的上级结束查找
+func AncestorFolderIDs(wiki *Wiki, items map[uint]*Wiki) []uint {
+	var ids []uint
+	seen := map[uint]bool{}
+	for parentID := wiki.ParentID; parentID != 0 && !seen[parentID]; {
+		parent, ok := items[parentID]
+		if !ok || parent.Type != WikiTypeFolder {
+			break
+		}
+		seen[parentID] = true
+		ids = append(ids, parent.ID)
+		parentID = parent.ParentID
+	}
+	return ids
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestAncestorFolderIDs(t *testing.T) {
+	items := map[uint]*Wiki{
+		2: {Model: gorm.Model{ID: 2}, Type: WikiTypeFolder, ParentID: 1},
+		3: {Model: gorm.Model{ID: 3}, Type: WikiTypeFolder, ParentID: 2},
+		// 成环的文件夹
+		4: {Model: gorm.Model{ID: 4}, Type: WikiTypeFolder, ParentID: 5},
+		5: {Model: gorm.Model{ID: 5}, Type: WikiTypeFolder, ParentID: 4},
+	}
+	if ids := AncestorFolderIDs(&Wiki{ParentID: 3}, items); len(ids) != 2 || ids[0] != 3 || ids[1] != 2 {
+		t.Fatalf("ancestors mismatch: %v", ids)
+	}
+	if ids := AncestorFolderIDs(&Wiki{ParentID: 4}, items); len(ids) != 2 {
+		t.Fatalf("cycle should stop: %v", ids)
+	}
+	if ids := AncestorFolderIDs(&Wiki{ParentID: 1}, items); len(ids) != 0 {
+		t.Fatalf("knowledge base is not a folder: %v", ids)
+	}
+}
+
+func TestBackfillValues(t *testing.T) {
+	w := &Wiki{Model: gorm.Model{ID: 7}, ParentID: 3, Url: "uploads/a.PDF"}
+	w.SetFolderIDs([]uint{3, 2})
+	values := w.backfillValues()
+	if values[customWikiIDFieldName] != "7" || values[customFolderIDsFieldName] != "3,2" || values[customFileTypeFieldName] != ".pdf" {
+		t.Fatalf("values mismatch: %v", values)
+	}
+	w.Url = "https://example.com"
+	if _, ok := w.backfillValues()[customFileTypeFieldName]; ok {
+		t.Fatal("url without extension should not have file type")
+	}
+}
@@ -0,0 +1,196 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SearchWithFilter 支持的过滤条件
+const (
+	FilterFileType     = "file_type"     // 文件类型，如 ".pdf"，支持字符串或字符串数组
+	FilterSource       = "source"        // 文档来源（文件路径或URL），支持字符串或字符串数组
+	FilterWikiID       = "wiki_id"       // 文章ID，支持数字或数字数组
+	FilterParentID     = "parent_id"     // 直接父文件夹ID，支持数字或数字数组
+	FilterFolderID     = "folder_id"     // 文件夹ID，包含所有层级的子文件夹，支持数字或数字数组
+	FilterLoadedAfter  = "loaded_after"  // 在该时间之后导入，支持time.Time、Unix秒、RFC3339或2006-01-02
+	FilterLoadedBefore = "loaded_before" // 在该时间之前导入
+)
+
+// BuildFilterQuery 把过滤条件转换为RediSearch预过滤表达式，多个条件之间为且的关系，
+// 同一条件的多个取值之间为或的关系。没有条件时返回空字符串
+func BuildFilterQuery(filter map[string]any) (string, error) {
+	keys := make([]string, 0, len(filter))
+	for k := range filter {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	clauses := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := filter[key]
+		var clause string
+		var err error
+		switch key {
+		case FilterFileType:
+			clause, err = tagClause(customFileTypeFieldName, value)
+		case FilterSource:
+			clause, err = tagClause(customSourceFieldName, value)
+		case FilterFolderID:
+			clause, err = tagClause(customFolderIDsFieldName, value)
+		case FilterWikiID:
+			clause, err = numericInClause(customWikiIDFieldName, value)
+		case FilterParentID:
+			clause, err = numericInClause(customParentIDFieldName, value)
+		case FilterLoadedAfter:
+			clause, err = timeClause(value, true)
+		case FilterLoadedBefore:
+			clause, err = timeClause(value, false)
+		default:
+			err = fmt.Errorf("unsupported filter key %q", key)
+		}
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, clause)
+	}
+	return strings.Join(clauses, " "), nil
+}
+
+// tagClause 生成TAG字段匹配表达式，如 @file_type:{\.pdf|\.docx}
+func tagClause(field string, value any) (string, error) {
+	values, err := toStrings(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s filter: %w", field, err)
+	}
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = escapeTagValue(v)
+	}
+	return fmt.Sprintf("@%s:{%s}", field, strings.Join(escaped, "|")), nil
+}
+
+// numericInClause 生成数值字段等值匹配表达式，多个取值时用或连接
+func numericInClause(field string, value any) (string, error) {
+	values, err := toStrings(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s filter: %w", field, err)
+	}
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "", fmt.Errorf("invalid %s filter: %q is not a number", field, v)
+		}
+		parts[i] = fmt.Sprintf("@%s:[%s %s]", field, v, v)
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return "(" + strings.Join(parts, " | ") + ")", nil
+}
+
+// timeClause 生成导入时间范围表达式，边界不包含在内
+func timeClause(value any, after bool) (string, error) {
+	ts, err := toUnix(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid loaded time filter: %w", err)
+	}
+	if after {
+		return fmt.Sprintf("@%s:[(%d +inf]", customLoadedAtFieldName, ts), nil
+	}
+	return fmt.Sprintf("@%s:[-inf (%d]", customLoadedAtFieldName, ts), nil
+}
+
+func toStrings(value any) ([]string, error) {
+	var values []string
+	switch v := value.(type) {
+	case []string:
+		values = v
+	case []any:
+		for _, item := range v {
+			s, err := scalarString(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s)
+		}
+	case []uint:
+		for _, item := range v {
+			values = append(values, strconv.FormatUint(uint64(item), 10))
+		}
+	case []int:
+		for _, item := range v {
+			values = append(values, strconv.Itoa(item))
+		}
+	default:
+		s, err := scalarString(v)
+		if err != nil {
+			return nil, err
+		}
+		values = []string{s}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return values, nil
+}
+
+func scalarString(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return "", fmt.Errorf("empty value")
+		}
+		return v, nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		// JSON解码得到的数字
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+func toUnix(value any) (int64, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Unix(), nil
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case string:
+		for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return t.Unix(), nil
+			}
+		}
+		return 0, fmt.Errorf("unsupported time format %q", v)
+	default:
+		return 0, fmt.Errorf("unsupported time type %T", value)
+	}
+}
+
+// escapeTagValue 转义TAG值中除字母数字外的字符
+func escapeTagValue(value string) string {
+	var sb strings.Builder
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
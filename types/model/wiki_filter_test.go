@@ -0,0 +1,71 @@
+package model
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBuildFilterQuery(t *testing.T) {
+	loaded := time.Date(2025, 1, 2, 0, 0, 0, 0, time.Local).Unix()
+
+	cases := []struct {
+		name   string
+		filter map[string]any
+		want   string
+	}{
+		{"empty", nil, ""},
+		{"file type", map[string]any{FilterFileType: ".pdf"}, `@file_type:{\.pdf}`},
+		{"file types", map[string]any{FilterFileType: []any{".pdf", ".docx"}}, `@file_type:{\.pdf|\.docx}`},
+		{"source", map[string]any{FilterSource: "docs/a b.md"}, `@source:{docs\/a\ b\.md}`},
+		{"folder", map[string]any{FilterFolderID: uint(7)}, `@folder_ids:{7}`},
+		{"wiki id", map[string]any{FilterWikiID: float64(3)}, `@wiki_id:[3 3]`},
+		{"parent ids", map[string]any{FilterParentID: []uint{1, 2}}, `(@parent_id:[1 1] | @parent_id:[2 2])`},
+		{"loaded after", map[string]any{FilterLoadedAfter: "2025-01-02"}, "@loaded_at:[(" + itoa(loaded) + " +inf]"},
+		{"loaded before", map[string]any{FilterLoadedBefore: loaded}, "@loaded_at:[-inf (" + itoa(loaded) + "]"},
+		{
+			"combined",
+			map[string]any{FilterWikiID: 3, FilterFileType: ".md"},
+			`@file_type:{\.md} @wiki_id:[3 3]`,
+		},
+	}
+	for _, c := range cases {
+		got, err := BuildFilterQuery(c.filter)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestBuildFilterQueryInvalid(t *testing.T) {
+	cases := []map[string]any{
+		{"unknown": 1},
+		{FilterWikiID: "abc"},
+		{FilterFileType: ""},
+		{FilterFileType: []any{}},
+		{FilterLoadedAfter: "yesterday"},
+		{FilterSource: map[string]any{}},
+	}
+	for _, filter := range cases {
+		if got, err := BuildFilterQuery(filter); err == nil {
+			t.Errorf("BuildFilterQuery(%v) = %q, want error", filter, got)
+		}
+	}
+}
+
+func TestJoinIDs(t *testing.T) {
+	if got := joinIDs([]uint{3, 12, 1}); got != "3,12,1" {
+		t.Errorf("joinIDs = %q, want %q", got, "3,12,1")
+	}
+	if got := joinIDs(nil); got != "" {
+		t.Errorf("joinIDs(nil) = %q, want empty", got)
+	}
+}
+
+func itoa(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
@@ -0,0 +1,384 @@
+package model
+
+import (
+	"ai_jianli_go/config"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 向量索引算法
+const (
+	IndexAlgorithmFlat = "FLAT" // 暴力检索，结果精确，适合小知识库
+	IndexAlgorithmHNSW = "HNSW" // 近似最近邻，适合大知识库
+)
+
+// 向量距离度量
+const (
+	DistanceCosine = "COSINE"
+	DistanceIP     = "IP"
+	DistanceL2     = "L2"
+)
+
+// 向量存储类型
+const (
+	VectorTypeFloat32 = "FLOAT32"
+	VectorTypeFloat16 = "FLOAT16" // 向量占用内存减半，精度略有下降
+)
+
+const wikiIndexConfigKey = "wiki_index_config:%d:%d" // 知识库的向量索引配置，不存在时使用默认配置
+
+// WikiIndexConfig 知识库的向量索引配置
+type WikiIndexConfig struct {
+	Algorithm      string `json:"algorithm"`       // FLAT(默认) / HNSW
+	DistanceMetric string `json:"distance_metric"` // COSINE(默认) / IP / L2
+	VectorType     string `json:"vector_type"`     // FLOAT32(默认) / FLOAT16
+	M              int    `json:"m"`               // HNSW每个节点的最大边数，默认16
+	EFConstruction int    `json:"ef_construction"` // HNSW建图时的候选数，默认200
+	EFRuntime      int    `json:"ef_runtime"`      // HNSW查询时的候选数，默认10
+}
+
+// DefaultWikiIndexConfig 默认索引配置：FLAT、COSINE、FLOAT32
+func DefaultWikiIndexConfig() WikiIndexConfig {
+	conf := WikiIndexConfig{}
+	_ = conf.Normalize()
+	return conf
+}
+
+// Normalize 统一大小写并填充默认值，取值不合法时返回错误
+func (c *WikiIndexConfig) Normalize() error {
+	c.Algorithm = strings.ToUpper(c.Algorithm)
+	c.DistanceMetric = strings.ToUpper(c.DistanceMetric)
+	c.VectorType = strings.ToUpper(c.VectorType)
+
+	switch c.Algorithm {
+	case "":
+		c.Algorithm = IndexAlgorithmFlat
+	case IndexAlgorithmFlat, IndexAlgorithmHNSW:
+	default:
+		return fmt.Errorf("unsupported index algorithm %q", c.Algorithm)
+	}
+	switch c.DistanceMetric {
+	case "":
+		c.DistanceMetric = DistanceCosine
+	case DistanceCosine, DistanceIP, DistanceL2:
+	default:
+		return fmt.Errorf("unsupported distance metric %q", c.DistanceMetric)
+	}
+	switch c.VectorType {
+	case "":
+		c.VectorType = VectorTypeFloat32
+	case VectorTypeFloat32, VectorTypeFloat16:
+	default:
+		return fmt.Errorf("unsupported vector type %q", c.VectorType)
+	}
+
+	if c.Algorithm == IndexAlgorithmFlat {
+		c.M, c.EFConstruction, c.EFRuntime = 0, 0, 0
+		return nil
+	}
+	if c.M < 0 || c.EFConstruction < 0 || c.EFRuntime < 0 {
+		return fmt.Errorf("hnsw parameters must not be negative")
+	}
+	if c.M == 0 {
+		c.M = 16
+	}
+	if c.EFConstruction == 0 {
+		c.EFConstruction = 200
+	}
+	if c.EFRuntime == 0 {
+		c.EFRuntime = 10
+	}
+	return nil
+}
+
+// vectorArgs 向量字段的索引参数
+func (c WikiIndexConfig) vectorArgs(dimension int) *redis.FTVectorArgs {
+	if c.Algorithm == IndexAlgorithmHNSW {
+		return &redis.FTVectorArgs{
+			HNSWOptions: &redis.FTHNSWOptions{
+				Type:                   c.VectorType,
+				Dim:                    dimension,
+				DistanceMetric:         c.DistanceMetric,
+				MaxEdgesPerNode:        c.M,
+				MaxAllowedEdgesPerNode: c.EFConstruction,
+				EFRunTime:              c.EFRuntime,
+			},
+		}
+	}
+	return &redis.FTVectorArgs{
+		FlatOptions: &redis.FTFlatOptions{
+			Type:           c.VectorType,
+			Dim:            dimension,
+			DistanceMetric: c.DistanceMetric,
+		},
+	}
+}
+
+// similarity 把KNN返回的距离转换为相似度。嵌入向量已归一化，IP与COSINE的距离均为1-余弦相似度，
+// L2的距离为欧氏距离的平方，等于2-2*余弦相似度
+func (c WikiIndexConfig) similarity(distance float64) float64 {
+	if c.DistanceMetric == DistanceL2 {
+		return 1 - distance/2
+	}
+	return 1 - distance
+}
+
+// loadIndexState 读取知识库当前使用的索引版本和索引配置
+func (w *Wiki) loadIndexState(ctx context.Context, client *redis.Client) error {
+	values, err := client.MGet(ctx,
+		fmt.Sprintf(wikiIndexVersionKey, w.UserId, w.RootId),
+		fmt.Sprintf(wikiIndexConfigKey, w.UserId, w.RootId),
+	).Result()
+	if err != nil {
+		return fmt.Errorf("get index state failed: %w", err)
+	}
+
+	w.version = 0
+	if v, ok := values[0].(string); ok {
+		if w.version, err = strconv.Atoi(v); err != nil {
+			return fmt.Errorf("invalid index version %q", v)
+		}
+	}
+	w.indexConfig = DefaultWikiIndexConfig()
+	if v, ok := values[1].(string); ok {
+		if err = json.Unmarshal([]byte(v), &w.indexConfig); err != nil {
+			return fmt.Errorf("invalid index config: %w", err)
+		}
+		if err = w.indexConfig.Normalize(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadIndexConfig 读取知识库的索引配置，未设置过时为默认配置
+func (w *Wiki) LoadIndexConfig(ctx context.Context, client *redis.Client) (WikiIndexConfig, error) {
+	if err := w.loadIndexState(ctx, client); err != nil {
+		return WikiIndexConfig{}, err
+	}
+	return w.indexConfig, nil
+}
+
+// IndexConfig 知识库的索引配置，在Init或GetIndexInfo后有效
+func (w *Wiki) IndexConfig() WikiIndexConfig {
+	return w.indexConfig
+}
+
+// IndexVersion 知识库当前使用的索引版本，在Init或GetIndexInfo后有效
+func (w *Wiki) IndexVersion() int {
+	return w.version
+}
+
+// RebuildIndex 按新的索引配置原地重建知识库当前版本的索引：删除索引(保留分块)后重新创建，
+// Redis在后台重新索引已有分块，进度见GetIndexInfo。向量类型变化时把已有分块的向量直接转换为新类型，
+// 不需要重新调用嵌入接口。重建期间检索结果不完整
+func (w *Wiki) RebuildIndex(ctx context.Context, client *redis.Client, conf WikiIndexConfig) error {
+	if w.UserId == 0 || w.RootId == 0 {
+		return fmt.Errorf("invalid user_id or root_id")
+	}
+	if err := conf.Normalize(); err != nil {
+		return err
+	}
+	if err := w.loadIndexState(ctx, client); err != nil {
+		return err
+	}
+
+	indexName := indexNameOf(w.UserId, w.RootId, w.version)
+	if err := dropIndex(ctx, client, indexName, false); err != nil {
+		return fmt.Errorf("drop index failed: %w", err)
+	}
+
+	// 转换按向量字节长度判断，中断后重新执行不会重复转换
+	keys, err := scanKeys(ctx, client, w.keyPrefix()+"*")
+	if err != nil {
+		return err
+	}
+	dimension := config.GetEmbeddingConfig().Dimension
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		batch := keys[start:min(start+deleteBatchSize, len(keys))]
+		if err = convertVectors(ctx, client, batch, dimension, conf.VectorType); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	if err = client.Set(ctx, fmt.Sprintf(wikiIndexConfigKey, w.UserId, w.RootId), data, 0).Err(); err != nil {
+		return fmt.Errorf("save index config failed: %w", err)
+	}
+	w.indexConfig = conf
+
+	if err = createIndex(ctx, client, indexName, w.keyPrefix(), conf); err != nil {
+		return err
+	}
+	// 删除索引时别名随之删除，迁移过的知识库需要重新指向
+	if w.version > 0 {
+		if err = client.FTAliasUpdate(ctx, indexName, w.indexName()).Err(); err != nil {
+			return fmt.Errorf("update index alias failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// convertVectors 把一批分块的向量字段转换为指定的向量类型，已是该类型的分块不改写
+func convertVectors(ctx context.Context, client *redis.Client, keys []string, dimension int, vectorType string) error {
+	fields := []string{customContentVectorFieldName, customTitleVectorFieldName}
+	pipe := client.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HMGet(ctx, key, fields...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("read vectors failed: %w", err)
+	}
+
+	pipe = client.Pipeline()
+	changed := 0
+	for i, cmd := range cmds {
+		values := map[string]any{}
+		for j, v := range cmd.Val() {
+			buf, ok := v.(string)
+			if !ok {
+				continue
+			}
+			if converted, ok := convertVector([]byte(buf), dimension, vectorType); ok {
+				values[fields[j]] = converted
+			}
+		}
+		if len(values) > 0 {
+			pipe.HSet(ctx, keys[i], values)
+			changed++
+		}
+	}
+	if changed == 0 {
+		return nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("write vectors failed: %w", err)
+	}
+	return nil
+}
+
+// convertVector 按字节长度识别向量的存储类型，与目标类型不同时转换，返回是否需要改写
+func convertVector(buf []byte, dimension int, vectorType string) ([]byte, bool) {
+	if len(buf) == dimension*vectorSize(vectorType) {
+		return nil, false
+	}
+	switch len(buf) {
+	case dimension * vectorSize(VectorTypeFloat32):
+		return encodeVector(decodeVector(buf, VectorTypeFloat32), vectorType), true
+	case dimension * vectorSize(VectorTypeFloat16):
+		return encodeVector(decodeVector(buf, VectorTypeFloat16), vectorType), true
+	}
+	// 维度不一致的向量无法转换，重建后计入索引失败数
+	return nil, false
+}
+
+func vectorSize(vectorType string) int {
+	if vectorType == VectorTypeFloat16 {
+		return 2
+	}
+	return 4
+}
+
+// encodeVector 把向量编码为索引向量类型的小端字节
+func encodeVector(vec []float64, vectorType string) []byte {
+	buf := make([]byte, vectorSize(vectorType)*len(vec))
+	for i, v := range vec {
+		if vectorType == VectorTypeFloat16 {
+			binary.LittleEndian.PutUint16(buf[i*2:], float32ToFloat16(float32(v)))
+		} else {
+			binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(v)))
+		}
+	}
+	return buf
+}
+
+func decodeVector(buf []byte, vectorType string) []float64 {
+	size := vectorSize(vectorType)
+	vec := make([]float64, len(buf)/size)
+	for i := range vec {
+		if vectorType == VectorTypeFloat16 {
+			vec[i] = float64(float16ToFloat32(binary.LittleEndian.Uint16(buf[i*2:])))
+		} else {
+			vec[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:])))
+		}
+	}
+	return vec
+}
+
+// float32ToFloat16 转换为IEEE 754半精度，就近舍入到偶数
+func float32ToFloat16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	rawExp := (bits >> 23) & 0xff
+	mant := bits & 0x7fffff
+
+	if rawExp == 0xff {
+		if mant != 0 {
+			return sign | 0x7e00 // NaN
+		}
+		return sign | 0x7c00 // Inf
+	}
+	exp := int(rawExp) - 127 + 15
+	if exp >= 0x1f {
+		return sign | 0x7c00
+	}
+	if exp <= 0 {
+		// 非规格化数，太小时为0
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint(14 - exp)
+		rounded := mant + (1 << (shift - 1)) - 1 + ((mant >> shift) & 1)
+		return sign | uint16(rounded>>shift)
+	}
+
+	rounded := mant + 0xfff + ((mant >> 13) & 1)
+	if rounded&0x800000 != 0 {
+		// 尾数进位到指数
+		rounded = 0
+		exp++
+		if exp >= 0x1f {
+			return sign | 0x7c00
+		}
+	}
+	return sign | uint16(exp<<10) | uint16(rounded>>13)
+}
+
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch exp {
+	case 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// 非规格化数转为规格化表示
+		e := uint32(127 - 15 + 1)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		return math.Float32frombits(sign | e<<23 | (mant&0x3ff)<<13)
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
+
+// errUnsupportedVectorType 使用eino检索器的接口只支持FLOAT32向量
+var errUnsupportedVectorType = errors.New("search only supports FLOAT32 index, use VectorSearch or HybridSearch")
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFloat16Conversion(t *testing.T) {
+	cases := []struct {
+		f    float32
+		want uint16
+	}{
+		{0, 0x0000},
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{0.1, 0x2e66},
+		{65504, 0x7bff},
+		{1e6, 0x7c00},                  // 溢出为Inf
+		{float32(math.Pow(2, -24)), 1}, // 最小的非规格化数
+		{1e-8, 0x0000},                 // 太小舍入为0
+		{float32(math.Inf(-1)), 0xfc00},
+	}
+	for _, c := range cases {
+		if got := float32ToFloat16(c.f); got != c.want {
+			t.Errorf("float32ToFloat16(%v) = %#04x, want %#04x", c.f, got, c.want)
+		}
+	}
+
+	// 半精度能表示的值往返转换后不变
+	for _, h := range []uint16{0x0001, 0x03ff, 0x0400, 0x3c00, 0x3555, 0xbc01, 0x7bff} {
+		if got := float32ToFloat16(float16ToFloat32(h)); got != h {
+			t.Errorf("round trip %#04x = %#04x", h, got)
+		}
+	}
+}
+
+func TestConvertVector(t *testing.T) {
+	vec := []float64{0.5, -0.25, 1}
+	f32 := encodeVector(vec, VectorTypeFloat32)
+
+	if _, ok := convertVector(f32, 3, VectorTypeFloat32); ok {
+		t.Error("FLOAT32 vector should not be converted to FLOAT32")
+	}
+	f16, ok := convertVector(f32, 3, VectorTypeFloat16)
+	if !ok || len(f16) != 6 {
+		t.Fatalf("convert to FLOAT16: ok=%v len=%d", ok, len(f16))
+	}
+	back, ok := convertVector(f16, 3, VectorTypeFloat32)
+	if !ok {
+		t.Fatal("FLOAT16 vector should be converted back to FLOAT32")
+	}
+	for i, v := range decodeVector(back, VectorTypeFloat32) {
+		if v != vec[i] {
+			t.Errorf("value %d = %v, want %v", i, v, vec[i])
+		}
+	}
+	if _, ok := convertVector(f32, 4, VectorTypeFloat16); ok {
+		t.Error("vector with mismatched dimension should not be converted")
+	}
+}
+
+func TestWikiIndexConfigNormalize(t *testing.T) {
+	conf := WikiIndexConfig{Algorithm: "hnsw", VectorType: "float16", EFRuntime: 50}
+	if err := conf.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	want := WikiIndexConfig{Algorithm: IndexAlgorithmHNSW, DistanceMetric: DistanceCosine, VectorType: VectorTypeFloat16, M: 16, EFConstruction: 200, EFRuntime: 50}
+	if conf != want {
+		t.Errorf("Normalize = %+v, want %+v", conf, want)
+	}
+
+	flat := WikiIndexConfig{M: 32}
+	if err := flat.Normalize(); err != nil || flat != DefaultWikiIndexConfig() {
+		t.Errorf("flat Normalize = %+v, %v", flat, err)
+	}
+
+	for _, bad := range []WikiIndexConfig{
+		{Algorithm: "IVF"},
+		{DistanceMetric: "HAMMING"},
+		{VectorType: "FLOAT64"},
+		{Algorithm: IndexAlgorithmHNSW, M: -1},
+	} {
+		if err := bad.Normalize(); err == nil {
+			t.Errorf("Normalize(%+v) should fail", bad)
+		}
+	}
+
+	l2 := WikiIndexConfig{DistanceMetric: DistanceL2}
+	if got := l2.similarity(0.5); got != 0.75 {
+		t.Errorf("L2 similarity = %v, want 0.75", got)
+	}
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 文档导入任务状态
+const (
+	WikiJobQueued    = "queued"    // 排队中，重试等待也是该状态
+	WikiJobParsing   = "parsing"   // 解析文档
+	WikiJobEmbedding = "embedding" // 计算向量并写入索引
+	WikiJobDone      = "done"
+	WikiJobFailed    = "failed"
+)
+
+// 文档导入任务类型
+const (
+	WikiJobCreate  = "create"  // 新建文章，最终失败时删除文章及已写入的分块
+	WikiJobUpdate  = "update"  // 更新文章，按内容哈希增量更新
+	WikiJobRebuild = "rebuild" // 按新配置重建知识库索引，执行期间暂停该知识库的文档导入
+)
+
+// WikiJob 文档导入任务，文章的解析、分块和向量计算在后台执行
+type WikiJob struct {
+	gorm.Model
+	WikiID         uint             `json:"wiki_id" gorm:"index"`                          // 文章ID，重建任务为知识库ID
+	RootID         uint             `json:"root_id" gorm:"index"`                          // 所属知识库ID
+	UserID         uint             `json:"user_id" gorm:"index"`                          // 用户ID
+	Kind           string           `json:"kind"`                                          // 任务类型: create/update/rebuild
+	Status         string           `json:"status" gorm:"index"`                           // 任务状态
+	TotalChunks    int              `json:"total_chunks"`                                  // 分块总数
+	EmbeddedChunks int              `json:"embedded_chunks"`                               // 已写入索引的分块数，包含内容未变化的分块
+	SkippedChunks  int              `json:"skipped_chunks"`                                // 内容未变化、无需重新计算向量的分块数
+	RemovedChunks  int              `json:"removed_chunks"`                                // 删除的旧分块数
+	Attempts       int              `json:"attempts"`                                      // 已尝试次数
+	MaxAttempts    int              `json:"max_attempts"`                                  // 最大尝试次数
+	Error          string           `json:"error" gorm:"type:text"`                        // 最近一次失败原因
+	NextRunAt      time.Time        `json:"next_run_at" gorm:"index"`                      // 下次执行时间，重试时按指数退避推迟
+	FinishedAt     *time.Time       `json:"finished_at"`                                   // 完成或最终失败时间
+	Owner          string           `json:"-" gorm:"size:128"`                             // 领取任务的导入协程
+	LeaseUntil     *time.Time       `json:"-" gorm:"index"`                                // 租约到期时间，持有者执行期间定期续约，过期后任务可被重新领取
+	IndexConfig    *WikiIndexConfig `json:"index_config,omitempty" gorm:"serializer:json"` // 重建任务的新索引配置
+}
+
+func (j *WikiJob) TableName() string {
+	return "wiki_job"
+}
+
+// Finished 任务是否已结束
+func (j *WikiJob) Finished() bool {
+	return j.Status == WikiJobDone || j.Status == WikiJobFailed
+}
+
+// NewWikiJob 创建排队中的导入任务
+func NewWikiJob(kind string, maxAttempts int) *WikiJob {
+	return &WikiJob{
+		Kind:        kind,
+		Status:      WikiJobQueued,
+		MaxAttempts: maxAttempts,
+		NextRunAt:   time.Now(),
+	}
+}
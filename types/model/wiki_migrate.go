@@ -0,0 +1,265 @@
+package model
+
+import (
+	"ai_jianli_go/config"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/redis/go-redis/v9"
+)
+
+// 迁移时保留了旧分块的索引版本，删除知识库或执行清理时一并删除这些版本的分块
+const wikiKeptVersionsKey = "wiki_index_kept_versions:%d:%d"
+
+// keyPrefixOf 知识库指定版本索引的文档键前缀，版本0为迁移前的键前缀
+func keyPrefixOf(userID, rootID uint, version int) string {
+	if version == 0 {
+		return fmt.Sprintf(wikiKeyPrefix, userID, rootID)
+	}
+	return fmt.Sprintf(wikiVersionKeyPrefix, userID, rootID, version)
+}
+
+// indexNameOf 知识库指定版本的索引名，版本0的索引名与别名相同
+func indexNameOf(userID, rootID uint, version int) string {
+	if version == 0 {
+		return fmt.Sprintf(wikiIndexName, userID, rootID)
+	}
+	return fmt.Sprintf(wikiVersionIndexName, userID, rootID, version)
+}
+
+// keyPrefix 当前版本索引的文档键前缀
+func (w *Wiki) keyPrefix() string {
+	return keyPrefixOf(w.UserId, w.RootId, w.version)
+}
+
+// indexName 检索使用的索引名，迁移过的知识库为指向当前版本索引的别名
+func (w *Wiki) indexName() string {
+	return fmt.Sprintf(wikiIndexName, w.UserId, w.RootId)
+}
+
+// MigrateResult 知识库索引迁移结果
+type MigrateResult struct {
+	FromVersion int // 迁移前的索引版本
+	ToVersion   int // 迁移后的索引版本
+	Chunks      int // 重新计算向量的分块数
+	Removed     int // 删除的旧分块数
+}
+
+// MigrateIndex 更换嵌入模型后，用新的嵌入器为知识库的所有分块重新计算向量，写入新版本的索引，
+// 完成后把检索使用的索引别名切换到新索引并删除旧索引。新索引的向量维度取自当前的嵌入模型配置，
+// 其余参数沿用知识库的索引配置。
+// keepOld为false时同时删除旧分块，否则记录旧版本，由DeleteKeptVersions或删除知识库时清理。迁移期间写入旧索引的分块不会被迁移，应在停止服务后执行。
+// onProgress不为nil时，每批分块写入后回报已迁移数和总数
+func (w *Wiki) MigrateIndex(ctx context.Context, client *redis.Client, emb embedding.Embedder, keepOld bool, onProgress func(done, total int)) (MigrateResult, error) {
+	var result MigrateResult
+	if w.UserId == 0 || w.RootId == 0 {
+		return result, fmt.Errorf("invalid user_id or root_id")
+	}
+	if err := w.loadIndexState(ctx, client); err != nil {
+		return result, err
+	}
+	result.FromVersion = w.version
+	result.ToVersion = w.version + 1
+
+	oldPrefix := keyPrefixOf(w.UserId, w.RootId, result.FromVersion)
+	newPrefix := keyPrefixOf(w.UserId, w.RootId, result.ToVersion)
+	newIndex := indexNameOf(w.UserId, w.RootId, result.ToVersion)
+
+	keys, err := scanKeys(ctx, client, oldPrefix+"*")
+	if err != nil {
+		return result, err
+	}
+
+	// 上次迁移中断时可能留下未切换的新索引，删除后重新迁移
+	if err = dropIndex(ctx, client, newIndex, true); err != nil {
+		return result, fmt.Errorf("drop unfinished index failed: %w", err)
+	}
+	if err = createIndex(ctx, client, newIndex, newPrefix, w.indexConfig); err != nil {
+		return result, err
+	}
+
+	dimension := config.GetEmbeddingConfig().Dimension
+	for start := 0; start < len(keys); start += syncBatchSize {
+		batch := keys[start:min(start+syncBatchSize, len(keys))]
+		if err = migrateChunks(ctx, client, emb, batch, oldPrefix, newPrefix, dimension, w.indexConfig.VectorType); err != nil {
+			return result, err
+		}
+		result.Chunks += len(batch)
+		if onProgress != nil {
+			onProgress(result.Chunks, len(keys))
+		}
+	}
+
+	if err = swapIndexAlias(ctx, client, w.indexName(), result.FromVersion, newIndex, indexNameOf(w.UserId, w.RootId, result.FromVersion)); err != nil {
+		return result, err
+	}
+	if err = client.Set(ctx, fmt.Sprintf(wikiIndexVersionKey, w.UserId, w.RootId), result.ToVersion, 0).Err(); err != nil {
+		// 别名已指向新索引，需手动设置版本，否则后续写入仍使用旧前缀
+		return result, fmt.Errorf("alias switched to %s but set index version to %d failed: %w", newIndex, result.ToVersion, err)
+	}
+	w.version = result.ToVersion
+
+	if keepOld {
+		if err = client.SAdd(ctx, fmt.Sprintf(wikiKeptVersionsKey, w.UserId, w.RootId), result.FromVersion).Err(); err != nil {
+			return result, fmt.Errorf("record kept version %d failed: %w", result.FromVersion, err)
+		}
+		return result, nil
+	}
+	if err = deleteKeys(ctx, client, keys); err != nil {
+		return result, err
+	}
+	result.Removed = len(keys)
+	return result, nil
+}
+
+// keptVersions 迁移时保留了旧分块的索引版本
+func (w *Wiki) keptVersions(ctx context.Context, client *redis.Client) ([]int, error) {
+	members, err := client.SMembers(ctx, fmt.Sprintf(wikiKeptVersionsKey, w.UserId, w.RootId)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get kept versions failed: %w", err)
+	}
+	versions := make([]int, 0, len(members))
+	for _, m := range members {
+		version, err := strconv.Atoi(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kept version %q", m)
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// keptKeys 保留的旧版本分块中匹配pattern(不含前缀)的键
+func (w *Wiki) keptKeys(ctx context.Context, client *redis.Client, pattern string) ([]string, error) {
+	versions, err := w.keptVersions(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, version := range versions {
+		// 当前版本的分块不属于保留的旧分块
+		if version == w.version {
+			continue
+		}
+		versionKeys, err := scanKeys(ctx, client, keyPrefixOf(w.UserId, w.RootId, version)+pattern)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, versionKeys...)
+	}
+	return keys, nil
+}
+
+// DeleteKeptVersions 删除迁移时保留的旧版本分块，返回删除的分块数
+func (w *Wiki) DeleteKeptVersions(ctx context.Context, client *redis.Client) (int, error) {
+	if w.UserId == 0 || w.RootId == 0 {
+		return 0, fmt.Errorf("invalid user_id or root_id")
+	}
+	if err := w.loadIndexState(ctx, client); err != nil {
+		return 0, err
+	}
+	keys, err := w.keptKeys(ctx, client, "*")
+	if err != nil {
+		return 0, err
+	}
+	if err = deleteKeys(ctx, client, keys); err != nil {
+		return 0, err
+	}
+	if err = client.Del(ctx, fmt.Sprintf(wikiKeptVersionsKey, w.UserId, w.RootId)).Err(); err != nil {
+		return len(keys), fmt.Errorf("delete kept versions failed: %w", err)
+	}
+	return len(keys), nil
+}
+
+// migrateChunks 读取一批旧分块，重新计算内容和标题向量后写入新前缀，其余字段原样复制
+func migrateChunks(ctx context.Context, client *redis.Client, emb embedding.Embedder, keys []string, oldPrefix, newPrefix string, dimension int, vectorType string) error {
+	pipe := client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HGetAll(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("read chunks failed: %w", err)
+	}
+
+	var chunks []string
+	var fields []map[string]string
+	var texts []string
+	for i, cmd := range cmds {
+		// 扫描后被删除的分块
+		if len(cmd.Val()) == 0 {
+			continue
+		}
+		chunks = append(chunks, keys[i])
+		fields = append(fields, cmd.Val())
+		texts = append(texts, cmd.Val()[customContentFieldName], cmd.Val()[customTitleFieldName])
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	vectors, err := emb.EmbedStrings(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embed chunks failed: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return fmt.Errorf("embedding returned %d vectors for %d texts", len(vectors), len(texts))
+	}
+
+	pipe = client.Pipeline()
+	for i, key := range chunks {
+		contentVec, titleVec := vectors[2*i], vectors[2*i+1]
+		if len(contentVec) != dimension || len(titleVec) != dimension {
+			return fmt.Errorf("embedding dimension %d does not match configured dimension %d", len(contentVec), dimension)
+		}
+		values := make(map[string]any, len(fields[i]))
+		for k, v := range fields[i] {
+			values[k] = v
+		}
+		values[customContentVectorFieldName] = encodeVector(contentVec, vectorType)
+		values[customTitleVectorFieldName] = encodeVector(titleVec, vectorType)
+		pipe.HSet(ctx, newPrefix+strings.TrimPrefix(key, oldPrefix), values)
+	}
+	if _, err = pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("write migrated chunks failed: %w", err)
+	}
+	return nil
+}
+
+// swapIndexAlias 把别名切换到新索引并删除旧索引(保留旧分块)。
+// 未迁移过的知识库旧索引与别名同名，需先删除旧索引才能创建别名
+func swapIndexAlias(ctx context.Context, client *redis.Client, alias string, fromVersion int, newIndex, oldIndex string) error {
+	if fromVersion == 0 {
+		if err := dropIndex(ctx, client, oldIndex, false); err != nil {
+			return fmt.Errorf("drop old index failed: %w", err)
+		}
+		if err := client.FTAliasAdd(ctx, newIndex, alias).Err(); err != nil {
+			return fmt.Errorf("add index alias failed: %w", err)
+		}
+		return nil
+	}
+
+	if err := client.FTAliasUpdate(ctx, newIndex, alias).Err(); err != nil {
+		return fmt.Errorf("update index alias failed: %w", err)
+	}
+	if err := dropIndex(ctx, client, oldIndex, false); err != nil {
+		return fmt.Errorf("drop old index failed: %w", err)
+	}
+	return nil
+}
+
+// scanKeys 获取匹配pattern的所有键
+func scanKeys(ctx context.Context, client *redis.Client, pattern string) ([]string, error) {
+	var keys []string
+	iter := client.Scan(ctx, 0, pattern, deleteBatchSize).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("scan keys failed: %w", err)
+	}
+	return keys, nil
+}
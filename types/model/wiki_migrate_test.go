@@ -0,0 +1,36 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVersionedIndexNames(t *testing.T) {
+	if got := keyPrefixOf(1, 2, 0); got != "wiki_1_2:" {
+		t.Errorf("keyPrefixOf v0 = %q", got)
+	}
+	if got := indexNameOf(1, 2, 0); got != "index_wiki_1_2" {
+		t.Errorf("indexNameOf v0 = %q", got)
+	}
+	if got := keyPrefixOf(1, 2, 3); got != "wiki_1_2_v3:" {
+		t.Errorf("keyPrefixOf v3 = %q", got)
+	}
+	if got := indexNameOf(1, 2, 3); got != "index_wiki_1_2_v3" {
+		t.Errorf("indexNameOf v3 = %q", got)
+	}
+
+	// 各版本的键前缀互不包含，新索引不会收录旧分块
+	prefixes := []string{keyPrefixOf(1, 2, 0), keyPrefixOf(1, 2, 1), keyPrefixOf(1, 2, 10), keyPrefixOf(1, 22, 0)}
+	for i, a := range prefixes {
+		for j, b := range prefixes {
+			if i != j && strings.HasPrefix(b, a) {
+				t.Errorf("prefix %q overlaps %q", a, b)
+			}
+		}
+	}
+
+	w := &Wiki{UserId: 1, RootId: 2, version: 3}
+	if w.indexName() != "index_wiki_1_2" || w.keyPrefix() != "wiki_1_2_v3:" {
+		t.Errorf("wiki names = %q, %q", w.indexName(), w.keyPrefix())
+	}
+}
@@ -0,0 +1,307 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/redis/go-redis/v9"
+)
+
+// 知识库检索模式
+const (
+	SearchModeVector = "vector" // 只按内容向量检索
+	SearchModeHybrid = "hybrid" // 全文、内容向量、标题向量三路召回后RRF融合
+)
+
+// 检索结果元数据中的分数字段
+const (
+	MetaScore            = "score"              // 排序分数(0-1)，vector模式为向量相似度，hybrid模式为归一化的RRF分数，重排后为重排分数
+	MetaBM25Score        = "bm25_score"         // 全文检索BM25分数
+	MetaVectorScore      = "vector_score"       // 内容向量相似度(0-1)，两种模式都有，用于阈值过滤
+	MetaTitleVectorScore = "title_vector_score" // 标题向量相似度
+	MetaRRFScore         = "rrf_score"          // RRF融合分数
+)
+
+// 检索结果元数据中的来源字段，用于生成引用
+const (
+	MetaTitle   = customTitleFieldName   // 分块标题
+	MetaSource  = customSourceFieldName  // 文档来源（文件路径或URL）
+	MetaWikiID  = customWikiIDFieldName  // 文章ID
+	MetaChunkID = customChunkIDFieldName // 分块序号
+	MetaPage    = customPageFieldName    // 页码，仅PDF有
+)
+
+const (
+	defaultRRFK      = 60
+	searchLanguage   = "chinese"
+	vectorScoreAlias = "__score"
+)
+
+// HybridSearchOptions 混合检索参数
+type HybridSearchOptions struct {
+	Candidates int            // 每一路召回的数量
+	RRFK       int            // RRF平滑常数，默认60
+	Weights    []float64      // 全文、内容向量、标题向量三路的权重，默认均为1
+	Filter     map[string]any // 过滤条件，见BuildFilterQuery
+}
+
+// VectorSearch 按内容向量检索，MetaScore为余弦相似度
+func (w *Wiki) VectorSearch(ctx context.Context, query string, topK int, filter map[string]any) ([]*schema.Document, error) {
+	if w.client == nil || w.embedder == nil {
+		return nil, fmt.Errorf("wiki not initialized, call Init() first")
+	}
+	filterQuery, err := BuildFilterQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	vec, err := w.embedQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := w.knnSearch(ctx, customContentVectorFieldName, vec, topK, MetaVectorScore, filterQuery)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		doc.MetaData[MetaScore] = doc.MetaData[MetaVectorScore]
+	}
+	return docs, nil
+}
+
+// HybridSearch 全文检索、内容向量和标题向量三路召回，使用倒数排名融合(RRF)合并结果
+func (w *Wiki) HybridSearch(ctx context.Context, query string, opts HybridSearchOptions) ([]*schema.Document, error) {
+	if w.client == nil || w.embedder == nil {
+		return nil, fmt.Errorf("wiki not initialized, call Init() first")
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query cannot be empty")
+	}
+	if opts.Candidates <= 0 {
+		opts.Candidates = 20
+	}
+	filterQuery, err := BuildFilterQuery(opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	vec, err := w.embedQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	textDocs, err := w.fullTextSearch(ctx, query, opts.Candidates, filterQuery)
+	if err != nil {
+		return nil, err
+	}
+	contentDocs, err := w.knnSearch(ctx, customContentVectorFieldName, vec, opts.Candidates, MetaVectorScore, filterQuery)
+	if err != nil {
+		return nil, err
+	}
+	titleDocs, err := w.knnSearch(ctx, customTitleVectorFieldName, vec, opts.Candidates, MetaTitleVectorScore, filterQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := FuseRRF([][]*schema.Document{textDocs, contentDocs, titleDocs}, opts.RRFK, opts.Weights)
+	fillVectorScore(docs, contentDocs)
+	return docs, nil
+}
+
+// fillVectorScore 只由全文或标题向量召回的片段没有内容向量相似度，取内容向量召回结果中的最低分。
+// 这些片段不在内容向量的前N个结果中，实际相似度不高于该值，保证阈值过滤与vector模式使用同一个尺度
+func fillVectorScore(docs, contentDocs []*schema.Document) {
+	floor := 0.0
+	for i, doc := range contentDocs {
+		if score, ok := doc.MetaData[MetaVectorScore].(float64); ok && (i == 0 || score < floor) {
+			floor = score
+		}
+	}
+	for _, doc := range docs {
+		if _, ok := doc.MetaData[MetaVectorScore].(float64); !ok {
+			doc.MetaData[MetaVectorScore] = floor
+		}
+	}
+}
+
+// FuseRRF 倒数排名融合：score(d) = Σ weight_i / (k + rank_i(d))。
+// 同一文档各路的分数合并到一个结果中，MetaScore为按最大可能值归一化后的RRF分数
+func FuseRRF(lists [][]*schema.Document, k int, weights []float64) []*schema.Document {
+	if k <= 0 {
+		k = defaultRRFK
+	}
+	weightOf := func(i int) float64 {
+		if i < len(weights) && weights[i] > 0 {
+			return weights[i]
+		}
+		return 1
+	}
+
+	var maxScore float64
+	fused := make(map[string]*schema.Document)
+	scores := make(map[string]float64)
+	order := make([]string, 0)
+	for i, docs := range lists {
+		maxScore += weightOf(i) / float64(k+1)
+		for rank, doc := range docs {
+			merged, ok := fused[doc.ID]
+			if !ok {
+				merged = &schema.Document{ID: doc.ID, Content: doc.Content, MetaData: map[string]any{}}
+				fused[doc.ID] = merged
+				order = append(order, doc.ID)
+			}
+			if merged.Content == "" {
+				merged.Content = doc.Content
+			}
+			for key, value := range doc.MetaData {
+				merged.MetaData[key] = value
+			}
+			scores[doc.ID] += weightOf(i) / float64(k+rank+1)
+		}
+	}
+
+	result := make([]*schema.Document, 0, len(order))
+	for _, id := range order {
+		doc := fused[id]
+		doc.MetaData[MetaRRFScore] = scores[id]
+		doc.MetaData[MetaScore] = scores[id] / maxScore
+		result = append(result, doc)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return scores[result[i].ID] > scores[result[j].ID]
+	})
+	return result
+}
+
+// embedQuery 计算查询向量
+func (w *Wiki) embedQuery(ctx context.Context, query string) ([]float64, error) {
+	if w.embedder == nil {
+		return nil, fmt.Errorf("embedder not initialized, call Init() first")
+	}
+	vectors, err := w.embedder.EmbedStrings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query failed: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embed query failed: got %d vectors", len(vectors))
+	}
+	return vectors[0], nil
+}
+
+// knnSearch 在指定向量字段上做KNN检索，scoreKey记录余弦相似度，filterQuery不为空时作为预过滤
+func (w *Wiki) knnSearch(ctx context.Context, field string, vec []float64, topK int, scoreKey, filterQuery string) ([]*schema.Document, error) {
+	indexName := w.indexName()
+	prefilter := "*"
+	if filterQuery != "" {
+		prefilter = "(" + filterQuery + ")"
+	}
+	query := fmt.Sprintf("%s=>[KNN $K @%s $vec AS %s]", prefilter, field, vectorScoreAlias)
+	res, err := w.client.FTSearchWithArgs(ctx, indexName, query, &redis.FTSearchOptions{
+		Params: map[string]any{
+			"K":   topK,
+			"vec": encodeVector(vec, w.indexConfig.VectorType),
+		},
+		Return:         append(searchReturnFields(), redis.FTSearchReturn{FieldName: vectorScoreAlias}),
+		SortBy:         []redis.FTSearchSortBy{{FieldName: vectorScoreAlias, Asc: true}},
+		Limit:          topK,
+		DialectVersion: 2,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("knn search on %s failed: %w", field, err)
+	}
+
+	docs := make([]*schema.Document, 0, len(res.Docs))
+	for _, d := range res.Docs {
+		doc := w.convertSearchDoc(d)
+		// 距离转为相似度
+		if distance, err := strconv.ParseFloat(d.Fields[vectorScoreAlias], 64); err == nil {
+			doc.MetaData[scoreKey] = w.indexConfig.similarity(distance)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// fullTextSearch 在content和title上做BM25全文检索
+func (w *Wiki) fullTextSearch(ctx context.Context, query string, topK int, filterQuery string) ([]*schema.Document, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	indexName := w.indexName()
+	ftQuery := fmt.Sprintf("@%s|%s:(%s)", customContentFieldName, customTitleFieldName, strings.Join(terms, "|"))
+	if filterQuery != "" {
+		ftQuery = filterQuery + " " + ftQuery
+	}
+	res, err := w.client.FTSearchWithArgs(ctx, indexName, ftQuery, &redis.FTSearchOptions{
+		WithScores:     true,
+		Scorer:         "BM25",
+		Language:       searchLanguage,
+		Return:         searchReturnFields(),
+		Limit:          topK,
+		DialectVersion: 2,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("full text search failed: %w", err)
+	}
+
+	docs := make([]*schema.Document, 0, len(res.Docs))
+	for _, d := range res.Docs {
+		doc := w.convertSearchDoc(d)
+		if d.Score != nil {
+			doc.MetaData[MetaBM25Score] = *d.Score
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func (w *Wiki) convertSearchDoc(d redis.Document) *schema.Document {
+	keyPrefix := w.keyPrefix()
+	doc := &schema.Document{
+		ID:       strings.TrimPrefix(d.ID, keyPrefix),
+		Content:  d.Fields[customContentFieldName],
+		MetaData: map[string]any{},
+	}
+	for _, field := range []string{customTitleFieldName, customFileTypeFieldName, customSourceFieldName, customWikiIDFieldName, customParentIDFieldName, customChunkIDFieldName, customPageFieldName} {
+		if v, ok := d.Fields[field]; ok {
+			doc.MetaData[field] = v
+		}
+	}
+	return doc
+}
+
+func searchReturnFields() []redis.FTSearchReturn {
+	return []redis.FTSearchReturn{
+		{FieldName: customContentFieldName},
+		{FieldName: customTitleFieldName},
+		{FieldName: customFileTypeFieldName},
+		{FieldName: customSourceFieldName},
+		{FieldName: customWikiIDFieldName},
+		{FieldName: customParentIDFieldName},
+		{FieldName: customChunkIDFieldName},
+		{FieldName: customPageFieldName},
+	}
+}
+
+// searchTerms 把查询拆成全文检索的词项，去掉RediSearch查询语法中的特殊字符
+func searchTerms(query string) []string {
+	fields := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	terms := make([]string, 0, len(fields))
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		f = strings.ToLower(f)
+		if !seen[f] {
+			seen[f] = true
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestFuseRRF(t *testing.T) {
+	doc := func(id string, meta map[string]any) *schema.Document {
+		return &schema.Document{ID: id, Content: "content " + id, MetaData: meta}
+	}
+	text := []*schema.Document{doc("a", map[string]any{MetaBM25Score: 3.2}), doc("b", map[string]any{})}
+	content := []*schema.Document{doc("b", map[string]any{MetaVectorScore: 0.9}), doc("c", map[string]any{})}
+	title := []*schema.Document{doc("b", map[string]any{MetaTitleVectorScore: 0.8})}
+
+	fused := FuseRRF([][]*schema.Document{text, content, title}, 60, nil)
+
+	ids := make([]string, len(fused))
+	for i, d := range fused {
+		ids[i] = d.ID
+	}
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("fused order = %v, want %v", ids, want)
+	}
+
+	b := fused[0]
+	wantRRF := 1.0/62 + 1.0/61 + 1.0/61
+	if got := b.MetaData[MetaRRFScore].(float64); math.Abs(got-wantRRF) > 1e-12 {
+		t.Errorf("rrf score = %v, want %v", got, wantRRF)
+	}
+	if got := b.MetaData[MetaScore].(float64); math.Abs(got-wantRRF/(3.0/61)) > 1e-12 {
+		t.Errorf("normalized score = %v", got)
+	}
+	if b.MetaData[MetaVectorScore] != 0.9 || b.MetaData[MetaTitleVectorScore] != 0.8 {
+		t.Errorf("per-route scores not merged: %v", b.MetaData)
+	}
+
+	// 提高内容向量权重后，只被内容向量召回的c排到a前面
+	weighted := FuseRRF([][]*schema.Document{text, content, title}, 60, []float64{1, 2, 1})
+	if weighted[1].ID != "c" {
+		t.Errorf("weighted fusion second = %s, want c", weighted[1].ID)
+	}
+}
+
+func TestFillVectorScore(t *testing.T) {
+	content := []*schema.Document{
+		{ID: "b", MetaData: map[string]any{MetaVectorScore: 0.9}},
+		{ID: "c", MetaData: map[string]any{MetaVectorScore: 0.6}},
+	}
+	docs := []*schema.Document{
+		{ID: "b", MetaData: map[string]any{MetaVectorScore: 0.9}},
+		{ID: "a", MetaData: map[string]any{MetaBM25Score: 3.2}},
+	}
+	fillVectorScore(docs, content)
+	// 只由全文召回的a取内容向量召回结果中的最低分
+	if docs[0].MetaData[MetaVectorScore] != 0.9 || docs[1].MetaData[MetaVectorScore] != 0.6 {
+		t.Fatalf("vector scores mismatch: %v, %v", docs[0].MetaData, docs[1].MetaData)
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	got := searchTerms("Go语言 channel-select, @title:(注入) Channel")
+	want := []string{"go语言", "channel", "select", "title", "注入"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("searchTerms = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeVector(t *testing.T) {
+	buf := encodeVector([]float64{1, -0.5}, VectorTypeFloat32)
+	if len(buf) != 8 {
+		t.Fatalf("len = %d, want 8", len(buf))
+	}
+	if v := math.Float32frombits(binary.LittleEndian.Uint32(buf[4:])); v != -0.5 {
+		t.Errorf("second value = %v, want -0.5", v)
+	}
+}
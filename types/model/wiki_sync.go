@@ -0,0 +1,220 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	deleteBatchSize = 500 // 每次批量删除的键数量
+	syncBatchSize   = 20  // 每次写入索引的分块数量，每批写入后回报进度
+)
+
+// SyncResult 增量更新文章的结果
+type SyncResult struct {
+	Total   int // 去重后的分块总数
+	Added   int // 新增的分块数，需要重新计算向量
+	Skipped int // 内容未变化的分块数，只更新元数据
+	Removed int // 删除的旧分块数
+}
+
+// ContentHash 分块内容哈希，标题或内容变化时哈希变化
+func ContentHash(title, content string) string {
+	sum := sha256.Sum256([]byte(title + "\x00" + content))
+	return hex.EncodeToString(sum[:8])
+}
+
+// chunkKey 分块在索引中的ID(不含前缀)：doc_文章ID_内容哈希
+func (w *Wiki) chunkKey(doc *schema.Document) string {
+	title, _ := doc.MetaData["title"].(string)
+	return fmt.Sprintf("doc_%d_%s", w.ID, ContentHash(title, doc.Content))
+}
+
+// ChunkKey 分块在索引中的文档ID（不含键前缀），与导入时写入的键一致
+func (w *Wiki) ChunkKey(doc *schema.Document) string {
+	prepareDocuments([]*schema.Document{doc})
+	return w.chunkKey(doc)
+}
+
+// metadataFields 分块中不需要计算向量的字段，内容未变化的分块更新时只重写这些字段
+func (w *Wiki) metadataFields(doc *schema.Document) map[string]string {
+	fields := map[string]string{
+		customWikiIDFieldName:    strconv.FormatUint(uint64(w.ID), 10),
+		customParentIDFieldName:  strconv.FormatUint(uint64(w.ParentID), 10),
+		customFolderIDsFieldName: joinIDs(w.folderIDs),
+	}
+	for _, field := range []string{customFileTypeFieldName, customSourceFieldName} {
+		if v, ok := doc.MetaData[field].(string); ok && v != "" {
+			fields[field] = v
+		}
+	}
+	// 引用来源：分块序号和页码
+	for _, field := range []string{customChunkIDFieldName, customPageFieldName} {
+		if v, ok := doc.MetaData[field].(int); ok {
+			fields[field] = strconv.Itoa(v)
+		}
+	}
+	return fields
+}
+
+// documentKeys 获取文章在Redis中的所有分块键(含前缀)，包括旧版本以时间戳命名的分块
+func (w *Wiki) documentKeys(ctx context.Context) ([]string, error) {
+	if w.client == nil {
+		return nil, fmt.Errorf("wiki not initialized, call Init() first")
+	}
+	if w.ID == 0 {
+		return nil, fmt.Errorf("invalid wiki id")
+	}
+
+	return scanKeys(ctx, w.client, w.keyPrefix()+fmt.Sprintf("doc_%d_*", w.ID))
+}
+
+// deleteKeys 分批删除键
+func deleteKeys(ctx context.Context, client *redis.Client, keys []string) error {
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		end := min(start+deleteBatchSize, len(keys))
+		if err := client.Del(ctx, keys[start:end]...).Err(); err != nil {
+			return fmt.Errorf("delete wiki documents failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// Sync 按内容哈希增量更新文章的分块：内容未变化的分块只更新元数据，
+// 新分块计算向量后写入，已不存在的分块从索引中删除。
+// onProgress不为nil时，每批分块写入后回报当前进度
+func (w *Wiki) Sync(ctx context.Context, docs []*schema.Document, onProgress func(SyncResult)) (SyncResult, error) {
+	var result SyncResult
+	if w.client == nil || w.embedder == nil {
+		return result, fmt.Errorf("wiki not initialized, call Init() first")
+	}
+	if len(docs) == 0 {
+		return result, fmt.Errorf("no documents to store")
+	}
+	prepareDocuments(docs)
+
+	existing, err := w.documentKeys(ctx)
+	if err != nil {
+		return result, err
+	}
+	keyPrefix := w.keyPrefix()
+	existingSet := make(map[string]bool, len(existing))
+	for _, key := range existing {
+		existingSet[key] = true
+	}
+
+	wanted := make(map[string]bool, len(docs))
+	var added []*schema.Document
+	pipe := w.client.Pipeline()
+	for _, doc := range docs {
+		key := keyPrefix + w.chunkKey(doc)
+		// 同一文章中内容相同的分块只保留一个
+		if wanted[key] {
+			continue
+		}
+		wanted[key] = true
+		if existingSet[key] {
+			pipe.HSet(ctx, key, w.metadataFields(doc))
+			result.Skipped++
+			continue
+		}
+		added = append(added, doc)
+	}
+	result.Total = len(wanted)
+	if result.Skipped > 0 {
+		if _, err = pipe.Exec(ctx); err != nil {
+			return result, fmt.Errorf("update wiki document metadata failed: %w", err)
+		}
+	}
+	report := func() {
+		if onProgress != nil {
+			onProgress(result)
+		}
+	}
+	report()
+
+	// 先写入新分块再删除旧分块，写入失败时保留旧内容。
+	// 已写入的分块键由内容决定，失败重试时会被识别为未变化而跳过
+	for start := 0; start < len(added); start += syncBatchSize {
+		batch := added[start:min(start+syncBatchSize, len(added))]
+		if err = w.storeChunks(ctx, batch); err != nil {
+			return result, fmt.Errorf("store documents failed: %w", err)
+		}
+		result.Added += len(batch)
+		report()
+	}
+
+	var stale []string
+	for _, key := range existing {
+		if !wanted[key] {
+			stale = append(stale, key)
+		}
+	}
+	if err = deleteKeys(ctx, w.client, stale); err != nil {
+		return result, err
+	}
+	result.Removed = len(stale)
+	report()
+
+	return result, nil
+}
+
+// storeChunks 计算分块的内容和标题向量，按索引的向量类型编码后写入。
+// 分块键由文章ID和内容哈希组成，内容不变时键不变，更新文章时可跳过
+func (w *Wiki) storeChunks(ctx context.Context, docs []*schema.Document) error {
+	texts := make([]string, 0, 2*len(docs))
+	for _, doc := range docs {
+		title, _ := doc.MetaData["title"].(string)
+		texts = append(texts, doc.Content, title)
+	}
+	vectors, err := w.embedder.EmbedStrings(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embed documents failed: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return fmt.Errorf("embedding returned %d vectors for %d texts", len(vectors), len(texts))
+	}
+
+	keyPrefix := w.keyPrefix()
+	pipe := w.client.Pipeline()
+	for i, doc := range docs {
+		fields := map[string]any{
+			customContentFieldName:       doc.Content,
+			customTitleFieldName:         texts[2*i+1],
+			customContentVectorFieldName: encodeVector(vectors[2*i], w.indexConfig.VectorType),
+			customTitleVectorFieldName:   encodeVector(vectors[2*i+1], w.indexConfig.VectorType),
+			customLoadedAtFieldName:      strconv.FormatInt(w.loadedAt, 10),
+		}
+		for field, value := range w.metadataFields(doc) {
+			fields[field] = value
+		}
+		pipe.HSet(ctx, keyPrefix+w.chunkKey(doc), fields)
+	}
+	if _, err = pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("write documents failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteDocuments 从索引中删除文章的所有分块，包括迁移时保留的旧版本分块，返回删除的分块数
+func (w *Wiki) DeleteDocuments(ctx context.Context) (int, error) {
+	keys, err := w.documentKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	kept, err := w.keptKeys(ctx, w.client, fmt.Sprintf("doc_%d_*", w.ID))
+	if err != nil {
+		return 0, err
+	}
+	keys = append(keys, kept...)
+	if err = deleteKeys(ctx, w.client, keys); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
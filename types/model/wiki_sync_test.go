@@ -0,0 +1,60 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestChunkKey(t *testing.T) {
+	w := &Wiki{}
+	w.ID = 7
+	doc := func(title, content string) *schema.Document {
+		return &schema.Document{Content: content, MetaData: map[string]any{"title": title, "chunk_id": 1}}
+	}
+
+	key := w.chunkKey(doc("并发", "goroutine是轻量级线程"))
+	if !strings.HasPrefix(key, "doc_7_") {
+		t.Fatalf("unexpected key %q", key)
+	}
+	if got := w.chunkKey(doc("并发", "goroutine是轻量级线程")); got != key {
+		t.Errorf("same content should have same key: %q != %q", got, key)
+	}
+	if got := w.chunkKey(doc("并发", "channel用于通信")); got == key {
+		t.Error("different content should have different key")
+	}
+	if got := w.chunkKey(doc("调度", "goroutine是轻量级线程")); got == key {
+		t.Error("different title should have different key")
+	}
+}
+
+func TestMetadataFields(t *testing.T) {
+	w := &Wiki{ParentID: 3}
+	w.ID = 7
+	w.SetFolderIDs([]uint{3, 1})
+	fields := w.metadataFields(&schema.Document{MetaData: map[string]any{
+		"file_type": ".pdf",
+		"source":    "upload/a.pdf",
+		"page":      2,
+		"chunk_id":  1,
+	}})
+
+	want := map[string]string{
+		"wiki_id":    "7",
+		"parent_id":  "3",
+		"folder_ids": "3,1",
+		"file_type":  ".pdf",
+		"source":     "upload/a.pdf",
+		"page":       "2",
+		"chunk_id":   "1",
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("got %v, want %v", fields, want)
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("field %s = %q, want %q", k, fields[k], v)
+		}
+	}
+}
@@ -1,31 +1,50 @@
 package req
 
+import (
+	"ai_jianli_go/types/model"
+)
+
 type CreateMeetingReq struct {
-	UserID         uint   `json:"user_id"`                      // 用户ID
-	Candidate      string `json:"candidate" binding:"required"` // 候选人
-	Position       string `json:"position" binding:"required"`  // 职位
-	JobDescription string `json:"job_description"`              // 职位描述
-	Time           int64  `json:"time"`                         // 面试时间
-	Status         string `json:"status"`                       // 面试状态
-	Remark         string `json:"remark"`                       // 备注
-	WikiID          uint   `json:"wiki_id"`                      // 知识库ID
+	UserID         uint                 `json:"user_id"`                         // 用户ID
+	Candidate      string               `json:"candidate" binding:"required"`    // 候选人
+	Position       string               `json:"position" binding:"required"`     // 职位
+	JobDescription string               `json:"job_description"`                 // 职位描述
+	Time           int64                `json:"time"`                            // 面试时间
+	Status         string               `json:"status"`                          // 面试状态
+	Remark         string               `json:"remark"`                          // 备注
+	WikiID         uint                 `json:"wiki_id"`                         // 知识库ID
+	WikiFolderID   uint                 `json:"wiki_folder_id"`                  // 知识库文件夹ID，不为0时只从该文件夹检索
+	Plan           *model.InterviewPlan `json:"plan"`                            // 面试计划，为空时使用默认计划
+	Stages         []MeetingStageReq    `json:"stages" binding:"omitempty,dive"` // 面试阶段，按顺序进行，为空时为单阶段面试
+}
+
+type MeetingStageReq struct {
+	Name         string               `json:"name" binding:"required"`                               // 阶段名称
+	Type         string               `json:"type" binding:"required,oneof=hr technical behavioral"` // 阶段类型
+	Persona      string               `json:"persona"`                                               // 面试官人设
+	WikiID       uint                 `json:"wiki_id"`                                               // 知识库ID
+	WikiFolderID uint                 `json:"wiki_folder_id"`                                        // 知识库文件夹ID，不为0时只从该文件夹检索
+	Rubric       string               `json:"rubric"`                                                // 评分标准
+	Plan         *model.InterviewPlan `json:"plan"`                                                  // 阶段面试计划，为空时使用面试计划
 }
 
 type UpdateMeetingReq struct {
-	ID               uint   `json:"id" binding:"required"` // 面试ID
-	UserID           uint   `json:"user_id"`               // 用户ID
-	Candidate        string `json:"candidate"`             // 候选人
-	Position         string `json:"position"`              // 职位
-	JobDescription   string `json:"job_description"`       // 职位描述
-	Time             int64  `json:"time"`                  // 面试时间
-	Status           string `json:"status"`                // 面试状态
-	Remark           string `json:"remark"`                // 备注
-	InterviewRecord  string `json:"interview_record"`      // 面试记录
-	InterviewSummary string `json:"interview_summary"`     // 面试总结
+	ID               uint                 `json:"id" binding:"required"` // 面试ID
+	UserID           uint                 `json:"user_id"`               // 用户ID
+	Candidate        string               `json:"candidate"`             // 候选人
+	Position         string               `json:"position"`              // 职位
+	JobDescription   string               `json:"job_description"`       // 职位描述
+	Time             int64                `json:"time"`                  // 面试时间
+	Status           string               `json:"status"`                // 面试状态
+	Remark           string               `json:"remark"`                // 备注
+	InterviewRecord  string               `json:"interview_record"`      // 面试记录
+	InterviewSummary string               `json:"interview_summary"`     // 面试总结
+	Plan             *model.InterviewPlan `json:"plan"`                  // 面试计划
 }
 
 type GetMeetingReq struct {
-	ID uint `json:"id" binding:"required"`
+	UserID uint `json:"user_id"` // 用户ID
+	ID     uint `json:"id" binding:"required"`
 }
 
 type UploadResumeReq struct {
@@ -35,12 +54,40 @@ type UploadResumeReq struct {
 }
 
 type AIInterviewReq struct {
-	UserID    uint   `json:"user_id"`                       // 用户ID
-	MeetingID uint   `json:"meeting_id" binding:"required"` // 面试ID
-	Answer    string `json:"answer" binding:"required"`     // 应聘者回答
+	UserID        uint   `json:"user_id"`                       // 用户ID
+	MeetingID     uint   `json:"meeting_id" binding:"required"` // 面试ID
+	Answer        string `json:"answer" binding:"required"`     // 应聘者回答
+	RecognitionID string `json:"recognition_id"`                // 语音回答的识别ID（语音识别接口返回），用于分析语速、停顿和口头禅
 }
 
 type GetRemarkReq struct {
+	UserID    uint `json:"user_id"`                       // 用户ID
+	MeetingID uint `json:"meeting_id" binding:"required"` // 面试ID
+	Version   int  `json:"version"`                       // 报告版本，为0时获取最新版本
+}
+
+type RegenerateRemarkReq struct {
+	UserID    uint `json:"user_id"`                       // 用户ID
+	MeetingID uint `json:"meeting_id" binding:"required"` // 面试ID
+	StageID   uint `json:"stage_id"`                      // 阶段ID，为0时重新生成整场面试的报告
+}
+
+type ExportReportReq struct {
 	UserID    uint   `json:"user_id"`                       // 用户ID
 	MeetingID uint   `json:"meeting_id" binding:"required"` // 面试ID
-}
\ No newline at end of file
+	Format    string `json:"format"`                        // 导出格式: pdf/md/html，默认pdf
+	Version   int    `json:"version"`                       // 报告版本，为0时使用最新版本
+}
+
+type CompareMeetingReq struct {
+	UserID     uint   `json:"user_id"`     // 用户ID
+	Position   string `json:"position"`    // 职位，未指定面试ID时对比该职位下的所有面试
+	MeetingIDs []uint `json:"meeting_ids"` // 参与对比的面试ID
+	Summary    bool   `json:"summary"`     // 是否生成排名靠前候选人的差异总结
+	TopN       int    `json:"top_n"`       // 差异总结包含的候选人数，默认3
+}
+
+type NextStageReq struct {
+	UserID    uint `json:"user_id"`                       // 用户ID
+	MeetingID uint `json:"meeting_id" binding:"required"` // 面试ID
+}
@@ -0,0 +1,37 @@
+package req
+
+type GenerateQuestionReq struct {
+	WikiID    uint `json:"wiki_id" form:"wiki_id" binding:"required"` // 文章ID
+	UserID    uint `json:"user_id" form:"user_id"`
+	MaxChunks int  `json:"max_chunks" form:"max_chunks"` // 最多根据多少个分块出题，默认5，最大10
+	PerChunk  int  `json:"per_chunk" form:"per_chunk"`   // 每个分块生成的题目数，默认2，最大5
+}
+
+type ListQuestionReq struct {
+	UserID   uint   `json:"user_id" form:"user_id"`     // 题目所属用户，管理员查询时为0表示所有用户
+	RootID   uint   `json:"root_id" form:"root_id"`     // 知识库ID
+	WikiID   uint   `json:"wiki_id" form:"wiki_id"`     // 文章ID
+	Status   string `json:"status" form:"status"`       // 审核状态: pending/approved/rejected，为空时不筛选
+	Page     int    `json:"page" form:"page"`           // 页码，从1开始
+	PageSize int    `json:"page_size" form:"page_size"` // 每页数量，默认20，最大100
+}
+
+type UpdateQuestionReq struct {
+	ID              uint     `json:"id" form:"id" binding:"required"`
+	UserID          uint     `json:"user_id" form:"user_id"`
+	Content         string   `json:"content"`          // 题目，为空时不修改
+	ReferenceAnswer string   `json:"reference_answer"` // 参考答案，为空时不修改
+	Difficulty      int      `json:"difficulty"`       // 难度1-5，为0时不修改
+	Tags            []string `json:"tags"`             // 标签，为nil时不修改
+}
+
+type ReviewQuestionReq struct {
+	IDs    []uint `json:"ids" binding:"required"`
+	UserID uint   `json:"user_id" form:"user_id"`
+	Status string `json:"status" binding:"required"` // 审核结果: approved/rejected，pending表示撤回审核
+}
+
+type DeleteQuestionReq struct {
+	ID     uint `json:"id" form:"id" binding:"required"`
+	UserID uint `json:"user_id" form:"user_id"`
+}
@@ -1,5 +1,7 @@
 package req
 
+import "ai_jianli_go/types/model"
+
 type CreateWikiRequest struct {
 	UserID   uint   `json:"user_id" form:"user_id"`
 	Title    string `json:"title" form:"title"`
@@ -27,13 +29,48 @@ type DeleteWikiRequest struct {
 }
 
 type UpdateWikiRequest struct {
-	ID      uint   `json:"id" form:"id" binding:"required"`
-	Title   string `json:"title" form:"title"`
-	Content string `json:"content" form:"content"`
+	ID     uint   `json:"id" form:"id" binding:"required"`
+	UserID uint   `json:"user_id" form:"user_id"`
+	Title  string `json:"title" form:"title"`
+	Url    string `json:"url" form:"url"` // 新的文章地址，上传新文件时为保存路径，为空时按原地址重新加载
 }
 
 type QueryWikiRequest struct {
-	Query  string `json:"query" form:"query"`
-	UserID uint   `json:"user_id" form:"user_id"`
-	RootId uint   `json:"root_id" form:"root_id"`
+	Query          string         `json:"query" form:"query"`
+	UserID         uint           `json:"user_id" form:"user_id"`
+	RootId         uint           `json:"root_id" form:"root_id"`
+	Mode           string         `json:"mode" form:"mode"`                       // 检索模式: hybrid(默认) / vector
+	TopK           int            `json:"top_k" form:"top_k"`                     // 返回的文档片段数量，默认10
+	ScoreThreshold float64        `json:"score_threshold" form:"score_threshold"` // 最低相关度(0-1)，按内容向量相似度过滤，与检索模式无关
+	Filter         map[string]any `json:"filter"`                                 // 过滤条件: file_type/source/wiki_id/parent_id/folder_id/loaded_after/loaded_before
+}
+
+type GetWikiJobRequest struct {
+	ID     uint `json:"id" form:"id" binding:"required"`
+	UserID uint `json:"user_id" form:"user_id"`
+}
+
+type ListWikiJobRequest struct {
+	WikiID uint `json:"wiki_id" form:"wiki_id" binding:"required"`
+	UserID uint `json:"user_id" form:"user_id"`
+}
+
+type GetEmbeddingUsageRequest struct {
+	UserID uint `json:"user_id" form:"user_id"`
+	Days   int  `json:"days" form:"days"` // 最近天数，默认7，最大90
+}
+
+type GetWikiIndexConfigRequest struct {
+	RootId uint `json:"root_id" form:"root_id" binding:"required"`
+	UserID uint `json:"user_id" form:"user_id"`
+}
+
+type UpdateWikiIndexConfigRequest struct {
+	RootId uint `json:"root_id" form:"root_id" binding:"required"`
+	UserID uint `json:"user_id" form:"user_id"`
+	model.WikiIndexConfig
+}
+
+type GetWikiIndexStatsRequest struct {
+	RootId uint `json:"root_id" form:"root_id" binding:"required"`
 }
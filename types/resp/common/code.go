@@ -52,6 +52,12 @@ const (
 	CodeGetRemarkFail
 	CodeMeetingNotCompleted
 	CodeMeetingCompleted
+	CodeInvalidInterviewPlan
+	CodeMeetingHasNoStage
+	CodeStageCompleted
+	CodeInvalidMeetingStage
+	CodeReportNotGenerated
+	CodeRecognitionNotFound
 )
 
 const (
@@ -86,6 +92,18 @@ const (
 	CodeCreateIndexFailed int64 = 2701 + iota
 	CodeCreateWikiFailed
 	CodeQueryWikiFailed
+	CodeUpdateWikiFailed
+	CodeDeleteWikiFailed
+	CodeRebuildIndexFailed
+	CodeGetIndexInfoFailed
+)
+
+const (
+	// 题库
+	CodeGenerateQuestionFail int64 = 2801 + iota
+	CodeQueryQuestionFail
+	CodeUpdateQuestionFail
+	CodeDeleteQuestionFail
 )
 
 const (
@@ -157,6 +175,12 @@ var Msg = map[int64]string{
 	CodeMeetingNotCompleted:   "面试未完成",
 	CodeGetMeetingFail:        "获取面试记录失败",
 	CodeMeetingCompleted:      "面试已完成",
+	CodeInvalidInterviewPlan:  "面试计划不合法",
+	CodeMeetingHasNoStage:     "该面试未设置面试阶段",
+	CodeStageCompleted:        "当前面试阶段已完成，请进入下一阶段",
+	CodeInvalidMeetingStage:   "面试阶段不合法",
+	CodeReportNotGenerated:    "面试还没有评价报告，请先获取评价报告",
+	CodeRecognitionNotFound:   "语音识别结果不存在或已过期",
 
 	// 简历
 	CodeUploadResumeFail:      "上传简历失败",
@@ -170,7 +194,17 @@ var Msg = map[int64]string{
 	CodeDeleteResumeFail:      "删除简历失败",
 
 	// 知识库
-	CodeCreateIndexFailed: "创建知识库索引失败",
-	CodeCreateWikiFailed:  "创建知识库失败",
-	CodeQueryWikiFailed:   "查询知识库失败",
+	CodeCreateIndexFailed:  "创建知识库索引失败",
+	CodeCreateWikiFailed:   "创建知识库失败",
+	CodeQueryWikiFailed:    "查询知识库失败",
+	CodeUpdateWikiFailed:   "更新知识库失败",
+	CodeDeleteWikiFailed:   "删除知识库失败",
+	CodeRebuildIndexFailed: "重建知识库索引失败",
+	CodeGetIndexInfoFailed: "获取知识库索引信息失败",
+
+	// 题库
+	CodeGenerateQuestionFail: "生成题目失败",
+	CodeQueryQuestionFail:    "查询题库失败",
+	CodeUpdateQuestionFail:   "修改题目失败",
+	CodeDeleteQuestionFail:   "删除题目失败",
 }
@@ -0,0 +1,9 @@
+package resp
+
+// ExportFile 导出的文件
+type ExportFile struct {
+	Name        string // 文件名
+	ASCIIName   string // 只含ASCII字符的文件名，用于不支持UTF-8文件名的客户端
+	ContentType string
+	Data        []byte
+}
@@ -0,0 +1,16 @@
+package resp
+
+import "ai_jianli_go/types/model"
+
+// QuestionListResp 题库分页列表
+type QuestionListResp struct {
+	Total int64             `json:"total"`
+	List  []*model.Question `json:"list"`
+}
+
+// GenerateQuestionResp 根据文章生成题目的结果，生成的题目均为待审核状态
+type GenerateQuestionResp struct {
+	Chunks    int               `json:"chunks"`    // 用于出题的分块数
+	Failed    int               `json:"failed"`    // 生成失败的分块数
+	Questions []*model.Question `json:"questions"` // 生成的题目
+}
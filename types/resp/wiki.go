@@ -0,0 +1,42 @@
+package resp
+
+import "ai_jianli_go/types/model"
+
+// WikiQueryResp 知识库问答结果，回答中的[n]对应Citations中Index为n的引用
+type WikiQueryResp struct {
+	Answer    string         `json:"answer"`
+	Citations []WikiCitation `json:"citations"`
+}
+
+// WikiCitation 回答引用的文档片段
+type WikiCitation struct {
+	Index   int     `json:"index"`    // 引用编号，从1开始
+	WikiID  uint    `json:"wiki_id"`  // 文章ID
+	Title   string  `json:"title"`    // 文章标题
+	Section string  `json:"section"`  // 分块标题或章节路径
+	Source  string  `json:"source"`   // 来源文件路径或URL，可通过 GET /wiki/file 获取
+	Page    int     `json:"page"`     // 页码，仅PDF有，从1开始
+	ChunkID int     `json:"chunk_id"` // 分块序号
+	Score   float64 `json:"score"`    // 相似度分数(0-1)
+	Snippet string  `json:"snippet"`  // 片段内容摘要
+	Cited   bool    `json:"cited"`    // 回答中是否引用了该片段
+}
+
+// WikiIndexStats 知识库索引的配置和运行统计
+type WikiIndexStats struct {
+	RootID               uint                  `json:"root_id"`
+	UserID               uint                  `json:"user_id"`
+	Title                string                `json:"title"`
+	IndexName            string                `json:"index_name"`              // 当前版本的索引名
+	Version              int                   `json:"version"`                 // 索引版本，迁移嵌入模型后递增
+	Config               model.WikiIndexConfig `json:"config"`                  // 向量索引配置
+	NumDocs              int                   `json:"num_docs"`                // 已索引的分块数
+	PercentIndexed       float64               `json:"percent_indexed"`         // 后台索引进度(0-1)，重建后小于1表示仍在索引
+	Indexing             bool                  `json:"indexing"`                // 是否正在后台索引
+	IndexingFailures     int                   `json:"indexing_failures"`       // 索引失败的分块数
+	LastIndexingError    string                `json:"last_indexing_error"`     // 最近一次索引失败原因
+	LastIndexingErrorKey string                `json:"last_indexing_error_key"` // 最近一次索引失败的分块键
+	VectorIndexSizeMB    float64               `json:"vector_index_size_mb"`    // 向量索引占用内存
+	TotalIndexMemoryMB   float64               `json:"total_index_memory_mb"`   // 索引总内存
+	DocTableSizeMB       float64               `json:"doc_table_size_mb"`       // 文档表占用内存
+}